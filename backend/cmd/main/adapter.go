@@ -117,11 +117,70 @@ func (a *GameServiceAdapter) SendPlayerListToPlayer(room interface{}, player *we
 	a.service.SendPlayerListToPlayer(gameRoom, playerAdapter)
 }
 
+// AddBot добавляет бота в комнату
+func (a *GameServiceAdapter) AddBot(room interface{}, skill game.BotSkill) error {
+	gameRoom, ok := room.(*game.Room)
+	if !ok {
+		return nil
+	}
+	_, err := a.service.AddBot(gameRoom, skill)
+	return err
+}
+
+// RemoveBot удаляет бота из комнаты
+func (a *GameServiceAdapter) RemoveBot(room interface{}, botID string) error {
+	gameRoom, ok := room.(*game.Room)
+	if !ok {
+		return nil
+	}
+	return a.service.RemoveBot(gameRoom, botID)
+}
+
+// RemoveBots удаляет всех ботов комнаты
+func (a *GameServiceAdapter) RemoveBots(room interface{}) {
+	gameRoom, ok := room.(*game.Room)
+	if !ok {
+		return
+	}
+	a.service.RemoveBots(gameRoom)
+}
+
+// StartRace запускает гонку в комнате
+func (a *GameServiceAdapter) StartRace(room interface{}) {
+	gameRoom, ok := room.(*game.Room)
+	if !ok {
+		return
+	}
+	a.service.StartRace(gameRoom)
+}
+
+// SetTeam переводит игрока в команду
+func (a *GameServiceAdapter) SetTeam(room interface{}, playerID string, team int) error {
+	gameRoom, ok := room.(*game.Room)
+	if !ok {
+		return nil
+	}
+	return a.service.SetTeam(gameRoom, playerID, team)
+}
+
+// AckState обрабатывает подтверждение версии поля от клиента
+func (a *GameServiceAdapter) AckState(room interface{}, playerID string, version uint64, resync bool) {
+	gameRoom, ok := room.(*game.Room)
+	if !ok {
+		return
+	}
+	a.service.AckState(gameRoom, playerID, version, resync)
+}
+
 // WSPlayerAdapter адаптирует websocket.Player для использования в game.Service
 type WSPlayerAdapter struct {
 	player *websocket.Player
 }
 
+func (a *WSPlayerAdapter) GetID() string {
+	return a.player.ID
+}
+
 func (a *WSPlayerAdapter) GetNickname() string {
 	return a.player.GetNickname()
 }
@@ -139,6 +198,8 @@ func (a *WSPlayerAdapter) GetMu() interface{} {
 }
 
 func (a *WSPlayerAdapter) GetConn() interface{} {
+	a.player.Mu.Lock()
+	defer a.player.Mu.Unlock()
 	return a.player.Conn
 }
 
@@ -150,6 +211,10 @@ func (a *WSPlayerAdapter) UpdateCursor(x, y float64) bool {
 	return a.player.UpdateCursor(x, y)
 }
 
+func (a *WSPlayerAdapter) Send(binaryData []byte, replay bool) error {
+	return a.player.Send(binaryData, replay)
+}
+
 // WSManagerAdapter адаптирует websocket.Manager для использования в game.Service
 type WSManagerAdapter struct {
 	manager *websocket.Manager
@@ -31,6 +31,7 @@ func convertGameStateToMain(gs *game.GameState) *GameState {
 		HintsUsed:     gs.HintsUsed,
 		LoserPlayerID: gs.LoserPlayerID,
 		LoserNickname: gs.LoserNickname,
+		LivesLeft:     gs.LivesLeft,
 		flagSetInfo:   make(map[int]FlagInfo),
 	}
 
@@ -43,7 +44,7 @@ func convertGameStateToMain(gs *game.GameState) *GameState {
 	// Конвертируем CellHints
 	mainGS.CellHints = make([]CellHint, len(gs.CellHints))
 	for i, ch := range gs.CellHints {
-		mainGS.CellHints[i] = CellHint{Row: ch.Row, Col: ch.Col, Type: ch.Type}
+		mainGS.CellHints[i] = CellHint{Row: ch.Row, Col: ch.Col, Type: ch.Type, Probability: ch.Probability}
 	}
 
 	// Конвертируем Board
@@ -57,6 +58,7 @@ func convertGameStateToMain(gs *game.GameState) *GameState {
 				IsFlagged:     gs.Board[i][j].IsFlagged,
 				NeighborMines: gs.Board[i][j].NeighborMines,
 				FlagColor:     gs.Board[i][j].FlagColor,
+				Exploded:      gs.Board[i][j].Exploded,
 			}
 		}
 	}
@@ -91,6 +93,7 @@ func convertGameStateFromMain(mainGS *GameState) *game.GameState {
 		HintsUsed:     mainGS.HintsUsed,
 		LoserPlayerID: mainGS.LoserPlayerID,
 		LoserNickname: mainGS.LoserNickname,
+		LivesLeft:     mainGS.LivesLeft,
 		FlagSetInfo:   make(map[int]game.FlagInfo),
 	}
 
@@ -103,7 +106,7 @@ func convertGameStateFromMain(mainGS *GameState) *game.GameState {
 	// Конвертируем CellHints
 	gs.CellHints = make([]game.CellHint, len(mainGS.CellHints))
 	for i, ch := range mainGS.CellHints {
-		gs.CellHints[i] = game.CellHint{Row: ch.Row, Col: ch.Col, Type: ch.Type}
+		gs.CellHints[i] = game.CellHint{Row: ch.Row, Col: ch.Col, Type: ch.Type, Probability: ch.Probability}
 	}
 
 	// Конвертируем Board
@@ -117,6 +120,7 @@ func convertGameStateFromMain(mainGS *GameState) *game.GameState {
 				IsFlagged:     mainGS.Board[i][j].IsFlagged,
 				NeighborMines: mainGS.Board[i][j].NeighborMines,
 				FlagColor:     mainGS.Board[i][j].FlagColor,
+				Exploded:      mainGS.Board[i][j].Exploded,
 			}
 		}
 	}
@@ -159,6 +163,7 @@ func decodeGameStateFromProtobuf(data []byte) (*game.GameState, error) {
 		HintsUsed:     int(gameStateProto.HintsUsed),
 		LoserPlayerID: gameStateProto.LoserPlayerId,
 		LoserNickname: gameStateProto.LoserNickname,
+		LivesLeft:     int(gameStateProto.LivesLeft),
 		flagSetInfo:   make(map[int]FlagInfo),
 	}
 
@@ -174,6 +179,7 @@ func decodeGameStateFromProtobuf(data []byte) (*game.GameState, error) {
 					IsFlagged:     cell.IsFlagged,
 					NeighborMines: int(cell.NeighborMines),
 					FlagColor:     cell.FlagColor,
+					Exploded:      cell.IsExploded,
 				}
 			}
 		}
@@ -188,7 +194,7 @@ func decodeGameStateFromProtobuf(data []byte) (*game.GameState, error) {
 	// Конвертируем CellHints
 	mainGS.CellHints = make([]CellHint, len(gameStateProto.CellHints))
 	for i, hint := range gameStateProto.CellHints {
-		mainGS.CellHints[i] = CellHint{Row: int(hint.Row), Col: int(hint.Col), Type: hint.Type}
+		mainGS.CellHints[i] = CellHint{Row: int(hint.Row), Col: int(hint.Col), Type: hint.Type, Probability: float64(hint.Probability)}
 	}
 
 	// Конвертируем в game.GameState
@@ -10,10 +10,12 @@ import (
 	"sync"
 	"time"
 
+	"minesweeperonline/internal/auth"
 	"minesweeperonline/internal/config"
 	"minesweeperonline/internal/database"
 	"minesweeperonline/internal/game"
 	"minesweeperonline/internal/handlers"
+	"minesweeperonline/internal/mailer"
 	"minesweeperonline/internal/middleware"
 	"minesweeperonline/internal/utils"
 	ws "minesweeperonline/internal/websocket"
@@ -57,9 +59,10 @@ type SafeCell struct {
 }
 
 type CellHint struct {
-	Row  int    `json:"r"`
-	Col  int    `json:"c"`
-	Type string `json:"t"` // "MINE", "SAFE", "UNKNOWN"
+	Row         int     `json:"r"`
+	Col         int     `json:"c"`
+	Type        string  `json:"t"`           // "MINE", "SAFE", "UNKNOWN", "PROBABILITY"
+	Probability float64 `json:"p,omitempty"` // Точная вероятность мины (режим training)
 }
 
 type GameState struct {
@@ -76,6 +79,7 @@ type GameState struct {
 	CellHints     []CellHint       `json:"hints,omitempty"` // Подсказки для ячеек (показываются в training всегда, в fair при проигрыше)
 	LoserPlayerID string           `json:"lpid,omitempty"`
 	LoserNickname string           `json:"ln,omitempty"`
+	LivesLeft     int              `json:"ll,omitempty"`    // Осталось общих жизней (режим жизней)
 	flagSetInfo   map[int]FlagInfo // Информация об установке флага для каждой ячейки (ключ: row*cols + col)
 	mu            sync.RWMutex
 }
@@ -86,6 +90,7 @@ type Cell struct {
 	IsFlagged     bool   `json:"f"`
 	NeighborMines int    `json:"n"`
 	FlagColor     string `json:"fc,omitempty"` // Цвет игрока, который поставил флаг
+	Exploded      bool   `json:"ex,omitempty"` // Подорванная мина (режим жизней)
 }
 
 type Message struct {
@@ -149,7 +154,7 @@ func NewServer(roomManager *game.RoomManager, db *database.DB) *Server {
 func NewGameState(rows, cols, mines int, gameMode string) *GameState {
 	// Эта функция используется только в main.go для совместимости
 	// Внутри используется game.NewGameState с пустым seed (будет сгенерирован UUID)
-	gs := game.NewGameState(rows, cols, mines, gameMode, "")
+	gs := game.NewGameState(rows, cols, mines, gameMode, false, "")
 	return convertGameStateToMain(gs)
 }
 
@@ -753,7 +758,7 @@ func (s *Server) handleCellClick(room *game.Room, playerID string, click *CellCl
 
 									go func() {
 										log.Printf("RecordGameResult (chording взрыв): передаем seed=%s (len=%d)", seed, len(seed))
-										if err := s.profileHandler.RecordGameResult(userID, room.Cols, room.Rows, room.Mines, gameTime, false, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants); err != nil {
+										if _, err := s.profileHandler.RecordGameResult(userID, room.Cols, room.Rows, room.Mines, gameTime, false, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants, game.GameResultDetails{}); err != nil {
 											log.Printf("Ошибка записи результата игры: %v", err)
 										}
 										// Сохраняем комнату в БД после проигрыша
@@ -837,7 +842,7 @@ func (s *Server) handleCellClick(room *game.Room, playerID string, click *CellCl
 					log.Printf("[MUTEX] handleCellClick (chording победа): room.Mu.RUnlock() разблокирован после сбора участников")
 
 					go func() {
-						if err := s.profileHandler.RecordGameResult(userID, room.Cols, room.Rows, room.Mines, gameTime, true, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants); err != nil {
+						if _, err := s.profileHandler.RecordGameResult(userID, room.Cols, room.Rows, room.Mines, gameTime, true, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants, game.GameResultDetails{}); err != nil {
 							log.Printf("Ошибка записи результата игры: %v", err)
 						}
 					}()
@@ -1043,7 +1048,7 @@ func (s *Server) handleCellClick(room *game.Room, playerID string, click *CellCl
 			log.Printf("[MUTEX] handleMineExplosion: room.Mu.RUnlock() разблокирован после сбора участников")
 
 			log.Printf("RecordGameResult (проигрыш): передаем seed=%s (len=%d)", seed, len(seed))
-			if err := s.profileHandler.RecordGameResult(userID, room.Cols, room.Rows, room.Mines, gameTime, false, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants); err != nil {
+			if _, err := s.profileHandler.RecordGameResult(userID, room.Cols, room.Rows, room.Mines, gameTime, false, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants, game.GameResultDetails{}); err != nil {
 				log.Printf("Ошибка записи результата игры: %v", err)
 			}
 		}
@@ -1176,7 +1181,7 @@ func (s *Server) handleCellClick(room *game.Room, playerID string, click *CellCl
 				for _, p := range room.Players {
 					// Записываем победу только для игроков, которые не проиграли
 					if p.ID != loserID && p.UserID > 0 && s.profileHandler != nil {
-						if err := s.profileHandler.RecordGameResult(p.UserID, room.Cols, room.Rows, room.Mines, gameTime, true, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants); err != nil {
+						if _, err := s.profileHandler.RecordGameResult(p.UserID, room.Cols, room.Rows, room.Mines, gameTime, true, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants, game.GameResultDetails{}); err != nil {
 							log.Printf("Ошибка записи результата игры: %v", err)
 						}
 					}
@@ -1467,7 +1472,7 @@ func (s *Server) handleHint(room *game.Room, playerID string, hint *Hint) {
 				}
 				for _, p := range room.Players {
 					if p.ID != loserID && p.UserID > 0 && s.profileHandler != nil {
-						if err := s.profileHandler.RecordGameResult(p.UserID, room.Cols, room.Rows, room.Mines, gameTime, true, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants); err != nil {
+						if _, err := s.profileHandler.RecordGameResult(p.UserID, room.Cols, room.Rows, room.Mines, gameTime, true, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants, game.GameResultDetails{}); err != nil {
 							log.Printf("Ошибка записи результата игры: %v", err)
 						}
 					}
@@ -2131,7 +2136,22 @@ func main() {
 	}
 
 	profileHandler := handlers.NewProfileHandler(db)
-	authHandler := handlers.NewAuthHandler(db, profileHandler, cfg)
+	// Заполняем материализованный рейтинг по истории игр (только при первом запуске)
+	if err := profileHandler.BackfillRatings(); err != nil {
+		log.Printf("Предупреждение: не удалось заполнить user_ratings: %v", err)
+	}
+	authHandler := handlers.NewAuthHandler(db, profileHandler, cfg, mailer.New(cfg))
+	// Access токен действует, пока его сессия не отозвана
+	auth.SetSessionChecker(handlers.NewSessionChecker(db))
+	auth.SetRoleLoader(handlers.NewRoleLoader(db))
+	// TRUSTED_PROXIES - обратные прокси перед сервером; только от них принимается X-Forwarded-For
+	if err := handlers.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Failed to read TRUSTED_PROXIES: %v", err)
+	}
+	// ADMIN_EMAIL - первый администратор; дальше роли выдаются через /admin/users/{username}/roles
+	if err := handlers.GrantAdminByEmail(db, cfg.AdminEmail); err != nil {
+		log.Printf("Предупреждение: не удалось выдать роль admin: %v", err)
+	}
 	roomHandler := handlers.NewRoomHandler(roomManager)
 
 	// Создаем WebSocket Manager и Game Service
@@ -2145,6 +2165,7 @@ func main() {
 	// ВАЖНО: обновляем wsManagerAdapter, чтобы он указывал на финальный wsManager
 	// Это нужно, чтобы gameService мог найти wsPlayers через правильный wsManager
 	wsManagerAdapter.UpdateWSManager(wsManager)
+	roomHandler.SetRoomCloser(wsManager.CloseRoom)
 
 	router := mux.NewRouter()
 
@@ -2155,6 +2176,8 @@ func main() {
 	r.HandleFunc("/auth/login", authHandler.Login).Methods("POST", "OPTIONS")
 	r.HandleFunc("/auth/request-password-reset", authHandler.RequestPasswordReset).Methods("POST", "OPTIONS")
 	r.HandleFunc("/auth/reset-password", authHandler.ResetPasswordByToken).Methods("POST", "OPTIONS")
+	r.HandleFunc("/auth/refresh", authHandler.Refresh).Methods("POST", "OPTIONS")
+	r.HandleFunc("/auth/verify-email", authHandler.VerifyEmail).Methods("GET")
 	r.HandleFunc("/ws", wsManager.HandleWebSocket)
 	r.HandleFunc("/rooms", roomHandler.GetRooms).Methods("GET", "OPTIONS")
 	r.HandleFunc("/rooms", roomHandler.CreateRoom).Methods("POST", "OPTIONS")
@@ -2164,12 +2187,22 @@ func main() {
 	protected := router.PathPrefix("/api").Subrouter()
 	protected.Use(middleware.AuthMiddleware)
 	protected.HandleFunc("/auth/me", authHandler.GetMe).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/auth/logout", authHandler.Logout).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/sessions", authHandler.GetSessions).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/auth/sessions/{id}", authHandler.RevokeSession).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/auth/resend-verification", authHandler.ResendVerification).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/profile", profileHandler.GetProfile).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/profile/activity", profileHandler.UpdateActivity).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/profile/color", profileHandler.UpdateColor).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/profile/change-password", profileHandler.ChangePassword).Methods("POST", "OPTIONS")
-	protected.HandleFunc("/auth/reset-password-admin", authHandler.ResetPasswordByAdmin).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/rooms/{id}", roomHandler.UpdateRoom).Methods("PUT", "OPTIONS")
+	protected.HandleFunc("/rooms/{id}", roomHandler.DeleteRoom).Methods("DELETE", "OPTIONS")
+
+	// Привилегированные маршруты: доступ по правам ролей пользователя
+	protected.Handle("/auth/reset-password-admin", requirePermission(auth.PermissionResetPasswords, authHandler.ResetPasswordByAdmin)).Methods("POST", "OPTIONS")
+	protected.Handle("/admin/roles", requirePermission(auth.PermissionManageRoles, authHandler.GetRoles)).Methods("GET", "OPTIONS")
+	protected.Handle("/admin/users/{username}/roles/{role}", requirePermission(auth.PermissionManageRoles, authHandler.GrantRole)).Methods("PUT", "OPTIONS")
+	protected.Handle("/admin/users/{username}/roles/{role}", requirePermission(auth.PermissionManageRoles, authHandler.RevokeRole)).Methods("DELETE", "OPTIONS")
 
 	// Публичный маршрут для просмотра профиля по username
 	r.HandleFunc("/profile", profileHandler.GetProfileByUsername).Methods("GET", "OPTIONS").Queries("username", "{username}")
@@ -2183,11 +2216,22 @@ func main() {
 	r.HandleFunc("/profile/recent-games", profileHandler.GetRecentGames).Methods("GET", "OPTIONS").Queries("username", "{username}")
 	// Защищенный маршрут для получения своих последних 10 игр (без параметра username)
 	protected.HandleFunc("/profile/recent-games", profileHandler.GetRecentGames).Methods("GET", "OPTIONS")
+	// Публичный маршрут для получения истории рейтинга (для графиков)
+	r.HandleFunc("/profile/rating-history", profileHandler.GetRatingHistory).Methods("GET", "OPTIONS").Queries("username", "{username}")
+	// Защищенный маршрут для получения своей истории рейтинга (без параметра username)
+	protected.HandleFunc("/profile/rating-history", profileHandler.GetRatingHistory).Methods("GET", "OPTIONS")
 	// Публичный маршрут для получения детальной информации об игре
 	r.HandleFunc("/game/details", profileHandler.GetGameDetails).Methods("GET", "OPTIONS")
+	// Публичный маршрут для получения записи ходов игры
+	r.HandleFunc("/game/replay", profileHandler.GetGameReplay).Methods("GET", "OPTIONS")
 
 	log.Printf("Сервер запущен на :%s", cfg.Port)
 	log.Fatal(http.ListenAndServe(":"+cfg.Port, middleware.CORSMiddleware(router)))
 }
 
+// requirePermission оборачивает обработчик проверкой права пользователя
+func requirePermission(permission auth.Permission, handler http.HandlerFunc) http.Handler {
+	return middleware.RequirePermission(permission)(handler)
+}
+
 // HTTP handlers перемещены в internal/handlers/rooms.go
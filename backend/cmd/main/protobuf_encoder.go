@@ -31,6 +31,7 @@ func encodeGameStateProtobuf(gs *GameState) ([]byte, error) {
 				IsFlagged:     cell.IsFlagged,
 				NeighborMines: int32(cell.NeighborMines),
 				FlagColor:     cell.FlagColor,
+				IsExploded:    cell.Exploded,
 			}
 		}
 		rows[i] = &pb.Row{Cells: cells}
@@ -49,9 +50,10 @@ func encodeGameStateProtobuf(gs *GameState) ([]byte, error) {
 	cellHints := make([]*pb.CellHint, len(gs.CellHints))
 	for i, hint := range gs.CellHints {
 		cellHints[i] = &pb.CellHint{
-			Row:  int32(hint.Row),
-			Col:  int32(hint.Col),
-			Type: hint.Type,
+			Row:         int32(hint.Row),
+			Col:         int32(hint.Col),
+			Type:        hint.Type,
+			Probability: float32(hint.Probability),
 		}
 	}
 
@@ -69,6 +71,7 @@ func encodeGameStateProtobuf(gs *GameState) ([]byte, error) {
 		CellHints:      cellHints,
 		LoserPlayerId:  truncatePlayerID(gs.LoserPlayerID),
 		LoserNickname:  gs.LoserNickname,
+		LivesLeft:      int32(gs.LivesLeft),
 	}
 
 	wsMsg := &pb.WebSocketMessage{
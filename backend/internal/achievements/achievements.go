@@ -0,0 +1,127 @@
+package achievements
+
+import "fmt"
+
+// Achievement описание достижения
+type Achievement struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// GameResult данные о завершенной игре, по которым проверяются правила
+type GameResult struct {
+	Width         int
+	Height        int
+	Mines         int
+	GameTime      float64
+	Won           bool
+	HasCustomSeed bool
+	GameMode      string
+	HintsUsed     int
+	FlagsPlaced   int // Флаги, поставленные самим игроком
+	Participants  int // Количество зарегистрированных участников игры
+	WinStreak     int // Количество побед подряд, включая текущую игру
+}
+
+// Rule правило получения достижения
+type Rule struct {
+	Achievement
+	Check func(result GameResult) bool
+}
+
+// isExpert проверяет, что игра сыграна на поле уровня эксперт (30x16, 99 мин)
+func isExpert(result GameResult) bool {
+	return result.Mines == 99 &&
+		((result.Width == 30 && result.Height == 16) || (result.Width == 16 && result.Height == 30))
+}
+
+// isCoop проверяет, что режим совместный: все игроки открывают одно поле вместе
+// В гонке, матче команд и пошаговом режиме игроки соревнуются
+func isCoop(gameMode string) bool {
+	return gameMode == "classic" || gameMode == "training" || gameMode == "fair"
+}
+
+// winUnder правило победы быстрее заданного времени на поле не проще минимального
+func winUnder(id, title string, seconds float64, minMines int) Rule {
+	return Rule{
+		Achievement: Achievement{
+			ID:          id,
+			Title:       title,
+			Description: fmt.Sprintf("Выиграть игру с %d или более минами быстрее %.0f секунд", minMines, seconds),
+		},
+		Check: func(result GameResult) bool {
+			return result.Won && result.Mines >= minMines && result.GameTime > 0 && result.GameTime < seconds
+		},
+	}
+}
+
+// Rules список всех достижений в порядке проверки
+var Rules = []Rule{
+	{
+		Achievement: Achievement{ID: "first_win", Title: "Первая победа", Description: "Выиграть первую игру"},
+		Check: func(result GameResult) bool {
+			return result.Won
+		},
+	},
+	{
+		Achievement: Achievement{ID: "first_expert_win", Title: "Эксперт", Description: "Выиграть игру на поле 30x16 с 99 минами"},
+		Check: func(result GameResult) bool {
+			return result.Won && isExpert(result)
+		},
+	},
+	{
+		Achievement: Achievement{ID: "no_flags_win", Title: "Без флагов", Description: "Выиграть игру, не поставив ни одного флага"},
+		Check: func(result GameResult) bool {
+			return result.Won && result.FlagsPlaced == 0 && result.Mines >= 10
+		},
+	},
+	winUnder("win_under_30s", "Молния", 30, 10),
+	{
+		Achievement: Achievement{ID: "win_streak_10", Title: "Серия", Description: "Выиграть 10 игр подряд"},
+		Check: func(result GameResult) bool {
+			return result.Won && result.WinStreak >= 10
+		},
+	},
+	{
+		Achievement: Achievement{ID: "training_no_hints", Title: "Сам справлюсь", Description: "Выиграть в режиме обучения без подсказок"},
+		Check: func(result GameResult) bool {
+			return result.Won && result.GameMode == "training" && result.HintsUsed == 0
+		},
+	},
+	{
+		Achievement: Achievement{ID: "coop_4_players", Title: "Командная игра", Description: "Выиграть совместную игру с 4 и более участниками"},
+		Check: func(result GameResult) bool {
+			return result.Won && isCoop(result.GameMode) && result.Participants >= 4
+		},
+	},
+}
+
+// Get возвращает описание достижения по ID
+func Get(id string) (Achievement, bool) {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule.Achievement, true
+		}
+	}
+	return Achievement{}, false
+}
+
+// Evaluate возвращает достижения, условия которых выполнены в этой игре и которые еще не получены
+// Игры с явно указанным seed достижений не дают
+func Evaluate(result GameResult, unlocked map[string]bool) []Achievement {
+	if result.HasCustomSeed {
+		return nil
+	}
+
+	var earned []Achievement
+	for _, rule := range Rules {
+		if unlocked[rule.ID] {
+			continue
+		}
+		if rule.Check(result) {
+			earned = append(earned, rule.Achievement)
+		}
+	}
+	return earned
+}
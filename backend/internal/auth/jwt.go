@@ -18,23 +18,57 @@ func getJWTSecret() string {
 	return secret
 }
 
+// AccessTokenTTL время жизни access токена, дальше он продлевается refresh токеном
+const AccessTokenTTL = 15 * time.Minute
+
+// RefreshTokenTTL время жизни refresh токена (сессия без обновлений истекает)
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
 type Claims struct {
-	UserID   int    `json:"userId"`
-	Username string `json:"username"`
+	UserID     int    `json:"userId"`
+	Username   string `json:"username"`
+	SessionID  int    `json:"sid"` // Сессия, выдавшая токен
+	Generation int    `json:"gen"` // Поколение токенов пользователя (меняется при смене пароля)
 	jwt.RegisteredClaims
 }
 
-type PasswordResetClaims struct {
+// sessionChecker проверяет, что сессия токена не отозвана, а поколение токенов не сменилось
+var sessionChecker func(claims *Claims) error
+
+// SetSessionChecker задает проверку сессии, которую выполняет ValidateToken
+func SetSessionChecker(checker func(claims *Claims) error) {
+	sessionChecker = checker
+}
+
+// EmailClaims токен из письма: сброс пароля или подтверждение email
+// Назначение токена задается audience, поэтому токен одного назначения не принимается для другого
+type EmailClaims struct {
 	UserID int    `json:"userId"`
 	Email  string `json:"email"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID int, username string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+type PasswordResetClaims = EmailClaims
+
+const (
+	passwordResetAudience     = "password-reset"
+	emailVerificationAudience = "email-verification"
+)
+
+// PasswordResetTTL время жизни ссылки сброса пароля
+const PasswordResetTTL = 1 * time.Hour
+
+// EmailVerificationTTL время жизни ссылки подтверждения email
+const EmailVerificationTTL = 48 * time.Hour
+
+// GenerateToken выдает короткоживущий access токен сессии
+func GenerateToken(userID int, username string, sessionID, generation int) (string, error) {
+	expirationTime := time.Now().Add(AccessTokenTTL)
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:     userID,
+		Username:   username,
+		SessionID:  sessionID,
+		Generation: generation,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -50,6 +84,7 @@ func GenerateToken(userID int, username string) (string, error) {
 	return tokenString, nil
 }
 
+// ValidateToken проверяет подпись и срок access токена, а также что его сессия еще действует
 func ValidateToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
@@ -68,15 +103,46 @@ func ValidateToken(tokenString string) (*Claims, error) {
 		return nil, errors.New("invalid token")
 	}
 
+	// Токены без сессии (выданные до появления сессий, токены сброса пароля) не принимаются
+	if claims.SessionID == 0 {
+		return nil, errors.New("token has no session")
+	}
+	if sessionChecker != nil {
+		if err := sessionChecker(claims); err != nil {
+			return nil, err
+		}
+	}
+
 	return claims, nil
 }
 
-func GeneratePasswordResetToken(userID int, email string) (string, error) {
-	expirationTime := time.Now().Add(1 * time.Hour) // Токен действителен 1 час
-	claims := &PasswordResetClaims{
+// GeneratePasswordResetToken выдает токен сброса пароля
+// tokenID записывается в БД: по нему токен принимается только один раз
+func GeneratePasswordResetToken(userID int, email, tokenID string) (string, error) {
+	return generateEmailToken(userID, email, passwordResetAudience, tokenID, PasswordResetTTL)
+}
+
+func ValidatePasswordResetToken(tokenString string) (*PasswordResetClaims, error) {
+	return validateEmailToken(tokenString, passwordResetAudience)
+}
+
+// GenerateEmailVerificationToken выдает токен подтверждения email
+func GenerateEmailVerificationToken(userID int, email string) (string, error) {
+	return generateEmailToken(userID, email, emailVerificationAudience, "", EmailVerificationTTL)
+}
+
+func ValidateEmailVerificationToken(tokenString string) (*EmailClaims, error) {
+	return validateEmailToken(tokenString, emailVerificationAudience)
+}
+
+func generateEmailToken(userID int, email, audience, tokenID string, ttl time.Duration) (string, error) {
+	expirationTime := time.Now().Add(ttl)
+	claims := &EmailClaims{
 		UserID: userID,
 		Email:  email,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Audience:  jwt.ClaimStrings{audience},
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -91,15 +157,15 @@ func GeneratePasswordResetToken(userID int, email string) (string, error) {
 	return tokenString, nil
 }
 
-func ValidatePasswordResetToken(tokenString string) (*PasswordResetClaims, error) {
-	claims := &PasswordResetClaims{}
+func validateEmailToken(tokenString, audience string) (*EmailClaims, error) {
+	claims := &EmailClaims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
 		}
 		return jwtSecret, nil
-	})
+	}, jwt.WithAudience(audience))
 
 	if err != nil {
 		return nil, err
@@ -111,4 +177,3 @@ func ValidatePasswordResetToken(tokenString string) (*PasswordResetClaims, error
 
 	return claims, nil
 }
-
@@ -0,0 +1,88 @@
+package auth
+
+import "errors"
+
+// Роли пользователей
+// Роль user есть у всех зарегистрированных пользователей и не хранится в user_roles
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleUser      = "user"
+)
+
+// Roles все роли в порядке убывания прав
+var Roles = []string{RoleAdmin, RoleModerator, RoleUser}
+
+// Permission право на привилегированное действие
+type Permission string
+
+const (
+	PermissionResetPasswords Permission = "users.reset_password" // Сброс пароля другого пользователя
+	PermissionDeleteRooms    Permission = "rooms.delete"         // Удаление чужих комнат
+	PermissionManageRoles    Permission = "roles.manage"         // Выдача и отзыв ролей
+)
+
+// rolePermissions права каждой роли
+var rolePermissions = map[string][]Permission{
+	RoleAdmin:     {PermissionResetPasswords, PermissionDeleteRooms, PermissionManageRoles},
+	RoleModerator: {PermissionDeleteRooms},
+	RoleUser:      {},
+}
+
+// roleLoader загружает роли пользователя, задается при запуске сервера
+var roleLoader func(userID int) ([]string, error)
+
+// SetRoleLoader задает загрузку ролей пользователя для проверки прав
+func SetRoleLoader(loader func(userID int) ([]string, error)) {
+	roleLoader = loader
+}
+
+// ValidRole проверяет, что роль существует
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolesHavePermission проверяет, дает ли хотя бы одна из ролей право
+func RolesHavePermission(roles []string, permission Permission) bool {
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PermissionsOf возвращает права, которые дают роли
+func PermissionsOf(roles []string) []Permission {
+	seen := make(map[Permission]bool)
+	permissions := make([]Permission, 0)
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if !seen[p] {
+				seen[p] = true
+				permissions = append(permissions, p)
+			}
+		}
+	}
+	return permissions
+}
+
+// UserRoles возвращает роли пользователя
+func UserRoles(userID int) ([]string, error) {
+	if roleLoader == nil {
+		return nil, errors.New("role loader not configured")
+	}
+	return roleLoader(userID)
+}
+
+// HasPermission проверяет право пользователя по его ролям
+func HasPermission(userID int, permission Permission) (bool, error) {
+	roles, err := UserRoles(userID)
+	if err != nil {
+		return false, err
+	}
+	return RolesHavePermission(roles, permission), nil
+}
@@ -12,7 +12,19 @@ type Config struct {
 	DbUser      string
 	DbPassword  string
 	NeedMigrate bool
-	AdminEmail  string // Email администратора, который может сбрасывать пароли
+	AdminEmail  string // Email пользователя, которому при запуске выдается роль admin
+	AppURL      string // Публичный адрес сайта для ссылок в письмах
+
+	// Прокси (IP или CIDR через запятую), которым доверяем X-Forwarded-For; пусто - адрес клиента из соединения
+	TrustedProxies string
+
+	// Почта: при пустом SMTPHost письма не отправляются, а пишутся в лог или в MailDir
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+	MailFrom     string
+	MailDir      string
 }
 
 func ReadConfig() (*Config, error) {
@@ -28,6 +40,14 @@ func ReadConfig() (*Config, error) {
 	dbPassword := envconfig.Get("POSTGRES_PASSWORD", "postgres")
 	needMigrate := envconfig.GetBool("NEED_MIGRATE", true)
 	adminEmail := envconfig.Get("ADMIN_EMAIL", "")
+	appURL := envconfig.Get("APP_URL", "http://localhost:5173")
+	trustedProxies := envconfig.Get("TRUSTED_PROXIES", "")
+	smtpHost := envconfig.Get("SMTP_HOST", "")
+	smtpPort := envconfig.Get("SMTP_PORT", "587")
+	smtpUser := envconfig.Get("SMTP_USER", "")
+	smtpPassword := envconfig.Get("SMTP_PASSWORD", "")
+	mailFrom := envconfig.Get("MAIL_FROM", "noreply@localhost")
+	mailDir := envconfig.Get("MAIL_DIR", "")
 	return &Config{
 		Port:        port,
 		DbHost:      dbHost,
@@ -37,5 +57,15 @@ func ReadConfig() (*Config, error) {
 		DbPassword:  dbPassword,
 		NeedMigrate: needMigrate,
 		AdminEmail:  adminEmail,
+		AppURL:      appURL,
+
+		TrustedProxies: trustedProxies,
+
+		SMTPHost:     smtpHost,
+		SMTPPort:     smtpPort,
+		SMTPUser:     smtpUser,
+		SMTPPassword: smtpPassword,
+		MailFrom:     mailFrom,
+		MailDir:      mailDir,
 	}, nil
 }
@@ -6,8 +6,10 @@ import (
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 
+	"minesweeperonline/internal/auth"
 	"minesweeperonline/internal/models"
 )
 
@@ -91,7 +93,15 @@ func (db *DB) InitSchema() error {
 		&models.UserStats{},
 		&models.UserGameHistory{},
 		&models.GameParticipant{},
+		&models.GameReplay{},
+		&models.UserRating{},
+		&models.RatingSnapshot{},
+		&models.UserAchievement{},
 		&models.Room{},
+		&models.AuthSession{},
+		&models.PasswordResetToken{},
+		&models.Role{},
+		&models.UserRole{},
 	}
 
 	for _, table := range tables {
@@ -256,6 +266,16 @@ func (db *DB) InitSchema() error {
 		}
 	}
 
+	// Справочник ролей
+	roles := []models.Role{
+		{Name: auth.RoleAdmin, Description: "Администратор: все права, выдача ролей"},
+		{Name: auth.RoleModerator, Description: "Модератор: удаление комнат"},
+		{Name: auth.RoleUser, Description: "Пользователь"},
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&roles).Error; err != nil {
+		log.Printf("Warning: failed to seed roles: %v", err)
+	}
+
 	log.Println("Database schema initialized successfully")
 	return nil
 }
@@ -0,0 +1,316 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"minesweeperonline/internal/utils"
+)
+
+// Параметры ботов
+const (
+	maxBotsPerRoom       = 8
+	defaultBotClickDelay = 700 * time.Millisecond
+	minBotClickDelay     = 50 * time.Millisecond // Нижняя граница для нагрузочного тестирования
+	botColor             = "#95A5A6"
+)
+
+// BotSkill настройки бота
+type BotSkill struct {
+	Perfect     bool          // Все выводы Solver; иначе только простые выводы по одной цифре с учетом флагов
+	GuessPolicy string        // "safest" (наименьшая вероятность мины), "random", "none" (не угадывает)
+	ClickDelay  time.Duration // Пауза между ходами
+}
+
+// Bot бот-участник комнаты: игрок без WebSocket соединения, который ходит через HandleCellClick
+type Bot struct {
+	Player *Player
+	Skill  BotSkill
+	stop   chan struct{}
+}
+
+// normalize подставляет значения по умолчанию
+func (skill BotSkill) normalize() BotSkill {
+	switch skill.GuessPolicy {
+	case "safest", "random", "none":
+	default:
+		skill.GuessPolicy = "safest"
+	}
+	if skill.ClickDelay <= 0 {
+		skill.ClickDelay = defaultBotClickDelay
+	} else if skill.ClickDelay < minBotClickDelay {
+		skill.ClickDelay = minBotClickDelay
+	}
+	return skill
+}
+
+// hasBots проверяет, есть ли в комнате боты
+// Вызывается под блокировкой room.Mu
+func (r *Room) hasBots() bool {
+	return len(r.bots) > 0
+}
+
+// AddBot добавляет в комнату бота и запускает его
+func (s *Service) AddBot(room *Room, skill BotSkill) (*Player, error) {
+	skill = skill.normalize()
+
+	room.Mu.Lock()
+	if len(room.bots) >= maxBotsPerRoom {
+		room.Mu.Unlock()
+		return nil, fmt.Errorf("too many bots")
+	}
+	if room.bots == nil {
+		room.bots = make(map[string]*Bot)
+	}
+	player := &Player{
+		ID:       utils.GenerateID(),
+		Nickname: fmt.Sprintf("Бот %d", len(room.bots)+1),
+		Color:    botColor,
+		IsBot:    true,
+	}
+	if room.GameMode == "versus" {
+		player.Team = room.smallerTeam()
+	}
+	bot := &Bot{
+		Player: player,
+		Skill:  skill,
+		stop:   make(chan struct{}),
+	}
+	room.bots[player.ID] = bot
+	room.Players[player.ID] = player
+	room.Mu.Unlock()
+
+	log.Printf("Бот %s добавлен в комнату %s (perfect=%v, guess=%s, delay=%v)", player.ID, room.ID, skill.Perfect, skill.GuessPolicy, skill.ClickDelay)
+	go s.runBot(room, bot)
+	s.BroadcastPlayerList(room)
+	return player, nil
+}
+
+// RemoveBot останавливает бота и убирает его из комнаты
+// botID может быть полным или укороченным ID (клиенты видят укороченные)
+func (s *Service) RemoveBot(room *Room, botID string) error {
+	room.Mu.Lock()
+	var bot *Bot
+	for id, b := range room.bots {
+		if id == botID || truncatePlayerID(id) == botID {
+			bot = b
+			break
+		}
+	}
+	if bot == nil {
+		room.Mu.Unlock()
+		return fmt.Errorf("bot not found")
+	}
+	delete(room.bots, bot.Player.ID)
+	delete(room.Players, bot.Player.ID)
+	room.Mu.Unlock()
+
+	close(bot.stop)
+	log.Printf("Бот %s удален из комнаты %s", bot.Player.ID, room.ID)
+	s.BroadcastPlayerList(room)
+	return nil
+}
+
+// RemoveBots останавливает всех ботов комнаты (например, когда в ней не осталось людей)
+func (s *Service) RemoveBots(room *Room) {
+	room.Mu.Lock()
+	bots := room.bots
+	room.bots = nil
+	for id := range bots {
+		delete(room.Players, id)
+	}
+	room.Mu.Unlock()
+
+	for _, bot := range bots {
+		close(bot.stop)
+	}
+	if len(bots) > 0 {
+		log.Printf("Из комнаты %s удалено ботов: %d", room.ID, len(bots))
+	}
+}
+
+// runBot цикл бота: раз в ClickDelay выбирает ход и делает его как обычный игрок
+func (s *Service) runBot(room *Room, bot *Bot) {
+	ticker := time.NewTicker(bot.Skill.ClickDelay)
+	defer ticker.Stop()
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	for {
+		select {
+		case <-bot.stop:
+			return
+		case <-ticker.C:
+		}
+
+		click := s.botMove(room, bot, rng)
+		if click == nil {
+			continue
+		}
+		if err := s.HandleCellClick(room, bot.Player.ID, click); err != nil {
+			log.Printf("Бот %s: ошибка хода: %v", bot.Player.ID, err)
+		}
+	}
+}
+
+// botMove выбирает ход бота или nil, если ходить нечего
+// Игра, в которой бот сделал ход, помечается как игра с ботами
+func (s *Service) botMove(room *Room, bot *Bot, rng *rand.Rand) *CellClick {
+	gs := room.GameState
+	// В гонке бот играет на своем поле, в матче команд - на поле своей команды
+	switch room.GameMode {
+	case "race":
+		gs = s.racerBoard(room, bot.Player.ID)
+	case "versus":
+		gs = s.teamBoard(room, bot.Player.ID)
+	case "turns":
+		// В пошаговом режиме бот ждет своего хода
+		if !s.isCurrentTurn(room, bot.Player.ID) {
+			return nil
+		}
+	}
+	if gs == nil {
+		return nil
+	}
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	if gs.GameOver || gs.GameWon {
+		return nil
+	}
+	click := chooseBotMove(gs, bot.Skill, rng)
+	if click != nil {
+		gs.BotsPlayed = true
+	}
+	return click
+}
+
+// chooseBotMove выбирает ход по открытым клеткам: сначала доказанно безопасная клетка,
+// затем флаг на доказанную мину, затем угадывание по GuessPolicy
+// Вызывается под блокировкой GameState
+func chooseBotMove(gs *GameState, skill BotSkill, rng *rand.Rand) *CellClick {
+	closed := make([]CellPos, 0)
+	for i := 0; i < gs.Rows; i++ {
+		for j := 0; j < gs.Cols; j++ {
+			if !gs.Board[i][j].IsRevealed && !gs.Board[i][j].IsFlagged {
+				closed = append(closed, CellPos{Row: i, Col: j})
+			}
+		}
+	}
+	if len(closed) == 0 {
+		return nil
+	}
+
+	// Первый ход - в центр поля
+	if gs.Revealed == 0 {
+		row, col := gs.Rows/2, gs.Cols/2
+		if gs.Board[row][col].IsFlagged {
+			pos := closed[rng.Intn(len(closed))]
+			row, col = pos.Row, pos.Col
+		}
+		return &CellClick{Row: row, Col: col}
+	}
+
+	lm := NewLabelMap(gs.Cols, gs.Rows)
+	for i := 0; i < gs.Rows; i++ {
+		for j := 0; j < gs.Cols; j++ {
+			if gs.Board[i][j].IsRevealed {
+				lm.labels[i][j] = gs.Board[i][j].NeighborMines
+			}
+		}
+	}
+	lm.Recalc()
+
+	var solver *Solver
+	if skill.Perfect {
+		solver = MakeSolver(lm, gs.Mines)
+		boundary := lm.GetBoundary()
+		for i, pos := range boundary {
+			if !gs.Board[pos.Row][pos.Col].IsFlagged && !solver.CanBeDangerous(i) {
+				return &CellClick{Row: pos.Row, Col: pos.Col}
+			}
+		}
+		if len(boundary) > 0 && solver.OutsideIsSafe() {
+			for _, pos := range closed {
+				if lm.GetBoundaryIndex(pos.Row, pos.Col) == -1 {
+					return &CellClick{Row: pos.Row, Col: pos.Col}
+				}
+			}
+		}
+		for i, pos := range boundary {
+			if !gs.Board[pos.Row][pos.Col].IsFlagged && !solver.CanBeSafe(i) {
+				return &CellClick{Row: pos.Row, Col: pos.Col, Flag: true}
+			}
+		}
+	} else if click := basicBotMove(gs, lm); click != nil {
+		return click
+	}
+
+	switch skill.GuessPolicy {
+	case "none":
+		return nil
+	case "safest":
+		if solver == nil {
+			solver = MakeSolver(lm, gs.Mines)
+		}
+		if probs, ok := solver.Probabilities(); ok {
+			best := -1
+			bestProbability := 2.0
+			for idx, pos := range closed {
+				p := probs.Outside
+				if b := lm.GetBoundaryIndex(pos.Row, pos.Col); b != -1 {
+					p = probs.Boundary[b]
+				}
+				if p < bestProbability {
+					best, bestProbability = idx, p
+				}
+			}
+			pos := closed[best]
+			return &CellClick{Row: pos.Row, Col: pos.Col}
+		}
+	}
+	pos := closed[rng.Intn(len(closed))]
+	return &CellClick{Row: pos.Row, Col: pos.Col}
+}
+
+// basicBotMove простые выводы по одной цифре: все мины вокруг отмечены флагами - остальные
+// соседи безопасны; закрытых соседей столько, сколько осталось мин - все они мины
+func basicBotMove(gs *GameState, lm *LabelMap) *CellClick {
+	for i := 0; i < gs.Rows; i++ {
+		for j := 0; j < gs.Cols; j++ {
+			if lm.labels[i][j] <= 0 {
+				continue
+			}
+			flags := 0
+			unknown := make([]CellPos, 0, 8)
+			for _, pos := range lm.closedAround(i, j) {
+				if gs.Board[pos.Row][pos.Col].IsFlagged {
+					flags++
+				} else {
+					unknown = append(unknown, pos)
+				}
+			}
+			if len(unknown) == 0 {
+				continue
+			}
+			if flags == lm.labels[i][j] {
+				return &CellClick{Row: unknown[0].Row, Col: unknown[0].Col}
+			}
+			if lm.labels[i][j]-flags == len(unknown) {
+				return &CellClick{Row: unknown[0].Row, Col: unknown[0].Col, Flag: true}
+			}
+		}
+	}
+	return nil
+}
+
+// ParseBotSkill собирает настройки бота из параметров протокола
+func ParseBotSkill(perfect bool, guessPolicy string, clickDelayMs int) BotSkill {
+	return BotSkill{
+		Perfect:     perfect,
+		GuessPolicy: strings.ToLower(guessPolicy),
+		ClickDelay:  time.Duration(clickDelayMs) * time.Millisecond,
+	}
+}
@@ -0,0 +1,286 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// clockSyncInterval период синхронизации часов с клиентами
+const clockSyncInterval = 5 * time.Second
+
+// MaxTimeLimit наибольшее ограничение времени игры (секунды)
+const MaxTimeLimit = 3600
+
+// Режимы игры на время
+const (
+	ClockDeadline = "deadline" // Открыть поле до конца времени, иначе проигрыш
+	ClockScore    = "score"    // Открыть как можно больше клеток за отведенное время
+)
+
+// RoomClock часы идущей игры комнаты: периодическая синхронизация с клиентами
+// и контроль ограничения времени, даже если никто не кликает
+// Время игры отсчитывается сервером от Room.StartTime
+type RoomClock struct {
+	StartedAt time.Time
+	Deadline  time.Time // Нулевое значение - без ограничения времени
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// halt останавливает горутину часов
+func (c *RoomClock) halt() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
+// timeLimited действует ли в комнате ограничение времени
+// Только для общего поля: в гонке, в матче команд и в пошаговом режиме игра идет до финиша
+func (r *Room) timeLimited() bool {
+	if r.TimeLimit <= 0 || r.GameMode == "race" || r.GameMode == "versus" || r.GameMode == "turns" {
+		return false
+	}
+	return r.ClockMode == ClockDeadline || r.ClockMode == ClockScore
+}
+
+// resetClock останавливает часы прошлой игры
+// ВАЖНО: вызывающий код должен удерживать r.Mu
+func (r *Room) resetClock() {
+	if r.Clock != nil {
+		r.Clock.halt()
+		r.Clock = nil
+	}
+}
+
+// startClock запускает часы комнаты, если игра идет, а часы еще не запущены
+// Вызывается после установки Room.StartTime и при подключении игрока (восстановленные из БД комнаты)
+func (s *Service) startClock(room *Room) {
+	if !s.gameRunning(room) {
+		return
+	}
+
+	room.Mu.Lock()
+	if room.StartTime == nil || room.Clock != nil {
+		room.Mu.Unlock()
+		return
+	}
+	clock := &RoomClock{
+		StartedAt: *room.StartTime,
+		stop:      make(chan struct{}),
+	}
+	if room.timeLimited() {
+		clock.Deadline = clock.StartedAt.Add(time.Duration(room.TimeLimit) * time.Second)
+	}
+	room.Clock = clock
+	room.Mu.Unlock()
+
+	if clock.Deadline.IsZero() {
+		log.Printf("Часы комнаты %s запущены", room.ID)
+	} else {
+		log.Printf("Часы комнаты %s запущены, игра заканчивается в %v", room.ID, clock.Deadline)
+	}
+	go s.runClock(room, clock)
+}
+
+// runClock рассылает синхронизацию времени и заканчивает игру по истечении времени
+func (s *Service) runClock(room *Room, clock *RoomClock) {
+	ticker := time.NewTicker(clockSyncInterval)
+	defer ticker.Stop()
+
+	var deadline <-chan time.Time
+	if !clock.Deadline.IsZero() {
+		timer := time.NewTimer(time.Until(clock.Deadline))
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	s.BroadcastTimeSync(room)
+	for {
+		select {
+		case <-clock.stop:
+			return
+		case <-ticker.C:
+			if !s.gameRunning(room) {
+				s.stopClock(room, clock)
+				return
+			}
+			s.BroadcastTimeSync(room)
+		case <-deadline:
+			s.handleTimeUp(room, clock)
+			return
+		}
+	}
+}
+
+// stopClock останавливает часы clock, если они все еще часы комнаты, и сообщает клиентам об остановке
+func (s *Service) stopClock(room *Room, clock *RoomClock) {
+	room.Mu.RLock()
+	current := room.Clock == clock
+	room.Mu.RUnlock()
+	if !current {
+		return
+	}
+
+	s.sendTimeSync(room, clock, false)
+	room.Mu.Lock()
+	if room.Clock == clock {
+		room.Clock = nil
+	}
+	room.Mu.Unlock()
+	clock.halt()
+}
+
+// gameRunning игра в комнате начата и еще не закончена
+func (s *Service) gameRunning(room *Room) bool {
+	room.Mu.RLock()
+	started := room.StartTime != nil
+	gameMode := room.GameMode
+	gs := room.GameState
+	race := room.Race
+	versus := room.Versus
+	room.Mu.RUnlock()
+	if !started {
+		return false
+	}
+
+	switch gameMode {
+	case "race":
+		if race == nil {
+			return false
+		}
+		race.Mu.Lock()
+		defer race.Mu.Unlock()
+		return race.Status == RaceRunning
+	case "versus":
+		if versus == nil {
+			return false
+		}
+		versus.Mu.Lock()
+		defer versus.Mu.Unlock()
+		return versus.WinnerTeam == 0
+	}
+
+	if gs == nil {
+		return false
+	}
+	gs.Mu.RLock()
+	defer gs.Mu.RUnlock()
+	return !gs.GameOver && !gs.GameWon
+}
+
+// handleTimeUp заканчивает игру по истечении времени
+// deadline - проигрыш всех игроков, score - игра окончена, счет равен числу открытых клеток
+func (s *Service) handleTimeUp(room *Room, clock *RoomClock) {
+	room.Mu.RLock()
+	current := room.Clock == clock
+	clockMode := room.ClockMode
+	gs := room.GameState
+	room.Mu.RUnlock()
+	if !current {
+		return
+	}
+
+	gs.Mu.Lock()
+	if gs.GameOver || gs.GameWon {
+		gs.Mu.Unlock()
+		s.stopClock(room, clock)
+		return
+	}
+	gs.GameOver = true
+	replay := gs.BuildReplay()
+	revealed := gs.Revealed
+	totalSafe := gs.Rows*gs.Cols - gs.Mines
+	gs.Mu.Unlock()
+
+	log.Printf("Комната %s: время вышло (режим %s), открыто %d из %d", room.ID, clockMode, revealed, totalSafe)
+	s.stopClock(room, clock)
+
+	// Время вышло для всех, поэтому результат записывается каждому игроку
+	// В игре на счет истечение времени - обычный конец игры, а не поражение
+	room.Mu.RLock()
+	playerIDs := make([]string, 0, len(room.Players))
+	for id, player := range room.Players {
+		if !player.IsBot && !player.Spectator && player.UserID > 0 {
+			playerIDs = append(playerIDs, id)
+		}
+	}
+	room.Mu.RUnlock()
+	for _, id := range playerIDs {
+		s.recordGameResult(room, id, false, clockMode == ClockScore, replay)
+	}
+
+	text := fmt.Sprintf("⏰ Время вышло! Открыто клеток: %d из %d", revealed, totalSafe)
+	if clockMode == ClockScore {
+		text = fmt.Sprintf("⏰ Время вышло! Счет: %d из %d", revealed, totalSafe)
+	}
+	s.BroadcastToAll(room, Message{
+		Type: "chat",
+		Chat: &ChatMessage{
+			Text:     text,
+			IsSystem: true,
+			Action:   "timeUp",
+		},
+	})
+	s.BroadcastGameState(room)
+}
+
+// BroadcastTimeSync отправляет всем игрокам комнаты время идущей игры
+func (s *Service) BroadcastTimeSync(room *Room) {
+	room.Mu.RLock()
+	clock := room.Clock
+	room.Mu.RUnlock()
+	if clock == nil {
+		return
+	}
+	s.sendTimeSync(room, clock, true)
+}
+
+// sendTimeSync отправляет всем игрокам комнаты состояние часов clock
+func (s *Service) sendTimeSync(room *Room, clock *RoomClock, running bool) {
+	binaryData, err := s.encodeRoomTimeSync(room, clock, running)
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка кодирования timeSync: %v", err)
+		return
+	}
+	s.broadcastBinary(room, binaryData, "timeSync")
+}
+
+// sendTimeSyncToPlayer отправляет подключившемуся игроку время идущей игры
+func (s *Service) sendTimeSyncToPlayer(room *Room, player WSPlayer) {
+	room.Mu.RLock()
+	clock := room.Clock
+	room.Mu.RUnlock()
+	if clock == nil {
+		return
+	}
+
+	binaryData, err := s.encodeRoomTimeSync(room, clock, true)
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка кодирования timeSync: %v", err)
+		return
+	}
+	if err := writeBinary(player, binaryData); err != nil {
+		log.Printf("[WS OUT] Ошибка отправки timeSync игроку: %v", err)
+	}
+}
+
+// encodeRoomTimeSync кодирует часы clock вместе с настройками времени комнаты
+func (s *Service) encodeRoomTimeSync(room *Room, clock *RoomClock, running bool) ([]byte, error) {
+	room.Mu.RLock()
+	clockMode, timeLimit := "", 0
+	if room.timeLimited() {
+		clockMode, timeLimit = room.ClockMode, room.TimeLimit
+	}
+	gs := room.GameState
+	room.Mu.RUnlock()
+
+	score := 0
+	if clockMode == ClockScore && gs != nil {
+		gs.Mu.RLock()
+		score = gs.Revealed
+		gs.Mu.RUnlock()
+	}
+	return EncodeTimeSyncProtobuf(clock, time.Now(), clockMode, timeLimit, running, score)
+}
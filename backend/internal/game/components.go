@@ -0,0 +1,305 @@
+package game
+
+// component независимая часть границы: некешированные ячейки, связанные общими метками
+// У каждой компоненты свой SAT, поэтому запросы к одной области не затрагивают остальные.
+// Компоненты связаны только общим числом мин
+type component struct {
+	cells        []int // Индексы ячеек границы, ячейке cells[j] соответствует переменная j+1
+	sat          *Sat
+	counter      []int // Счетчик мин компоненты, строится лениво
+	counterAdded bool
+	model        []bool // Последнее найденное решение компоненты (индекс - переменная)
+	mines        int    // Число мин в model
+	counts       []bool // Допустимые числа мин, nil пока не вычислены
+}
+
+// setModel запоминает решение компоненты
+func (c *component) setModel(solution []bool) {
+	c.model = solution
+	c.mines = 0
+	for v := 1; v <= len(c.cells); v++ {
+		if solution[v] {
+			c.mines++
+		}
+	}
+}
+
+// addCounter добавляет счетчик мин компоненты
+func (c *component) addCounter() {
+	if c.counterAdded {
+		return
+	}
+	c.counterAdded = true
+	vars := make([]int, len(c.cells))
+	for j := range vars {
+		vars[j] = j + 1
+	}
+	c.counter = c.sat.AddCounter(vars)
+}
+
+// solveRange ищет решение компоненты с числом мин от lo до hi при предположениях
+func (c *component) solveRange(assumptions []int, lo, hi int) *[]bool {
+	c.addCounter()
+	atLeast, ok := CounterAtLeast(c.counter, lo)
+	if !ok {
+		return nil
+	}
+	atMost, ok := CounterAtMost(c.counter, hi)
+	if !ok {
+		return nil
+	}
+	lits := make([]int, 0, len(assumptions)+len(atLeast)+len(atMost))
+	lits = append(lits, assumptions...)
+	lits = append(lits, atLeast...)
+	lits = append(lits, atMost...)
+	return c.sat.SolveAssuming(lits)
+}
+
+// computeCounts находит все допустимые числа мин компоненты
+// Каждый запрос на отрезке либо находит новое число, либо доказывает, что на отрезке решений нет
+func (c *component) computeCounts() {
+	if c.counts != nil {
+		return
+	}
+	c.counts = make([]bool, len(c.cells)+1)
+	var search func(lo, hi int)
+	search = func(lo, hi int) {
+		if lo > hi {
+			return
+		}
+		solution := c.solveRange(nil, lo, hi)
+		if solution == nil {
+			return
+		}
+		c.setModel(*solution)
+		k := c.mines
+		c.counts[k] = true
+		search(lo, k-1)
+		search(k+1, hi)
+	}
+	search(0, len(c.cells))
+}
+
+// split разбивает некешированные ячейки границы на компоненты и строит SAT каждой
+func (s *Solver) split() {
+	s.componentOf = make([]int, s.numMines)
+	s.localVar = make([]int, s.numMines)
+	for i := range s.componentOf {
+		s.componentOf[i] = -1
+	}
+
+	// Объединяем ячейки, входящие в одну метку
+	parent := make([]int, s.numMines)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(x int) int
+	find = func(x int) int {
+		for parent[x] != x {
+			parent[x] = parent[parent[x]]
+			x = parent[x]
+		}
+		return x
+	}
+	for _, mineList := range s.labelToMine {
+		for j := 1; j < len(mineList); j++ {
+			parent[find(mineList[j])] = find(mineList[0])
+		}
+	}
+
+	roots := make(map[int]int)
+	for _, m := range s.uncachedMines {
+		root := find(m)
+		c, ok := roots[root]
+		if !ok {
+			c = len(s.components)
+			roots[root] = c
+			s.components = append(s.components, &component{})
+		}
+		comp := s.components[c]
+		s.componentOf[m] = c
+		s.localVar[m] = len(comp.cells) + 1
+		comp.cells = append(comp.cells, m)
+	}
+
+	for _, comp := range s.components {
+		comp.sat = NewSat(len(comp.cells))
+	}
+	for l, mineList := range s.labelToMine {
+		if len(mineList) == 0 {
+			continue
+		}
+		comp := s.components[s.componentOf[mineList[0]]]
+		vars := make([]int, len(mineList))
+		for j, m := range mineList {
+			vars[j] = s.localVar[m]
+		}
+		comp.sat.AssertAtLeast(vars, s.labels[l])
+		comp.sat.AssertAtMost(vars, s.labels[l])
+	}
+
+	// Начальные решения компонент
+	for _, comp := range s.components {
+		solution := comp.sat.Solve()
+		if solution == nil {
+			s.unsat = true
+			return
+		}
+		comp.setModel(*solution)
+	}
+}
+
+// remainingBounds возвращает допустимое число мин среди некешированных ячеек границы
+func (s *Solver) remainingBounds() (int, int) {
+	lo := s.minMines - s.numCachedTrue
+	hi := s.maxMines - s.numCachedTrue
+	if hi < 0 {
+		hi = len(s.uncachedMines) // Как и раньше, отрицательный остаток не ограничивает
+	}
+	return lo, hi
+}
+
+// solveCell ищет решение, в котором ячейка границы idx - мина (isMine) или безопасна
+func (s *Solver) solveCell(idx int, isMine bool) *[]bool {
+	lo, hi := s.remainingBounds()
+	if s.cache[idx] != nil && s.componentOf[idx] == -1 {
+		if *s.cache[idx] != isMine {
+			return nil
+		}
+		return s.solveGlobal(-1, nil, lo, hi)
+	}
+	lit := s.localVar[idx]
+	if !isMine {
+		lit = -lit
+	}
+	return s.solveGlobal(s.componentOf[idx], []int{lit}, lo, hi)
+}
+
+// solveGlobal ищет решение всей границы с числом некешированных мин от lo до hi
+// Если c >= 0, в компоненте c дополнительно выполняются предположения assumptions.
+// Быстрый путь: решение компоненты c вместе с последними решениями остальных, если сумма
+// подходит. Иначе по допустимым числам мин остальных компонент выбирается, сколько мин
+// может быть в c, и каждой компоненте подбирается решение с нужным числом мин.
+// Возвращает решение в формате SAT всей границы (индекс i+1 - ячейка границы i) или nil
+func (s *Solver) solveGlobal(c int, assumptions []int, lo, hi int) *[]bool {
+	if s.unsat || lo > hi {
+		return nil
+	}
+
+	if c >= 0 {
+		solution := s.components[c].sat.SolveAssuming(assumptions)
+		if solution == nil {
+			return nil
+		}
+		s.components[c].setModel(*solution)
+	}
+	total := 0
+	for _, comp := range s.components {
+		total += comp.mines
+	}
+	if total >= lo && total <= hi {
+		return s.assemble()
+	}
+
+	// Достижимые суммы мин остальных компонент: reach[t][sum] по первым t компонентам
+	others := make([]*component, 0, len(s.components))
+	for d, comp := range s.components {
+		if d != c {
+			comp.computeCounts()
+			others = append(others, comp)
+		}
+	}
+	reach := make([][]bool, len(others)+1)
+	reach[0] = []bool{true}
+	for t, comp := range others {
+		reach[t+1] = make([]bool, len(reach[t])+len(comp.cells))
+		for sum, ok := range reach[t] {
+			if !ok {
+				continue
+			}
+			for k, possible := range comp.counts {
+				if possible {
+					reach[t+1][sum+k] = true
+				}
+			}
+		}
+	}
+	sums := reach[len(others)]
+
+	// Число мин в компоненте c, при котором остальные могут добрать сумму до [lo, hi]
+	own := 0
+	if c >= 0 {
+		comp := s.components[c]
+		allowed := func(k int) bool {
+			for sum, ok := range sums {
+				if ok && k+sum >= lo && k+sum <= hi {
+					return true
+				}
+			}
+			return false
+		}
+		found := false
+		for a := 0; a <= len(comp.cells) && !found; a++ {
+			if !allowed(a) {
+				continue
+			}
+			b := a
+			for b+1 <= len(comp.cells) && allowed(b+1) {
+				b++
+			}
+			if solution := comp.solveRange(assumptions, a, b); solution != nil {
+				comp.setModel(*solution)
+				found = true
+			}
+			a = b
+		}
+		if !found {
+			return nil
+		}
+		own = comp.mines
+	}
+
+	target := -1
+	for sum, ok := range sums {
+		if ok && own+sum >= lo && own+sum <= hi {
+			target = sum
+			break
+		}
+	}
+	if target == -1 {
+		return nil
+	}
+
+	// Раскладываем сумму по компонентам с конца
+	for t := len(others) - 1; t >= 0; t-- {
+		comp := others[t]
+		for k, possible := range comp.counts {
+			if possible && target-k >= 0 && target-k < len(reach[t]) && reach[t][target-k] {
+				if comp.mines != k {
+					solution := comp.solveRange(nil, k, k)
+					if solution == nil {
+						return nil
+					}
+					comp.setModel(*solution)
+				}
+				target -= k
+				break
+			}
+		}
+	}
+
+	return s.assemble()
+}
+
+// assemble собирает решение всей границы из кэша и текущих решений компонент
+func (s *Solver) assemble() *[]bool {
+	result := make([]bool, s.numMines+1)
+	for i := 0; i < s.numMines; i++ {
+		if c := s.componentOf[i]; c >= 0 {
+			result[i+1] = s.components[c].model[s.localVar[i]]
+		} else if s.cache[i] != nil {
+			result[i+1] = *s.cache[i]
+		}
+	}
+	return &result
+}
@@ -0,0 +1,71 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// testPosition позиция для проверки перебором
+type testPosition struct {
+	name  string
+	lm    *LabelMap
+	mines int
+}
+
+// cloneLabelMap копирует открытые ячейки позиции без кэша решателя
+func cloneLabelMap(lm *LabelMap) *LabelMap {
+	clone := NewLabelMap(lm.width, lm.height)
+	for i := range lm.labels {
+		copy(clone.labels[i], lm.labels[i])
+	}
+	clone.Recalc()
+	return clone
+}
+
+// countCoupled проверяет, что общее число мин отсекает часть расстановок, допустимых по меткам
+func countCoupled(lm *LabelMap, mines int) bool {
+	free := cloneLabelMap(lm)
+	free.numOutside = len(lm.boundary) + mines
+	coupled := false
+	boundaryLayouts(free, len(lm.boundary), func(layout []bool, k int) {
+		if k > mines || mines-k > lm.numOutside {
+			coupled = true
+		}
+	})
+	return coupled
+}
+
+// coupledPositions строит позиции, где граница распадается на несколько компонент,
+// связанных только общим числом мин, и это число отсекает часть сочетаний их расстановок
+func coupledPositions(seed int64, count int) []testPosition {
+	rng := rand.New(rand.NewSource(seed))
+	positions := make([]testPosition, 0, count)
+	for round := 0; len(positions) < count && round < 100000; round++ {
+		rows, cols := 4+rng.Intn(4), 4+rng.Intn(4)
+		mines := 2 + rng.Intn(rows*cols/2)
+		lm := randomPosition(rng, rows, cols, mines, 2+rng.Intn(4))
+		if len(lm.boundary) > maxBruteForceBoundary {
+			continue
+		}
+		if len(MakeSolver(cloneLabelMap(lm), mines).components) < 2 || !countCoupled(lm, mines) {
+			continue
+		}
+		positions = append(positions, testPosition{
+			name:  fmt.Sprintf("позиция %d (%dx%d, мин %d)", round, rows, cols, mines),
+			lm:    lm,
+			mines: mines,
+		})
+	}
+	return positions
+}
+
+func TestComponentsBruteForce(t *testing.T) {
+	positions := coupledPositions(1, 100)
+	if len(positions) < 100 {
+		t.Fatalf("найдено только %d позиций с несколькими связанными компонентами", len(positions))
+	}
+	for _, pos := range positions {
+		checkSolverBruteForce(t, pos.lm, pos.mines, pos.name)
+	}
+}
@@ -0,0 +1,297 @@
+package game
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ограничения поиска объяснения
+const (
+	maxExplanationLabels = 5     // Наибольшее число меток в минимальном объяснении
+	maxExplanationDepth  = 3     // Насколько далеко (через общие закрытые клетки) берутся метки
+	maxExplanationSets   = 20000 // Наибольшее число проверяемых наборов одного размера
+)
+
+// HintExplanation объяснение подсказки: наименьший набор открытых чисел, из которого
+// следует, что клетка мина или безопасна
+type HintExplanation struct {
+	Row     int
+	Col     int
+	IsMine  bool
+	Pattern string    // "BASIC", "SUBTRACTION", "1-2-1", "1-2-2-1", "COMPLEX", "MINE_COUNT", "GUESS"
+	Labels  []CellPos // Открытые клетки, числа которых участвуют в выводе
+	Cells   []CellPos // Закрытые клетки вокруг этих чисел (кроме самой клетки)
+}
+
+// ExplainCell ищет минимальное объяснение того, что клетка (row, col) мина (isMine) или безопасна
+// Перебираются связные наборы соседних чисел по возрастанию размера, каждый набор проверяется
+// отдельным SAT только по своим ограничениям. Если локальных наборов не хватает, вывод
+// проверяется по всей области и с учетом общего числа мин (totalMines)
+func ExplainCell(lm *LabelMap, totalMines, row, col int, isMine bool) *HintExplanation {
+	e := &HintExplanation{Row: row, Col: col, IsMine: isMine, Pattern: "GUESS"}
+
+	// Метки, связанные с клеткой через общие закрытые клетки, с расстоянием в шагах
+	adjacent := lm.labelsAround(row, col)
+	if len(adjacent) == 0 {
+		if solverForces(lm, totalMines, row, col, isMine) {
+			e.Pattern = "MINE_COUNT"
+		}
+		return e
+	}
+	depth := make(map[CellPos]int)
+	region := make([]CellPos, 0)
+	queue := make([]CellPos, 0)
+	for _, l := range adjacent {
+		depth[l] = 0
+		region = append(region, l)
+		queue = append(queue, l)
+	}
+	for len(queue) > 0 {
+		l := queue[0]
+		queue = queue[1:]
+		for _, c := range lm.closedAround(l.Row, l.Col) {
+			for _, next := range lm.labelsAround(c.Row, c.Col) {
+				if _, seen := depth[next]; seen {
+					continue
+				}
+				depth[next] = depth[l] + 1
+				region = append(region, next)
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	// Пул для перебора: метки не дальше maxExplanationDepth шагов
+	pool := make([]CellPos, 0)
+	for _, l := range region {
+		if depth[l] <= maxExplanationDepth {
+			pool = append(pool, l)
+		}
+	}
+	indexOf := make(map[CellPos]int, len(pool))
+	for i, l := range pool {
+		indexOf[l] = i
+	}
+	overlaps := make([][]int, len(pool))
+	for i, l := range pool {
+		seen := map[int]bool{i: true}
+		for _, c := range lm.closedAround(l.Row, l.Col) {
+			for _, next := range lm.labelsAround(c.Row, c.Col) {
+				if j, ok := indexOf[next]; ok && !seen[j] {
+					seen[j] = true
+					overlaps[i] = append(overlaps[i], j)
+				}
+			}
+		}
+	}
+
+	// Наборы размера k+1 получаются из связных наборов размера k добавлением соседней метки
+	level := make([][]int, 0, len(adjacent))
+	for _, l := range adjacent {
+		level = append(level, []int{indexOf[l]})
+	}
+	for size := 1; size <= maxExplanationLabels && len(level) > 0; size++ {
+		for _, set := range level {
+			labels := make([]CellPos, len(set))
+			for i, idx := range set {
+				labels[i] = pool[idx]
+			}
+			if lm.labelsForce(labels, row, col, isMine) {
+				e.setLabels(lm, labels)
+				e.Pattern = explanationPattern(lm, labels)
+				return e
+			}
+		}
+		if size == maxExplanationLabels {
+			break
+		}
+		next := make([][]int, 0)
+		seen := make(map[string]bool)
+		for _, set := range level {
+			in := make(map[int]bool, len(set))
+			for _, idx := range set {
+				in[idx] = true
+			}
+			for _, idx := range set {
+				for _, j := range overlaps[idx] {
+					if in[j] {
+						continue
+					}
+					grown := append(append(make([]int, 0, len(set)+1), set...), j)
+					sort.Ints(grown)
+					key := setKey(grown)
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+					next = append(next, grown)
+				}
+			}
+			if len(next) >= maxExplanationSets {
+				break
+			}
+		}
+		level = next
+	}
+
+	// Вывод требует больше меток: проверяем всю связную область
+	if lm.labelsForce(region, row, col, isMine) {
+		e.setLabels(lm, region)
+		e.Pattern = "COMPLEX"
+	} else if solverForces(lm, totalMines, row, col, isMine) {
+		e.setLabels(lm, region)
+		e.Pattern = "MINE_COUNT"
+	}
+	return e
+}
+
+// setLabels запоминает метки объяснения и закрытые клетки вокруг них
+func (e *HintExplanation) setLabels(lm *LabelMap, labels []CellPos) {
+	e.Labels = append([]CellPos(nil), labels...)
+	sortCells(e.Labels)
+	seen := make(map[CellPos]bool)
+	e.Cells = make([]CellPos, 0)
+	for _, l := range labels {
+		for _, c := range lm.closedAround(l.Row, l.Col) {
+			if seen[c] || (c.Row == e.Row && c.Col == e.Col) {
+				continue
+			}
+			seen[c] = true
+			e.Cells = append(e.Cells, c)
+		}
+	}
+	sortCells(e.Cells)
+}
+
+// labelsForce проверяет, что ограничения только этих меток делают клетку миной (isMine) или безопасной
+func (lm *LabelMap) labelsForce(labels []CellPos, row, col int, isMine bool) bool {
+	vars := make(map[CellPos]int)
+	constraints := make([][]int, len(labels))
+	for i, l := range labels {
+		for _, c := range lm.closedAround(l.Row, l.Col) {
+			v, ok := vars[c]
+			if !ok {
+				v = len(vars) + 1
+				vars[c] = v
+			}
+			constraints[i] = append(constraints[i], v)
+		}
+	}
+	target, ok := vars[CellPos{Row: row, Col: col}]
+	if !ok {
+		return false
+	}
+
+	sat := NewSat(len(vars))
+	for i, l := range labels {
+		sat.AssertAtLeast(constraints[i], lm.labels[l.Row][l.Col])
+		sat.AssertAtMost(constraints[i], lm.labels[l.Row][l.Col])
+	}
+	// Противоположное значение клетки должно быть невозможно
+	if isMine {
+		target = -target
+	}
+	return sat.SolveAssuming([]int{target}) == nil
+}
+
+// solverForces проверяет вывод полным решателем с учетом общего числа мин
+func solverForces(lm *LabelMap, totalMines, row, col int, isMine bool) bool {
+	fresh := NewLabelMap(lm.width, lm.height)
+	for i := range lm.labels {
+		copy(fresh.labels[i], lm.labels[i])
+	}
+	fresh.Recalc()
+	solver := MakeSolver(fresh, totalMines)
+
+	idx := fresh.GetBoundaryIndex(row, col)
+	if idx == -1 {
+		if len(fresh.GetBoundary()) == 0 {
+			return false
+		}
+		if isMine {
+			return !solver.OutsideCanBeSafe()
+		}
+		return solver.OutsideIsSafe()
+	}
+	if isMine {
+		return !solver.CanBeSafe(idx)
+	}
+	return !solver.CanBeDangerous(idx)
+}
+
+// explanationPattern называет известный шаблон по набору меток
+func explanationPattern(lm *LabelMap, labels []CellPos) string {
+	switch len(labels) {
+	case 1:
+		return "BASIC"
+	case 2:
+		return "SUBTRACTION"
+	}
+	line := append([]CellPos(nil), labels...)
+	sortCells(line)
+	sameRow, sameCol := true, true
+	for i := 1; i < len(line); i++ {
+		if line[i].Row != line[0].Row || line[i].Col != line[i-1].Col+1 {
+			sameRow = false
+		}
+		if line[i].Col != line[0].Col || line[i].Row != line[i-1].Row+1 {
+			sameCol = false
+		}
+	}
+	if !sameRow && !sameCol {
+		return "COMPLEX"
+	}
+	values := make([]string, len(line))
+	for i, l := range line {
+		values[i] = strconv.Itoa(lm.labels[l.Row][l.Col])
+	}
+	switch pattern := strings.Join(values, "-"); pattern {
+	case "1-2-1", "1-2-2-1":
+		return pattern
+	}
+	return "COMPLEX"
+}
+
+// labelsAround возвращает открытые соседние клетки
+func (lm *LabelMap) labelsAround(row, col int) []CellPos {
+	return lm.around(row, col, true)
+}
+
+// closedAround возвращает закрытые соседние клетки
+func (lm *LabelMap) closedAround(row, col int) []CellPos {
+	return lm.around(row, col, false)
+}
+
+func (lm *LabelMap) around(row, col int, revealed bool) []CellPos {
+	cells := make([]CellPos, 0, 8)
+	for di := -1; di <= 1; di++ {
+		for dj := -1; dj <= 1; dj++ {
+			ni, nj := row+di, col+dj
+			if (di == 0 && dj == 0) || ni < 0 || ni >= lm.height || nj < 0 || nj >= lm.width {
+				continue
+			}
+			if (lm.labels[ni][nj] != -1) == revealed {
+				cells = append(cells, CellPos{Row: ni, Col: nj})
+			}
+		}
+	}
+	return cells
+}
+
+func sortCells(cells []CellPos) {
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Row != cells[j].Row {
+			return cells[i].Row < cells[j].Row
+		}
+		return cells[i].Col < cells[j].Col
+	})
+}
+
+func setKey(set []int) string {
+	parts := make([]string, len(set))
+	for i, idx := range set {
+		parts[i] = strconv.Itoa(idx)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,258 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// explainBoard строит позицию из строк: 'o' - открытая клетка (число считается по минам вокруг),
+// '*' - закрытая мина, '.' - закрытая безопасная клетка
+func explainBoard(rows ...string) *LabelMap {
+	lm := NewLabelMap(len(rows[0]), len(rows))
+	for i, row := range rows {
+		for j := range row {
+			if row[j] != 'o' {
+				continue
+			}
+			n := 0
+			for di := -1; di <= 1; di++ {
+				for dj := -1; dj <= 1; dj++ {
+					ni, nj := i+di, j+dj
+					if ni >= 0 && ni < len(rows) && nj >= 0 && nj < len(row) && rows[ni][nj] == '*' {
+						n++
+					}
+				}
+			}
+			lm.labels[i][j] = n
+		}
+	}
+	lm.Recalc()
+	return lm
+}
+
+// informativeLabels открытые клетки, у которых есть закрытые соседи
+func informativeLabels(lm *LabelMap) []CellPos {
+	labels := make([]CellPos, 0)
+	for i := 0; i < lm.height; i++ {
+		for j := 0; j < lm.width; j++ {
+			if lm.labels[i][j] != -1 && len(lm.closedAround(i, j)) > 0 {
+				labels = append(labels, CellPos{Row: i, Col: j})
+			}
+		}
+	}
+	return labels
+}
+
+// bruteForceForces перебирает расстановки мин вокруг меток и проверяет, что во всех
+// согласованных с этими метками расстановках клетка (row, col) мина (isMine) или безопасна
+func bruteForceForces(lm *LabelMap, labels []CellPos, row, col int, isMine bool) bool {
+	index := make(map[CellPos]int)
+	masks := make([]int, len(labels))
+	for i, l := range labels {
+		for _, c := range lm.closedAround(l.Row, l.Col) {
+			idx, ok := index[c]
+			if !ok {
+				idx = len(index)
+				index[c] = idx
+			}
+			masks[i] |= 1 << idx
+		}
+	}
+	target, ok := index[CellPos{Row: row, Col: col}]
+	if !ok {
+		return false
+	}
+	for mask := 0; mask < 1<<len(index); mask++ {
+		consistent := true
+		for i, l := range labels {
+			if popcount(mask&masks[i]) != lm.labels[l.Row][l.Col] {
+				consistent = false
+				break
+			}
+		}
+		if consistent && (mask&(1<<target) != 0) != isMine {
+			return false
+		}
+	}
+	return true
+}
+
+// smallerForcingSet ищет перебором набор меньше size меток, из которого следует тот же вывод
+func smallerForcingSet(lm *LabelMap, row, col int, isMine bool, size int) []CellPos {
+	labels := informativeLabels(lm)
+	set := make([]CellPos, 0, size)
+	var search func(start int) []CellPos
+	search = func(start int) []CellPos {
+		if len(set) > 0 && bruteForceForces(lm, set, row, col, isMine) {
+			return append([]CellPos(nil), set...)
+		}
+		if len(set) == size-1 {
+			return nil
+		}
+		for i := start; i < len(labels); i++ {
+			set = append(set, labels[i])
+			if found := search(i + 1); found != nil {
+				return found
+			}
+			set = set[:len(set)-1]
+		}
+		return nil
+	}
+	return search(0)
+}
+
+func TestExplainCell(t *testing.T) {
+	tests := []struct {
+		name        string
+		board       []string
+		row, col    int
+		wantPattern string
+		wantLabels  []CellPos
+	}{
+		{
+			name:        "BASIC: у единицы одна закрытая клетка",
+			board:       []string{"*o", "oo"},
+			row:         0,
+			col:         0,
+			wantPattern: "BASIC",
+			wantLabels:  []CellPos{{Row: 0, Col: 1}},
+		},
+		{
+			name:        "SUBTRACTION: 1-2 у стены",
+			board:       []string{"...**...", "..oooo.."},
+			row:         0,
+			col:         1,
+			wantPattern: "SUBTRACTION",
+			wantLabels:  []CellPos{{Row: 1, Col: 2}, {Row: 1, Col: 3}},
+		},
+		{
+			name:        "1-2-1: клетка над единицей",
+			board:       []string{"*.*.....", "ooo....."},
+			row:         0,
+			col:         1,
+			wantPattern: "1-2-1",
+			wantLabels:  []CellPos{{Row: 1, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}},
+		},
+		{
+			name:        "COMPLEX: три метки не в одну линию",
+			board:       []string{"...**...", "..oooo.."},
+			row:         0,
+			col:         2,
+			wantPattern: "COMPLEX",
+			wantLabels:  []CellPos{{Row: 1, Col: 2}, {Row: 1, Col: 4}, {Row: 1, Col: 5}},
+		},
+		{
+			name:        "GUESS: метки не определяют клетку",
+			board:       []string{"*..", "o..", "..."},
+			row:         0,
+			col:         1,
+			wantPattern: "GUESS",
+		},
+	}
+
+	for _, tt := range tests {
+		lm := explainBoard(tt.board...)
+		mines := 0
+		for _, row := range tt.board {
+			mines += strings.Count(row, "*")
+		}
+		isMine := tt.board[tt.row][tt.col] == '*'
+		e := ExplainCell(lm, mines, tt.row, tt.col, isMine)
+		if e.Pattern != tt.wantPattern {
+			t.Errorf("%s: шаблон %q, ожидался %q", tt.name, e.Pattern, tt.wantPattern)
+		}
+		if len(e.Labels) != len(tt.wantLabels) || len(tt.wantLabels) > 0 && !reflect.DeepEqual(e.Labels, tt.wantLabels) {
+			t.Errorf("%s: метки %v, ожидались %v", tt.name, e.Labels, tt.wantLabels)
+		}
+		if e.Pattern == "GUESS" {
+			continue
+		}
+		if !bruteForceForces(lm, e.Labels, tt.row, tt.col, isMine) {
+			t.Errorf("%s: из меток %v вывод не следует", tt.name, e.Labels)
+		}
+		if smaller := smallerForcingSet(lm, tt.row, tt.col, isMine, len(e.Labels)); smaller != nil {
+			t.Errorf("%s: объяснение %v не минимально, хватает %v", tt.name, e.Labels, smaller)
+		}
+	}
+}
+
+func TestExplanationPattern(t *testing.T) {
+	// Числа в строке и столбце: 1 2 2 1 по строке 1 и 1 2 1 по столбцу 5
+	lm := NewLabelMap(7, 5)
+	for j, v := range []int{1, 2, 2, 1, 2, 2} {
+		lm.SetLabel(1, j, v)
+	}
+	for i, v := range []int{1, 2, 1} {
+		lm.SetLabel(2+i, 5, v)
+	}
+	lm.SetLabel(3, 6, 2)
+	lm.Recalc()
+
+	pos := func(row, col int) CellPos { return CellPos{Row: row, Col: col} }
+	tests := []struct {
+		name   string
+		labels []CellPos
+		want   string
+	}{
+		{"одна метка", []CellPos{pos(1, 1)}, "BASIC"},
+		{"две метки", []CellPos{pos(1, 0), pos(1, 1)}, "SUBTRACTION"},
+		{"1-2-2-1 по строке", []CellPos{pos(1, 0), pos(1, 1), pos(1, 2), pos(1, 3)}, "1-2-2-1"},
+		{"1-2-2-1 в произвольном порядке", []CellPos{pos(1, 3), pos(1, 1), pos(1, 0), pos(1, 2)}, "1-2-2-1"},
+		{"1-2-1 по столбцу", []CellPos{pos(2, 5), pos(3, 5), pos(4, 5)}, "1-2-1"},
+		{"2-2-1 по строке", []CellPos{pos(1, 1), pos(1, 2), pos(1, 3)}, "COMPLEX"},
+		{"линия с разрывом", []CellPos{pos(1, 0), pos(1, 1), pos(1, 3)}, "COMPLEX"},
+		{"не на одной линии", []CellPos{pos(2, 5), pos(3, 5), pos(3, 6)}, "COMPLEX"},
+	}
+	for _, tt := range tests {
+		if got := explanationPattern(lm, tt.labels); got != tt.want {
+			t.Errorf("%s: шаблон %q, ожидался %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestExplainCellMinimalBruteForce сверяет объяснения на случайных позициях с перебором наборов меток:
+// из выбранных меток вывод следует, а меньшего набора нет
+func TestExplainCellMinimalBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	explained := 0
+	for round := 0; round < 60; round++ {
+		rows, cols := 4+rng.Intn(2), 4+rng.Intn(3)
+		mines := 2 + rng.Intn(rows*cols/3)
+		lm := randomPosition(rng, rows, cols, mines, 1+rng.Intn(3))
+		if len(lm.boundary) > 14 {
+			continue
+		}
+		all := informativeLabels(lm)
+		for _, cell := range lm.boundary {
+			for _, isMine := range []bool{false, true} {
+				name := fmt.Sprintf("раунд %d (%dx%d, мин %d), клетка %v, мина=%v", round, rows, cols, mines, cell, isMine)
+				e := ExplainCell(lm, mines, cell.Row, cell.Col, isMine)
+				if !bruteForceForces(lm, all, cell.Row, cell.Col, isMine) {
+					if e.Pattern != "GUESS" && e.Pattern != "MINE_COUNT" {
+						t.Errorf("%s: шаблон %q, но метки вывод не дают", name, e.Pattern)
+					}
+					continue
+				}
+				explained++
+				if !bruteForceForces(lm, e.Labels, cell.Row, cell.Col, isMine) {
+					t.Errorf("%s: из меток %v вывод не следует", name, e.Labels)
+					continue
+				}
+				// Связные наборы до maxExplanationLabels-1 меток всегда попадают в перебор ExplainCell
+				size := len(e.Labels)
+				if size > maxExplanationLabels {
+					size = maxExplanationLabels
+				}
+				if smaller := smallerForcingSet(lm, cell.Row, cell.Col, isMine, size); smaller != nil {
+					t.Errorf("%s: объяснение %v (%s) не минимально, хватает %v", name, e.Labels, e.Pattern, smaller)
+				}
+			}
+		}
+	}
+	if explained < 100 {
+		t.Fatalf("перебором проверено только %d объяснений", explained)
+	}
+}
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"fmt"
 	"log"
 	mathrand "math/rand"
 
@@ -9,8 +10,9 @@ import (
 
 // NewGameState создает новое состояние игры
 // seed: если пустая строка, генерируется новый UUID; иначе используется переданный
-func NewGameState(rows, cols, mines int, gameMode string, seed string) *GameState {
-	log.Printf("NewGameState: начало создания, rows=%d, cols=%d, mines=%d, gameMode=%s, seed=%s", rows, cols, mines, gameMode, seed)
+// noGuess: в классическом режиме мины размещаются при первом клике так, чтобы поле решалось без угадываний
+func NewGameState(rows, cols, mines int, gameMode string, noGuess bool, seed string) *GameState {
+	log.Printf("NewGameState: начало создания, rows=%d, cols=%d, mines=%d, gameMode=%s, noGuess=%v, seed=%s", rows, cols, mines, gameMode, noGuess, seed)
 	// По умолчанию classic
 	if gameMode == "" {
 		gameMode = "classic"
@@ -43,8 +45,9 @@ func NewGameState(rows, cols, mines int, gameMode string, seed string) *GameStat
 	log.Printf("NewGameState: поле инициализировано")
 
 	// В режимах training и fair мины НЕ размещаются заранее - они определяются динамически при клике
-	// В классическом режиме размещаем мины случайно
-	if gameMode == "classic" {
+	// В режиме без угадываний мины размещаются при первом клике (PlaceNoGuessMines)
+	// В классическом режиме, в гонке, в матче команд и в пошаговом режиме размещаем мины случайно (у всех одно поле по seed)
+	if (gameMode == "classic" && !noGuess) || gameMode == "race" || gameMode == "versus" || gameMode == "turns" {
 		log.Printf("NewGameState: размещаем мины в классическом режиме с seed=%s", seed)
 		// Конвертируем UUID в int64 для использования в math/rand
 		seedInt64 := utils.UUIDToInt64(seed)
@@ -101,15 +104,32 @@ func (gs *GameState) Copy() *GameState {
 		GameWon:       gs.GameWon,
 		Revealed:      gs.Revealed,
 		HintsUsed:     gs.HintsUsed,
+		NoGuess:       gs.NoGuess,
 		SafeCells:     make([]SafeCell, len(gs.SafeCells)),
 		CellHints:     make([]CellHint, len(gs.CellHints)),
 		LoserPlayerID: gs.LoserPlayerID,
 		LoserNickname: gs.LoserNickname,
 		Board:         make([][]Cell, len(gs.Board)),
 		FlagSetInfo:   make(map[int]FlagInfo),
+		Moves:         make([]Move, len(gs.Moves)),
 	}
 
 	copy(gsCopy.SafeCells, gs.SafeCells)
+	copy(gsCopy.Moves, gs.Moves)
+	if gs.ClickStats != nil {
+		gsCopy.ClickStats = make(map[string]*ClickStats, len(gs.ClickStats))
+		for id, stats := range gs.ClickStats {
+			statsCopy := *stats
+			gsCopy.ClickStats[id] = &statsCopy
+		}
+	}
+	if gs.Contributions != nil {
+		gsCopy.Contributions = make(map[string]*Contribution, len(gs.Contributions))
+		for id, c := range gs.Contributions {
+			cCopy := *c
+			gsCopy.Contributions[id] = &cCopy
+		}
+	}
 	copy(gsCopy.CellHints, gs.CellHints)
 	for k, v := range gs.FlagSetInfo {
 		gsCopy.FlagSetInfo[k] = v
@@ -226,6 +246,66 @@ func (gs *GameState) EnsureFirstClickSafe(firstRow, firstCol int) {
 	gs.calculateNeighborMines()
 }
 
+// noGuessMaxAttempts количество попыток генерации поля без угадываний
+// Плотность мин ограничена ValidateNoGuess, на допустимых полях решаемое поле находится за десятки попыток
+const noGuessMaxAttempts = 2000
+
+// Наибольшая плотность мин вне области первого клика, при которой поле без угадываний находится надежно
+const (
+	noGuessMaxDensity       = 0.215 // Эксперт 30x16/99 - 0.21
+	noGuessMaxDensityNarrow = 0.15  // Узкие длинные поля (сторона до 8 и втрое длиннее) решаются хуже
+)
+
+// NoGuessMaxMines возвращает наибольшее число мин, при котором для поля можно сгенерировать расстановку без угадываний
+func NoGuessMaxMines(rows, cols int) int {
+	short, long := rows, cols
+	if short > long {
+		short, long = long, short
+	}
+	density := noGuessMaxDensity
+	if short <= 8 && long >= 3*short {
+		density = noGuessMaxDensityNarrow
+	}
+	return int(density * float64(rows*cols-9))
+}
+
+// ValidateNoGuess проверяет, что режим без угадываний допустим для поля
+func ValidateNoGuess(rows, cols, mines int) error {
+	if maxMines := NoGuessMaxMines(rows, cols); mines > maxMines {
+		return fmt.Errorf("No-guess mode allows at most %d mines on a %dx%d field", maxMines, cols, rows)
+	}
+	return nil
+}
+
+// GenerateNoGuessBoard генерирует поле без угадываний для первого клика (firstRow, firstCol)
+// Генератор инициализируется seed и координатами первого клика, поэтому поле воспроизводимо
+// по seed и первому ходу записи игры. Состояние игры не используется: на плотных полях генерация
+// занимает до секунд, поэтому вызывается без gs.Mu.
+// Если решаемое поле не найдено, возвращает последнее сгенерированное (первый клик на нем безопасен) и false
+func GenerateNoGuessBoard(seed string, rows, cols, mines, firstRow, firstCol int) ([][]bool, bool) {
+	seedInt64 := utils.UUIDToInt64(seed) + int64(firstRow*cols+firstCol)
+	rng := mathrand.New(mathrand.NewSource(seedInt64))
+	return GenerateSolvableBoard(rows, cols, mines, firstRow, firstCol, rng, noGuessMaxAttempts)
+}
+
+// PlaceNoGuessMines размещает поле, сгенерированное GenerateNoGuessBoard
+// NoGuess выставляется, только если поле решаемо: иначе игра не засчитывается как игра без угадываний
+// ВАЖНО: вызывающий код должен удерживать gs.Mu
+func (gs *GameState) PlaceNoGuessMines(board [][]bool, solvable bool) {
+	for i := 0; i < gs.Rows; i++ {
+		for j := 0; j < gs.Cols; j++ {
+			gs.Board[i][j].IsMine = board[i][j]
+		}
+	}
+	gs.calculateNeighborMines()
+	gs.NoGuess = solvable
+
+	if !solvable {
+		log.Printf("ВНИМАНИЕ: PlaceNoGuessMines: решаемое поле %dx%d/%d не найдено за %d попыток (seed=%s), игра идет на обычном поле и не засчитывается как игра без угадываний",
+			gs.Cols, gs.Rows, gs.Mines, noGuessMaxAttempts, gs.Seed)
+	}
+}
+
 // RevealNeighbors открывает соседние пустые ячейки и возвращает измененные ячейки
 func (gs *GameState) RevealNeighbors(row, col int, changedCells map[[2]int]bool) {
 	for di := -1; di <= 1; di++ {
@@ -0,0 +1,41 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestValidateNoGuess(t *testing.T) {
+	tests := []struct {
+		rows, cols, mines int
+		ok                bool
+	}{
+		{9, 9, 10, true},
+		{16, 16, 40, true},
+		{16, 30, 99, true},
+		{16, 30, 120, false},
+		{5, 50, 36, true},
+		{5, 50, 45, false},
+		{8, 8, 11, true},
+	}
+	for _, tt := range tests {
+		err := ValidateNoGuess(tt.rows, tt.cols, tt.mines)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateNoGuess(%d, %d, %d) = %v, ожидалось допустимо = %v", tt.rows, tt.cols, tt.mines, err, tt.ok)
+		}
+	}
+}
+
+// На наибольшей допустимой плотности решаемое поле находится с любого первого клика
+func TestGenerateNoGuessBoardAtLimit(t *testing.T) {
+	for _, size := range [][2]int{{9, 9}, {16, 30}, {5, 50}, {8, 24}} {
+		rows, cols := size[0], size[1]
+		mines := NoGuessMaxMines(rows, cols)
+		for i, first := range [][2]int{{0, 0}, {rows / 2, cols / 2}, {rows - 1, 0}} {
+			seed := fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+			if _, ok := GenerateNoGuessBoard(seed, rows, cols, mines, first[0], first[1]); !ok {
+				t.Errorf("поле %dx%d/%d с первым кликом %v не сгенерировано", cols, rows, mines, first)
+			}
+		}
+	}
+}
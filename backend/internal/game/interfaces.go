@@ -1,8 +1,10 @@
 package game
 
+import "minesweeperonline/internal/achievements"
+
 // GameResultRecorder интерфейс для записи результатов игры
 type GameResultRecorder interface {
-	RecordGameResult(userID, cols, rows, mines int, gameTime float64, won bool, chording bool, quickStart bool, roomID string, seed string, hasCustomSeed bool, creatorID int, participants []GameParticipant) error
+	RecordGameResult(userID, cols, rows, mines int, gameTime float64, won bool, chording bool, quickStart bool, roomID string, seed string, hasCustomSeed bool, creatorID int, participants []GameParticipant, details GameResultDetails) ([]achievements.Achievement, error)
 }
 
 // GameParticipant представляет участника игры
@@ -10,5 +12,24 @@ type GameParticipant struct {
 	UserID   int
 	Nickname string
 	Color    string
+	Score    *int // Итоговый счет игрока (пошаговый режим)
+	Contribution *Contribution // Вклад игрока в совместную игру (nil - не отслеживается)
 }
 
+
+// GameResultDetails дополнительные сведения о завершенной игре
+type GameResultDetails struct {
+	Replay      *GameReplay // Запись ходов для воспроизведения (может быть nil)
+	GameMode    string      // "classic", "training", "fair", "race", "versus", "turns"
+	HintsUsed   int         // Количество использованных подсказок в игре
+	FlagsPlaced int         // Количество флагов, поставленных игроком
+	ThreeBV     int         // 3BV поля
+	Clicks      ClickStats  // Клики игрока за игру
+	NoGuess     bool        // Поле без угадываний (проверено Solver при генерации)
+	Placement   int         // Место игрока в гонке или команды в матче (0 - не соревнование)
+	LivesLost   int         // Жизни, потерянные за игру (режим жизней)
+	Score       int         // Открытые клетки в игре на счет (режим score) или очки пошагового режима
+	ContributionShare *float64 // Множитель рейтинга за вклад в совместную игру (nil - игрок был один)
+	Players     int         // Люди, игравшие в игре, включая гостей
+	Completed   bool        // Игра окончена без победы и поражения (время вышло в игре на счет)
+}
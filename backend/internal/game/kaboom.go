@@ -1,307 +1,10 @@
 package game
 
 import (
+	"log"
 	"math/rand"
 )
 
-// SAT solver для проверки решаемости поля
-type Sat struct {
-	numVars int
-	clauses [][]int
-	// Кэш для быстрого доступа к unit clauses
-	unitClauses map[int]bool // var -> value (true/false)
-}
-
-func NewSat(numVars int) *Sat {
-	return &Sat{
-		numVars:     numVars,
-		clauses:     make([][]int, 0),
-		unitClauses: make(map[int]bool),
-	}
-}
-
-// Assert добавляет дизъюнкцию (clause)
-func (s *Sat) Assert(vars []int) {
-	if len(vars) == 1 {
-		// Unit clause - сохраняем для быстрого доступа
-		varIdx := abs(vars[0])
-		if varIdx <= s.numVars {
-			s.unitClauses[varIdx] = vars[0] > 0
-		}
-	}
-	s.clauses = append(s.clauses, vars)
-}
-
-// AssertAtLeast добавляет ограничение: хотя бы k переменных из vars должны быть true
-func (s *Sat) AssertAtLeast(vars []int, k int) {
-	if k <= 0 {
-		return
-	}
-	if k > len(vars) {
-		// Невозможно - добавляем невыполнимое условие
-		s.Assert([]int{1, -1}) // Противоречие
-		return
-	}
-	size := len(vars) - k + 1
-	for _, comb := range combinations(vars, size) {
-		s.Assert(comb)
-	}
-}
-
-// AssertAtMost добавляет ограничение: не более k переменных из vars могут быть true
-func (s *Sat) AssertAtMost(vars []int, k int) {
-	if k < 0 {
-		s.Assert([]int{1, -1}) // Противоречие
-		return
-	}
-	if k >= len(vars) {
-		return // Всегда выполнимо
-	}
-	size := k + 1
-	for _, comb := range combinations(vars, size) {
-		negated := make([]int, len(comb))
-		for i, v := range comb {
-			negated[i] = -v
-		}
-		s.Assert(negated)
-	}
-}
-
-// SolveWith решает SAT с дополнительным условием
-// Оптимизировано: не копируем clauses, а используем стек
-func (s *Sat) SolveWith(additional func()) *[]bool {
-	// Сохраняем текущее состояние
-	oldClausesLen := len(s.clauses)
-	oldUnitClauses := make(map[int]bool)
-	for k, v := range s.unitClauses {
-		oldUnitClauses[k] = v
-	}
-	
-	// Добавляем дополнительное условие
-	if additional != nil {
-		additional()
-	}
-	
-	// Решаем
-	result := s.Solve()
-	
-	// Восстанавливаем состояние (удаляем добавленные clauses)
-	if len(s.clauses) > oldClausesLen {
-		s.clauses = s.clauses[:oldClausesLen]
-	}
-	
-	// Восстанавливаем unit clauses (удаляем только те, что были добавлены)
-	s.unitClauses = oldUnitClauses
-	
-	return result
-}
-
-// Solve решает SAT задачу (простая реализация DPLL)
-func (s *Sat) Solve() *[]bool {
-	if len(s.clauses) == 0 {
-		// Всегда выполнимо
-		result := make([]bool, s.numVars+1)
-		return &result
-	}
-	
-	// Простая реализация DPLL
-	assignment := make([]bool, s.numVars+1)
-	used := make([]bool, s.numVars+1)
-	
-	return s.dpll(assignment, used, 0)
-}
-
-func (s *Sat) dpll(assignment []bool, used []bool, depth int) *[]bool {
-	// Unit propagation: применяем unit clauses из кэша
-	for varIdx, val := range s.unitClauses {
-		if varIdx <= s.numVars && !used[varIdx] {
-			assignment[varIdx] = val
-			used[varIdx] = true
-		}
-	}
-	
-	// Проверяем unit clauses из clauses (на случай если они не в кэше)
-	for {
-		unitFound := false
-		for _, clause := range s.clauses {
-			if len(clause) == 1 {
-				lit := clause[0]
-				varIdx := abs(lit)
-				if varIdx > s.numVars {
-					continue
-				}
-				if !used[varIdx] {
-					assignment[varIdx] = lit > 0
-					used[varIdx] = true
-					unitFound = true
-					break
-				}
-			}
-		}
-		if !unitFound {
-			break
-		}
-	}
-	
-	// Объединенная проверка: противоречия и удовлетворенность
-	allSatisfied := true
-	for _, clause := range s.clauses {
-		satisfied := false
-		hasUnassigned := false
-		
-		for _, lit := range clause {
-			varIdx := abs(lit)
-			if varIdx > s.numVars {
-				continue
-			}
-			if used[varIdx] {
-				val := assignment[varIdx]
-				if (lit > 0 && val) || (lit < 0 && !val) {
-					satisfied = true
-					break
-				}
-			} else {
-				hasUnassigned = true
-			}
-		}
-		
-		if !satisfied {
-			if !hasUnassigned {
-				// Противоречие: все литералы ложны
-				return nil
-			}
-			allSatisfied = false
-		}
-	}
-	
-	// Ранний выход: если все клаузы выполнены
-	if allSatisfied {
-		return &assignment
-	}
-	
-	// Выбираем неиспользованную переменную (оптимизация: выбираем из наиболее часто встречающихся)
-	var nextVar int
-	for i := 1; i <= s.numVars; i++ {
-		if !used[i] {
-			nextVar = i
-			break
-		}
-	}
-	
-	if nextVar == 0 {
-		// Все переменные использованы, но решение не найдено
-		return nil
-	}
-	
-	// Пробуем true
-	assignment[nextVar] = true
-	used[nextVar] = true
-	if result := s.dpll(assignment, used, depth+1); result != nil {
-		return result
-	}
-	
-	// Пробуем false
-	assignment[nextVar] = false
-	if result := s.dpll(assignment, used, depth+1); result != nil {
-		return result
-	}
-	
-	used[nextVar] = false
-	return nil
-}
-
-// AddCounter добавляет счетчик для переменных (для ограничений на количество мин)
-func (s *Sat) AddCounter(vars []int) []int {
-	if len(vars) <= 1 {
-		return vars
-	}
-	
-	mid := len(vars) / 2
-	left := s.AddCounter(vars[:mid])
-	right := s.AddCounter(vars[mid:])
-	
-	counter := make([]int, len(vars))
-	for i := range counter {
-		s.numVars++
-		counter[i] = s.numVars
-	}
-	
-	// Добавляем ограничения для счетчика
-	for a := 0; a <= len(left); a++ {
-		for b := 0; b <= len(right); b++ {
-			if a > 0 && b > 0 {
-				s.Assert([]int{-left[a-1], -right[b-1], counter[a+b-1]})
-			} else if a > 0 {
-				s.Assert([]int{-left[a-1], counter[a-1]})
-			} else if b > 0 {
-				s.Assert([]int{-right[b-1], counter[b-1]})
-			}
-			
-			if a < len(left) && b < len(right) {
-				s.Assert([]int{left[a], right[b], -counter[a+b]})
-			} else if a < len(left) {
-				s.Assert([]int{left[a], -counter[a+b]})
-			} else if b < len(right) {
-				s.Assert([]int{right[b], -counter[a+b]})
-			}
-		}
-	}
-	
-	return counter
-}
-
-// AssertCounterAtLeast: хотя бы k переменных из счетчика должны быть true
-func (s *Sat) AssertCounterAtLeast(counter []int, k int) {
-	for i := 0; i < k && i < len(counter); i++ {
-		s.Assert([]int{counter[i]})
-	}
-}
-
-// AssertCounterAtMost: не более k переменных из счетчика могут быть true
-func (s *Sat) AssertCounterAtMost(counter []int, k int) {
-	for i := k; i < len(counter); i++ {
-		s.Assert([]int{-counter[i]})
-	}
-}
-
-// Вспомогательные функции
-func abs(x int) int {
-	if x < 0 {
-		return -x
-	}
-	return x
-}
-
-func combinations(list []int, n int) [][]int {
-	if n == 0 {
-		return [][]int{{}}
-	}
-	if len(list) < n {
-		return [][]int{}
-	}
-	if n == len(list) {
-		return [][]int{list}
-	}
-	
-	result := make([][]int, 0)
-	
-	// Рекурсивно генерируем комбинации
-	var generate func(start int, current []int, remaining int)
-	generate = func(start int, current []int, remaining int) {
-		if remaining == 0 {
-			result = append(result, append([]int{}, current...))
-			return
-		}
-		
-		for i := start; i <= len(list)-remaining; i++ {
-			generate(i+1, append(current, list[i]), remaining-1)
-		}
-	}
-	
-	generate(0, []int{}, n)
-	return result
-}
-
 // LabelMap представляет карту открытых ячеек и границы
 type LabelMap struct {
 	width       int
@@ -474,12 +177,14 @@ type Solver struct {
 	labels        []int
 	labelToMine   [][]int
 	cache         []*bool
-	sat           *Sat
 	canBeSafe     []bool
 	canBeDangerous []bool
 	uncachedMines []int
 	numCachedTrue int
-	counter       []int
+	components    []*component // Независимые части границы, у каждой свой SAT
+	componentOf   []int        // Ячейка границы -> компонента, -1 для кешированных
+	localVar      []int        // Ячейка границы -> переменная в SAT своей компоненты
+	unsat         bool         // Метки противоречивы
 }
 
 func NewSolver(lm *LabelMap, numMines, minMines, maxMines int) *Solver {
@@ -491,7 +196,6 @@ func NewSolver(lm *LabelMap, numMines, minMines, maxMines int) *Solver {
 		labels:        make([]int, 0),
 		labelToMine:   make([][]int, 0),
 		cache:         make([]*bool, numMines),
-		sat:           NewSat(numMines),
 		canBeSafe:     make([]bool, numMines),
 		canBeDangerous: make([]bool, numMines),
 		uncachedMines: make([]int, 0),
@@ -530,49 +234,8 @@ func (s *Solver) AddLabel(label int, mineList []int) {
 
 // Run запускает решатель
 func (s *Solver) Run() {
-	// Добавляем ограничения из меток
-	for i := 0; i < len(s.labels); i++ {
-		label := s.labels[i]
-		vars := make([]int, len(s.labelToMine[i]))
-		for j, m := range s.labelToMine[i] {
-			vars[j] = m + 1 // SAT переменные начинаются с 1
-		}
-		
-		if len(vars) > 0 {
-			s.sat.AssertAtLeast(vars, label)
-			s.sat.AssertAtMost(vars, label)
-		}
-	}
-	
-	// Добавляем ограничения из кэша
-	for i := 0; i < s.numMines; i++ {
-		if s.cache[i] != nil {
-			if *s.cache[i] {
-				s.sat.Assert([]int{i + 1})
-			} else {
-				s.sat.Assert([]int{-(i + 1)})
-			}
-		}
-	}
-	
-	// Добавляем счетчик для некешированных мин
-	if len(s.uncachedMines) > 0 {
-		vars := make([]int, len(s.uncachedMines))
-		for i, m := range s.uncachedMines {
-			vars[i] = m + 1
-		}
-		s.counter = s.sat.AddCounter(vars)
-		
-		minRemaining := s.minMines - s.numCachedTrue
-		if minRemaining > 0 {
-			s.sat.AssertCounterAtLeast(s.counter, minRemaining)
-		}
-		
-		maxRemaining := s.maxMines - s.numCachedTrue
-		if maxRemaining >= 0 && maxRemaining < len(s.counter) {
-			s.sat.AssertCounterAtMost(s.counter, maxRemaining)
-		}
-	}
+	// Каждая компонента решается отдельно, общее у них только число мин
+	s.split()
 	
 	// Проверяем каждую ячейку на границе
 	// log.Printf("Solver.Run: начинаем проверку %d ячеек на границе", s.numMines)
@@ -592,27 +255,22 @@ func (s *Solver) Run() {
 		}
 		
 		// Проверяем, может ли быть безопасной
+		// Ответ уже известен, если ячейка была безопасной в одном из найденных решений
 		// log.Printf("Solver.Run: проверяем ячейку %d на безопасность", i)
-		solution := s.sat.SolveWith(func() {
-			s.sat.Assert([]int{-(i + 1)})
-		})
-		if solution != nil {
-			s.canBeSafe[i] = true
-			s.update(*solution)
-		} else {
-			s.canBeSafe[i] = false
+		if !s.canBeSafe[i] {
+			if solution := s.solveCell(i, false); solution != nil {
+				s.canBeSafe[i] = true
+				s.update(*solution)
+			}
 		}
 		
 		// Проверяем, может ли быть опасной
 		// log.Printf("Solver.Run: проверяем ячейку %d на опасность", i)
-		solution = s.sat.SolveWith(func() {
-			s.sat.Assert([]int{i + 1})
-		})
-		if solution != nil {
-			s.canBeDangerous[i] = true
-			s.update(*solution)
-		} else {
-			s.canBeDangerous[i] = false
+		if !s.canBeDangerous[i] {
+			if solution := s.solveCell(i, true); solution != nil {
+				s.canBeDangerous[i] = true
+				s.update(*solution)
+			}
 		}
 		
 		// Обновляем кэш, если можем
@@ -676,22 +334,37 @@ func (s *Solver) HasNonDeadlyCells() bool {
 	return false
 }
 
+// solveAtLeast ищет решение, в котором среди некешированных ячеек границы хотя бы k мин
+func (s *Solver) solveAtLeast(k int) *[]bool {
+	lo, hi := s.remainingBounds()
+	if k > lo {
+		lo = k
+	}
+	return s.solveGlobal(-1, nil, lo, hi)
+}
+
+// solveAtMost ищет решение, в котором среди некешированных ячеек границы не более k мин
+func (s *Solver) solveAtMost(k int) *[]bool {
+	lo, hi := s.remainingBounds()
+	if k < hi {
+		hi = k
+	}
+	return s.solveGlobal(-1, nil, lo, hi)
+}
+
 // OutsideIsSafe проверяет, безопасна ли область вне границы
 func (s *Solver) OutsideIsSafe() bool {
 	return s.numMines >= s.maxMines &&
-		s.sat.SolveWith(func() {
-			s.sat.AssertCounterAtMost(s.counter, s.maxMines-s.numCachedTrue-1)
-		}) == nil
+		s.solveAtMost(s.maxMines-s.numCachedTrue-1) == nil
 }
 
 // OutsideCanBeSafe проверяет, может ли область вне границы быть безопасной
 func (s *Solver) OutsideCanBeSafe() bool {
-	if s.minMines < 0 {
+	// minMines ограничен снизу нулем, поэтому сравниваем с исходной разностью
+	if s.maxMines < s.map_.numOutside {
 		return true
 	}
-	return s.sat.SolveWith(func() {
-		s.sat.AssertCounterAtLeast(s.counter, s.minMines-s.numCachedTrue+1)
-	}) != nil
+	return s.solveAtLeast(s.minMines-s.numCachedTrue+1) != nil
 }
 
 // AnySafeShape возвращает форму с безопасной ячейкой по индексу
@@ -699,9 +372,7 @@ func (s *Solver) AnySafeShape(idx int) *MineShape {
 	if idx < 0 || idx >= s.numMines {
 		return nil
 	}
-	solution := s.sat.SolveWith(func() {
-		s.sat.Assert([]int{-(idx + 1)})
-	})
+	solution := s.solveCell(idx, false)
 	if solution == nil {
 		return nil
 	}
@@ -713,9 +384,7 @@ func (s *Solver) AnyDangerousShape(idx int) *MineShape {
 	if idx < 0 || idx >= s.numMines {
 		return nil
 	}
-	solution := s.sat.SolveWith(func() {
-		s.sat.Assert([]int{idx + 1})
-	})
+	solution := s.solveCell(idx, true)
 	if solution == nil {
 		return nil
 	}
@@ -724,23 +393,20 @@ func (s *Solver) AnyDangerousShape(idx int) *MineShape {
 
 // AnyShapeWithOneEmpty возвращает форму с одной пустой ячейкой вне границы
 func (s *Solver) AnyShapeWithOneEmpty() *MineShape {
-	solution := s.sat.SolveWith(func() {
-		s.sat.AssertCounterAtLeast(s.counter, s.minMines-s.numCachedTrue+1)
-	})
+	solution := s.solveAtLeast(s.minMines-s.numCachedTrue+1)
 	return s.shape(solution)
 }
 
 // AnyShapeWithRemaining возвращает форму с оставшимися минами
 func (s *Solver) AnyShapeWithRemaining() *MineShape {
-	solution := s.sat.SolveWith(func() {
-		s.sat.AssertCounterAtMost(s.counter, s.maxMines-s.numCachedTrue-1)
-	})
+	solution := s.solveAtMost(s.maxMines-s.numCachedTrue-1)
 	return s.shape(solution)
 }
 
 // AnyShape возвращает любую форму (решение SAT)
 func (s *Solver) AnyShape() *MineShape {
-	solution := s.sat.Solve()
+	lo, hi := s.remainingBounds()
+	solution := s.solveGlobal(-1, nil, lo, hi)
 	return s.shape(solution)
 }
 
@@ -888,131 +554,261 @@ func MakeSolver(lm *LabelMap, maxMines int) *Solver {
 	return solver
 }
 
-// CheckSolvability проверяет, решаемо ли поле (для генерации)
-func CheckSolvability(board [][]bool, rows, cols, mines int) bool {
-	// Создаем LabelMap с полностью закрытым полем
+// CheckSolvability проверяет, что поле решается без угадываний начиная с первого клика
+// Игра симулируется пошагово: сначала применяются простые выводы по отдельным цифрам,
+// а когда они заканчиваются, открываются ячейки, безопасность которых доказывает Solver.
+// Поле решаемо, если так удается открыть все безопасные ячейки
+func CheckSolvability(board [][]bool, rows, cols, mines, firstRow, firstCol int) bool {
+	if firstRow < 0 || firstRow >= rows || firstCol < 0 || firstCol >= cols || board[firstRow][firstCol] {
+		return false
+	}
+
 	lm := NewLabelMap(cols, rows)
-	
-	// Симулируем открытие первой ячейки (обычно безопасной)
-	// Находим безопасную ячейку
-	firstRow, firstCol := -1, -1
+	revealed := make([][]bool, rows)
+	knownMine := make([][]bool, rows)
 	for i := 0; i < rows; i++ {
-		for j := 0; j < cols; j++ {
-			if !board[i][j] {
-				// Подсчитываем соседние мины
-				count := 0
-				for di := -1; di <= 1; di++ {
-					for dj := -1; dj <= 1; dj++ {
-						if di == 0 && dj == 0 {
-							continue
-						}
-						ni, nj := i+di, j+dj
-						if ni >= 0 && ni < rows && nj >= 0 && nj < cols {
-							if board[ni][nj] {
-								count++
-							}
-						}
-					}
+		revealed[i] = make([]bool, cols)
+		knownMine[i] = make([]bool, cols)
+	}
+	revealedCount := 0
+
+	forNeighbors := func(r, c int, fn func(ni, nj int)) {
+		for di := -1; di <= 1; di++ {
+			for dj := -1; dj <= 1; dj++ {
+				if di == 0 && dj == 0 {
+					continue
+				}
+				ni, nj := r+di, c+dj
+				if ni >= 0 && ni < rows && nj >= 0 && nj < cols {
+					fn(ni, nj)
 				}
-				firstRow, firstCol = i, j
-				lm.SetLabel(i, j, count)
-				break
 			}
 		}
-		if firstRow != -1 {
-			break
+	}
+
+	// open открывает ячейку так же, как это делает игра (с рекурсивным открытием нулей)
+	var open func(r, c int)
+	open = func(r, c int) {
+		if revealed[r][c] || board[r][c] {
+			return
+		}
+		revealed[r][c] = true
+		revealedCount++
+
+		count := 0
+		forNeighbors(r, c, func(ni, nj int) {
+			if board[ni][nj] {
+				count++
+			}
+		})
+		lm.labels[r][c] = count
+
+		if count == 0 {
+			forNeighbors(r, c, open)
 		}
 	}
-	
-	if firstRow == -1 {
-		return false // Нет безопасных ячеек
+
+	// markMine отмечает доказанную мину, Solver получает ее через кэш LabelMap
+	markMine := func(r, c int) {
+		if knownMine[r][c] {
+			return
+		}
+		knownMine[r][c] = true
+		isMine := true
+		lm.cache[r][c] = &isMine
 	}
-	
-	// Открываем все соседние пустые ячейки (flood fill)
-	revealed := make([][]bool, rows)
-	for i := range revealed {
-		revealed[i] = make([]bool, cols)
+
+	// constraint ограничение одной цифры: среди закрытых соседей ровно need мин
+	type constraint struct {
+		cells []CellPos
+		need  int
 	}
-	
-	var floodFill func(r, c int)
-	floodFill = func(r, c int) {
-		if r < 0 || r >= rows || c < 0 || c >= cols || revealed[r][c] {
-			return
+
+	open(firstRow, firstCol)
+	safeTotal := rows*cols - mines
+
+	for revealedCount < safeTotal {
+		// Простые выводы: все мины вокруг цифры найдены или все закрытые соседи - мины
+		progress := false
+		constraints := make([]constraint, 0)
+		for r := 0; r < rows; r++ {
+			for c := 0; c < cols; c++ {
+				if !revealed[r][c] || lm.labels[r][c] == 0 {
+					continue
+				}
+				closed := make([]CellPos, 0, 8)
+				flagged := 0
+				forNeighbors(r, c, func(ni, nj int) {
+					if revealed[ni][nj] {
+						return
+					}
+					if knownMine[ni][nj] {
+						flagged++
+					} else {
+						closed = append(closed, CellPos{Row: ni, Col: nj})
+					}
+				})
+				if len(closed) == 0 {
+					continue
+				}
+				need := lm.labels[r][c] - flagged
+				if need == 0 {
+					for _, pos := range closed {
+						open(pos.Row, pos.Col)
+					}
+					progress = true
+				} else if need == len(closed) {
+					for _, pos := range closed {
+						markMine(pos.Row, pos.Col)
+					}
+					progress = true
+				} else {
+					constraints = append(constraints, constraint{cells: closed, need: need})
+				}
+			}
 		}
-		if board[r][c] {
-			return
+		if progress {
+			continue
 		}
-		
-		revealed[r][c] = true
-		count := 0
-		for di := -1; di <= 1; di++ {
-			for dj := -1; dj <= 1; dj++ {
-				if di == 0 && dj == 0 {
+
+		// Выводы по парам цифр: если закрытые соседи A входят в закрытых соседей B,
+		// то в разности лежит ровно need(B) - need(A) мин
+		for a := 0; a < len(constraints) && !progress; a++ {
+			for b := 0; b < len(constraints) && !progress; b++ {
+				if a == b || len(constraints[a].cells) >= len(constraints[b].cells) {
 					continue
 				}
-				ni, nj := r+di, c+dj
-				if ni >= 0 && ni < rows && nj >= 0 && nj < cols {
-					if board[ni][nj] {
-						count++
+				diff := make([]CellPos, 0, 8)
+				subset := true
+				for _, pos := range constraints[a].cells {
+					found := false
+					for _, other := range constraints[b].cells {
+						if pos == other {
+							found = true
+							break
+						}
+					}
+					if !found {
+						subset = false
+						break
+					}
+				}
+				if !subset {
+					continue
+				}
+				for _, pos := range constraints[b].cells {
+					inA := false
+					for _, other := range constraints[a].cells {
+						if pos == other {
+							inA = true
+							break
+						}
 					}
+					if !inA {
+						diff = append(diff, pos)
+					}
+				}
+				need := constraints[b].need - constraints[a].need
+				if need == 0 {
+					for _, pos := range diff {
+						open(pos.Row, pos.Col)
+					}
+					progress = true
+				} else if need == len(diff) {
+					for _, pos := range diff {
+						markMine(pos.Row, pos.Col)
+					}
+					progress = true
 				}
 			}
 		}
-		lm.SetLabel(r, c, count)
-		
-		if count == 0 {
-			for di := -1; di <= 1; di++ {
-				for dj := -1; dj <= 1; dj++ {
-					if di != 0 || dj != 0 {
-						floodFill(r+di, c+dj)
+		if progress {
+			continue
+		}
+
+		// Простые выводы закончились - используем Solver
+		lm.Recalc()
+		solver := MakeSolver(lm, mines)
+
+		toOpen := make([]CellPos, 0)
+		for i, pos := range lm.boundary {
+			if !solver.CanBeDangerous(i) {
+				toOpen = append(toOpen, pos)
+			} else if !solver.CanBeSafe(i) {
+				markMine(pos.Row, pos.Col)
+			}
+		}
+
+		// Если все оставшиеся мины на границе, ячейки вне границы безопасны
+		if len(toOpen) == 0 && lm.numOutside > 0 && solver.OutsideIsSafe() {
+			for r := 0; r < rows; r++ {
+				for c := 0; c < cols; c++ {
+					if lm.labels[r][c] == -1 && lm.boundaryGrid[r][c] == -1 {
+						toOpen = append(toOpen, CellPos{Row: r, Col: c})
 					}
 				}
 			}
 		}
+
+		if len(toOpen) == 0 {
+			return false // Дальше только угадывать
+		}
+		for _, pos := range toOpen {
+			open(pos.Row, pos.Col)
+		}
 	}
-	
-	floodFill(firstRow, firstCol)
-	
-	// Проверяем решаемость
-	solver := MakeSolver(lm, mines)
-	return solver.HasSafeCells() || len(lm.boundary) == 0
+
+	return true
 }
 
-// GenerateSolvableBoard генерирует решаемое поле
-func GenerateSolvableBoard(rows, cols, mines int, maxAttempts int) ([][]bool, bool) {
+// GenerateSolvableBoard генерирует поле, которое решается без угадываний с первого клика
+// Мины не ставятся в первую ячейку и ее соседей (если позволяет плотность), поэтому первый клик
+// всегда открывает область. Все случайные решения берутся из rng, поэтому при одинаковом
+// источнике и первом клике результат воспроизводим.
+// Если за maxAttempts попыток решаемое поле не найдено, возвращает последнее сгенерированное и false
+func GenerateSolvableBoard(rows, cols, mines, firstRow, firstCol int, rng *rand.Rand, maxAttempts int) ([][]bool, bool) {
+	// Ячейки, где мины разрешены
+	clearRadius := 1
+	if rows*cols-9 < mines {
+		clearRadius = 0
+	}
+	positions := make([]int, 0, rows*cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if abs(i-firstRow) <= clearRadius && abs(j-firstCol) <= clearRadius {
+				continue
+			}
+			positions = append(positions, i*cols+j)
+		}
+	}
+
+	var board [][]bool
 	for attempt := 0; attempt < maxAttempts; attempt++ {
-		// Генерируем случайное поле
-		board := make([][]bool, rows)
+		board = make([][]bool, rows)
 		for i := range board {
 			board[i] = make([]bool, cols)
 		}
-		
-		// Размещаем мины случайно
-		positions := make([]int, rows*cols)
-		for i := range positions {
-			positions[i] = i
-		}
-		
+
 		// Перемешиваем
-		rand.Shuffle(len(positions), func(i, j int) {
+		rng.Shuffle(len(positions), func(i, j int) {
 			positions[i], positions[j] = positions[j], positions[i]
 		})
-		
+
 		// Размещаем мины
 		for i := 0; i < mines && i < len(positions); i++ {
 			pos := positions[i]
-			row := pos / cols
-			col := pos % cols
-			board[row][col] = true
+			board[pos/cols][pos%cols] = true
 		}
-		
+
 		// Проверяем решаемость
-		if CheckSolvability(board, rows, cols, mines) {
+		if CheckSolvability(board, rows, cols, mines, firstRow, firstCol) {
+			log.Printf("GenerateSolvableBoard: решаемое поле %dx%d/%d найдено с попытки %d", cols, rows, mines, attempt+1)
 			return board, true
 		}
 	}
-	
-	return nil, false
+
+	log.Printf("GenerateSolvableBoard: не удалось найти решаемое поле %dx%d/%d за %d попыток", cols, rows, mines, maxAttempts)
+	return board, false
 }
 
 // CellInfo представляет информацию о ячейке для расчета безопасных ячеек
@@ -0,0 +1,487 @@
+package game
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+// benchmarkPosition строит позицию для бенчмарков решателя: случайное поле, открытая
+// область первого клика и часть безопасных ячеек, чтобы граница была длинной и плотной
+func benchmarkPosition(rows, cols, mines int, seed int64) *LabelMap {
+	rng := rand.New(rand.NewSource(seed))
+	board := make([][]bool, rows)
+	for i := range board {
+		board[i] = make([]bool, cols)
+	}
+	// Мины не ставятся рядом с первым кликом в центре
+	placed := 0
+	for _, pos := range rng.Perm(rows * cols) {
+		r, c := pos/cols, pos%cols
+		if placed == mines {
+			break
+		}
+		if abs(r-rows/2) <= 1 && abs(c-cols/2) <= 1 {
+			continue
+		}
+		board[r][c] = true
+		placed++
+	}
+
+	count := func(r, c int) int {
+		n := 0
+		for di := -1; di <= 1; di++ {
+			for dj := -1; dj <= 1; dj++ {
+				ni, nj := r+di, c+dj
+				if (di != 0 || dj != 0) && ni >= 0 && ni < rows && nj >= 0 && nj < cols && board[ni][nj] {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	lm := NewLabelMap(cols, rows)
+	var open func(r, c int)
+	open = func(r, c int) {
+		if r < 0 || r >= rows || c < 0 || c >= cols || board[r][c] || lm.labels[r][c] != -1 {
+			return
+		}
+		lm.labels[r][c] = count(r, c)
+		if lm.labels[r][c] == 0 {
+			for di := -1; di <= 1; di++ {
+				for dj := -1; dj <= 1; dj++ {
+					open(r+di, c+dj)
+				}
+			}
+		}
+	}
+	open(rows/2, cols/2)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if rng.Intn(4) == 0 {
+				open(i, j)
+			}
+		}
+	}
+	return lm
+}
+
+func benchmarkMakeSolver(b *testing.B, rows, cols, mines int) {
+	template := benchmarkPosition(rows, cols, mines, 1)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		lm := NewLabelMap(cols, rows)
+		for i := range template.labels {
+			copy(lm.labels[i], template.labels[i])
+		}
+		lm.Recalc()
+		b.StartTimer()
+
+		MakeSolver(lm, mines)
+	}
+}
+
+func BenchmarkMakeSolverBeginner(b *testing.B)     { benchmarkMakeSolver(b, 9, 9, 10) }
+func BenchmarkMakeSolverIntermediate(b *testing.B) { benchmarkMakeSolver(b, 16, 16, 40) }
+func BenchmarkMakeSolverExpert(b *testing.B)       { benchmarkMakeSolver(b, 16, 30, 99) }
+func BenchmarkMakeSolver50x50(b *testing.B)        { benchmarkMakeSolver(b, 50, 50, 500) }
+
+// satisfied проверяет, что решение выполняет все предположения
+func satisfied(solution []bool, lits []int) bool {
+	for _, lit := range lits {
+		if lit > 0 && !solution[lit] || lit < 0 && solution[-lit] {
+			return false
+		}
+	}
+	return true
+}
+
+// maskLits возвращает предположения, задающие значения переменных 1..n по битам mask
+func maskLits(mask, n int) []int {
+	lits := make([]int, n)
+	for v := 1; v <= n; v++ {
+		lits[v-1] = -v
+		if mask&(1<<(v-1)) != 0 {
+			lits[v-1] = v
+		}
+	}
+	return lits
+}
+
+func popcount(mask int) int {
+	return bits.OnesCount(uint(mask))
+}
+
+func TestSatSolveAssuming(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const numVars = 10
+	for round := 0; round < 50; round++ {
+		s := NewSat(numVars)
+		clauses := make([][]int, 3*numVars+rng.Intn(2*numVars))
+		for i := range clauses {
+			clause := make([]int, 1+rng.Intn(3))
+			for j := range clause {
+				clause[j] = 1 + rng.Intn(numVars)
+				if rng.Intn(2) == 0 {
+					clause[j] = -clause[j]
+				}
+			}
+			clauses[i] = clause
+			s.Assert(clause)
+		}
+
+		// Все модели формулы перебором
+		models := make([][]bool, 0)
+		for mask := 0; mask < 1<<numVars; mask++ {
+			model := make([]bool, numVars+1)
+			for v := 1; v <= numVars; v++ {
+				model[v] = mask&(1<<(v-1)) != 0
+			}
+			ok := true
+			for _, clause := range clauses {
+				if !anySatisfied(model, clause) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				models = append(models, model)
+			}
+		}
+
+		// Один решатель отвечает на серию запросов: выученные клаузы не должны мешать следующим
+		for query := 0; query < 20; query++ {
+			assumptions := make([]int, rng.Intn(4))
+			for j := range assumptions {
+				assumptions[j] = 1 + rng.Intn(numVars)
+				if rng.Intn(2) == 0 {
+					assumptions[j] = -assumptions[j]
+				}
+			}
+			want := false
+			for _, model := range models {
+				if satisfied(model, assumptions) {
+					want = true
+					break
+				}
+			}
+
+			solution := s.SolveAssuming(assumptions)
+			if (solution != nil) != want {
+				t.Fatalf("раунд %d: SolveAssuming(%v) нашел решение = %v, перебор = %v", round, assumptions, solution != nil, want)
+			}
+			if solution == nil {
+				continue
+			}
+			if !satisfied(*solution, assumptions) {
+				t.Fatalf("раунд %d: решение нарушает предположения %v", round, assumptions)
+			}
+			for _, clause := range clauses {
+				if !anySatisfied(*solution, clause) {
+					t.Fatalf("раунд %d: решение нарушает клаузу %v", round, clause)
+				}
+			}
+		}
+	}
+}
+
+// anySatisfied проверяет, что решение выполняет хотя бы один литерал клаузы
+func anySatisfied(solution []bool, clause []int) bool {
+	for _, lit := range clause {
+		if satisfied(solution, []int{lit}) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSatAssertAtMostAtLeast(t *testing.T) {
+	for n := 1; n <= 6; n++ {
+		vars := make([]int, n)
+		for i := range vars {
+			vars[i] = i + 1
+		}
+		for k := -1; k <= n+1; k++ {
+			atMost := NewSat(n)
+			atMost.AssertAtMost(vars, k)
+			atLeast := NewSat(n)
+			atLeast.AssertAtLeast(vars, k)
+
+			for mask := 0; mask < 1<<n; mask++ {
+				lits := maskLits(mask, n)
+				ones := popcount(mask)
+				if got := atMost.SolveAssuming(lits) != nil; got != (ones <= k) {
+					t.Errorf("AssertAtMost(n=%d, k=%d), истинных %d: выполнимо = %v", n, k, ones, got)
+				}
+				if got := atLeast.SolveAssuming(lits) != nil; got != (ones >= k) {
+					t.Errorf("AssertAtLeast(n=%d, k=%d), истинных %d: выполнимо = %v", n, k, ones, got)
+				}
+			}
+		}
+	}
+}
+
+func TestSatAddCounter(t *testing.T) {
+	for n := 1; n <= 7; n++ {
+		s := NewSat(n)
+		vars := make([]int, n)
+		for i := range vars {
+			vars[i] = i + 1
+		}
+		counter := s.AddCounter(vars)
+		if len(counter) != n {
+			t.Fatalf("n=%d: длина счетчика %d", n, len(counter))
+		}
+
+		for mask := 0; mask < 1<<n; mask++ {
+			lits := maskLits(mask, n)
+			ones := popcount(mask)
+
+			solution := s.SolveAssuming(lits)
+			if solution == nil {
+				t.Fatalf("n=%d, mask=%b: счетчик запрещает допустимое назначение", n, mask)
+			}
+			for i, v := range counter {
+				if (*solution)[v] != (ones >= i+1) {
+					t.Fatalf("n=%d, mask=%b: counter[%d] = %v при %d истинных", n, mask, i, (*solution)[v], ones)
+				}
+			}
+
+			for k := 0; k <= n+1; k++ {
+				if bound, ok := CounterAtLeast(counter, k); !ok {
+					if ones >= k {
+						t.Errorf("n=%d: CounterAtLeast(%d) невыполнимо при %d истинных", n, k, ones)
+					}
+				} else if got := s.SolveAssuming(append(append([]int{}, lits...), bound...)) != nil; got != (ones >= k) {
+					t.Errorf("n=%d: CounterAtLeast(%d) при %d истинных: выполнимо = %v", n, k, ones, got)
+				}
+				if bound, ok := CounterAtMost(counter, k); !ok {
+					t.Errorf("n=%d: CounterAtMost(%d) отклонено", n, k)
+				} else if got := s.SolveAssuming(append(append([]int{}, lits...), bound...)) != nil; got != (ones <= k) {
+					t.Errorf("n=%d: CounterAtMost(%d) при %d истинных: выполнимо = %v", n, k, ones, got)
+				}
+			}
+		}
+	}
+}
+
+// maxBruteForceBoundary наибольшая граница, которую тесты перебирают полностью
+const maxBruteForceBoundary = 18
+
+// randomPosition строит позицию для проверки перебором: случайное поле и несколько открытых
+// безопасных ячеек (с раскрытием нулей). Разбросанные открытия дают несвязанные части границы
+func randomPosition(rng *rand.Rand, rows, cols, mines, opens int) *LabelMap {
+	board := make([][]bool, rows)
+	for i := range board {
+		board[i] = make([]bool, cols)
+	}
+	for _, pos := range rng.Perm(rows * cols)[:mines] {
+		board[pos/cols][pos%cols] = true
+	}
+
+	lm := NewLabelMap(cols, rows)
+	var open func(r, c int)
+	open = func(r, c int) {
+		if r < 0 || r >= rows || c < 0 || c >= cols || board[r][c] || lm.labels[r][c] != -1 {
+			return
+		}
+		n := 0
+		for di := -1; di <= 1; di++ {
+			for dj := -1; dj <= 1; dj++ {
+				ni, nj := r+di, c+dj
+				if ni >= 0 && ni < rows && nj >= 0 && nj < cols && board[ni][nj] {
+					n++
+				}
+			}
+		}
+		lm.labels[r][c] = n
+		if n == 0 {
+			for di := -1; di <= 1; di++ {
+				for dj := -1; dj <= 1; dj++ {
+					open(r+di, c+dj)
+				}
+			}
+		}
+	}
+	for opened := 0; opened < opens; {
+		r, c := rng.Intn(rows), rng.Intn(cols)
+		if !board[r][c] {
+			open(r, c)
+			opened++
+		}
+	}
+	lm.Recalc()
+	return lm
+}
+
+// boundaryLabel метка открытой ячейки и ее соседи на границе
+type boundaryLabel struct {
+	need  int
+	cells []int
+}
+
+// boundaryLabels собирает метки открытых ячеек по индексам границы
+func boundaryLabels(lm *LabelMap) []boundaryLabel {
+	labels := make([]boundaryLabel, 0)
+	for i := 0; i < lm.height; i++ {
+		for j := 0; j < lm.width; j++ {
+			if lm.labels[i][j] == -1 {
+				continue
+			}
+			l := boundaryLabel{need: lm.labels[i][j]}
+			for di := -1; di <= 1; di++ {
+				for dj := -1; dj <= 1; dj++ {
+					if idx := lm.GetBoundaryIndex(i+di, j+dj); idx != -1 && (di != 0 || dj != 0) {
+						l.cells = append(l.cells, idx)
+					}
+				}
+			}
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
+
+// matchesLabels проверяет, что расстановка на границе согласована со всеми метками
+func matchesLabels(labels []boundaryLabel, layout []bool) bool {
+	for _, l := range labels {
+		count := 0
+		for _, idx := range l.cells {
+			if layout[idx] {
+				count++
+			}
+		}
+		if count != l.need {
+			return false
+		}
+	}
+	return true
+}
+
+// boundaryLayouts перебирает все расстановки мин на границе, согласованные с метками
+// и с общим числом мин (остаток должен поместиться вне границы), и передает их visit
+// вместе с числом мин на границе
+func boundaryLayouts(lm *LabelMap, mines int, visit func(layout []bool, k int)) {
+	// Метки как битовые маски соседей на границе
+	labels := boundaryLabels(lm)
+	masks := make([]int, len(labels))
+	for l, label := range labels {
+		for _, idx := range label.cells {
+			masks[l] |= 1 << idx
+		}
+	}
+
+	n := len(lm.boundary)
+	layout := make([]bool, n)
+	for mask := 0; mask < 1<<n; mask++ {
+		k := popcount(mask)
+		if k > mines || mines-k > lm.numOutside {
+			continue
+		}
+		ok := true
+		for l, label := range labels {
+			if popcount(mask&masks[l]) != label.need {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		for i := range layout {
+			layout[i] = mask&(1<<i) != 0
+		}
+		visit(layout, k)
+	}
+}
+
+// validShape проверяет, что форма решателя - одна из допустимых расстановок
+func validShape(lm *LabelMap, mines int, shape *MineShape) bool {
+	k := 0
+	for _, mine := range shape.mines {
+		if mine {
+			k++
+		}
+	}
+	return mines-k == shape.remaining && shape.remaining >= 0 && shape.remaining <= lm.numOutside &&
+		matchesLabels(boundaryLabels(lm), shape.mines)
+}
+
+// checkSolverBruteForce сравнивает выводы MakeSolver с полным перебором расстановок
+// Возвращает решатель для дополнительных проверок или nil, если граница слишком велика для перебора
+func checkSolverBruteForce(t *testing.T, lm *LabelMap, mines int, name string) *Solver {
+	t.Helper()
+	n := len(lm.boundary)
+	if n > maxBruteForceBoundary {
+		return nil
+	}
+
+	canBeSafe := make([]bool, n)
+	canBeDangerous := make([]bool, n)
+	layouts, outsideCanBeSafe, outsideIsSafe := 0, false, true
+	boundaryLayouts(lm, mines, func(layout []bool, k int) {
+		layouts++
+		for i, mine := range layout {
+			if mine {
+				canBeDangerous[i] = true
+			} else {
+				canBeSafe[i] = true
+			}
+		}
+		if mines-k < lm.numOutside {
+			outsideCanBeSafe = true
+		}
+		if k != mines {
+			outsideIsSafe = false
+		}
+	})
+	if layouts == 0 {
+		t.Fatalf("%s: нет допустимых расстановок у позиции реального поля", name)
+	}
+
+	solver := MakeSolver(lm, mines)
+	for i := 0; i < n; i++ {
+		if solver.CanBeSafe(i) != canBeSafe[i] || solver.CanBeDangerous(i) != canBeDangerous[i] {
+			t.Errorf("%s: ячейка %d (%v): решатель safe=%v dangerous=%v, перебор safe=%v dangerous=%v",
+				name, i, lm.boundary[i], solver.CanBeSafe(i), solver.CanBeDangerous(i), canBeSafe[i], canBeDangerous[i])
+		}
+
+		safe := solver.AnySafeShape(i)
+		if (safe != nil) != canBeSafe[i] || safe != nil && (safe.mines[i] || !validShape(lm, mines, safe)) {
+			t.Errorf("%s: AnySafeShape(%d) вернул недопустимую форму", name, i)
+		}
+		dangerous := solver.AnyDangerousShape(i)
+		if (dangerous != nil) != canBeDangerous[i] || dangerous != nil && (!dangerous.mines[i] || !validShape(lm, mines, dangerous)) {
+			t.Errorf("%s: AnyDangerousShape(%d) вернул недопустимую форму", name, i)
+		}
+	}
+	if shape := solver.AnyShape(); shape == nil || !validShape(lm, mines, shape) {
+		t.Errorf("%s: AnyShape вернул недопустимую форму", name)
+	}
+	if got := solver.OutsideCanBeSafe(); got != outsideCanBeSafe {
+		t.Errorf("%s: OutsideCanBeSafe = %v, перебор = %v", name, got, outsideCanBeSafe)
+	}
+	if got := solver.OutsideIsSafe(); got != outsideIsSafe {
+		t.Errorf("%s: OutsideIsSafe = %v, перебор = %v", name, got, outsideIsSafe)
+	}
+	return solver
+}
+
+func TestMakeSolverBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	checked := 0
+	for round := 0; round < 300; round++ {
+		rows, cols := 4+rng.Intn(3), 4+rng.Intn(4)
+		mines := 2 + rng.Intn(rows*cols/3)
+		lm := randomPosition(rng, rows, cols, mines, 1+rng.Intn(4))
+		if checkSolverBruteForce(t, lm, mines, fmt.Sprintf("раунд %d (%dx%d, мин %d)", round, rows, cols, mines)) != nil {
+			checked++
+		}
+	}
+	if checked < 100 {
+		t.Fatalf("перебором проверено только %d позиций", checked)
+	}
+}
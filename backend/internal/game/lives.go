@@ -0,0 +1,133 @@
+package game
+
+import (
+	"fmt"
+	"log"
+)
+
+// MaxLives наибольшее число жизней, которое можно задать комнате
+const MaxLives = 10
+
+// resetLives переносит настройки жизней комнаты в текущее поле и восстанавливает жизни
+// Жизни работают только в classic: в training и fair мины переставляются при каждом клике,
+// и подорванная мина не осталась бы на месте
+// ВАЖНО: вызывающий код должен удерживать r.Mu
+func (r *Room) resetLives() {
+	gs := r.GameState
+	if gs == nil {
+		return
+	}
+	gs.Lives = 0
+	gs.PerPlayerLives = false
+	if r.Lives > 1 && r.GameMode == "classic" {
+		gs.Lives = r.Lives
+		gs.PerPlayerLives = r.PerPlayerLives
+	}
+	gs.LivesLeft = gs.Lives
+	gs.LivesLost = make(map[string]int)
+}
+
+// livesEnabled включен ли режим жизней
+func (gs *GameState) livesEnabled() bool {
+	return gs.Lives > 1
+}
+
+// playerOut игрок потерял все свои жизни (жизни у каждого свои) и больше не ходит
+// Вызывается под блокировкой GameState
+func (gs *GameState) playerOut(playerID string) bool {
+	return gs.livesEnabled() && gs.PerPlayerLives && gs.LivesLost[playerID] >= gs.Lives
+}
+
+// TotalLivesLost возвращает число жизней, потерянных всеми игроками за игру
+// Вызывается под блокировкой GameState
+func (gs *GameState) TotalLivesLost() int {
+	total := 0
+	for _, lost := range gs.LivesLost {
+		total += lost
+	}
+	return total
+}
+
+// loseLife списывает жизнь за открытую игроком мину (row, col)
+// Если игра продолжается, мина закрывается обратно, помечается подорванной и отмечается флагом:
+// счетчик открытых клеток, проверка победы и chording остаются верными
+// Возвращает false, если режим выключен или жизней не осталось - тогда игра заканчивается как обычно
+// Вызывается под блокировкой GameState сразу после открытия мины
+func (s *Service) loseLife(room *Room, playerID string, row, col int) bool {
+	gs := room.GameState
+	if !gs.livesEnabled() {
+		return false
+	}
+	if gs.LivesLost == nil {
+		gs.LivesLost = make(map[string]int)
+	}
+	gs.LivesLost[playerID]++
+
+	if gs.PerPlayerLives {
+		if s.allPlayersOut(room) {
+			return false
+		}
+	} else {
+		gs.LivesLeft--
+		if gs.LivesLeft <= 0 {
+			return false
+		}
+	}
+
+	cell := &gs.Board[row][col]
+	cell.IsRevealed = false
+	gs.Revealed--
+	cell.IsFlagged = true
+	cell.Exploded = true
+	cell.FlagColor = ""
+	delete(gs.FlagSetInfo, row*gs.Cols+col)
+	return true
+}
+
+// allPlayersOut все игроки комнаты потеряли свои жизни
+// Вызывается под блокировкой GameState
+func (s *Service) allPlayersOut(room *Room) bool {
+	room.Mu.RLock()
+	defer room.Mu.RUnlock()
+	for id, player := range room.Players {
+		if !player.Spectator && !room.GameState.playerOut(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// announceLifeLost сообщает в чат о подрыве, после которого игра продолжается
+func (s *Service) announceLifeLost(room *Room, playerID string, row, col int, nickname, playerColor string) {
+	if nickname == "" {
+		return
+	}
+
+	room.GameState.Mu.RLock()
+	var text string
+	switch {
+	case !room.GameState.PerPlayerLives:
+		text = fmt.Sprintf("%s подорвался на мине на (%d, %d) 💣 Осталось жизней: %d", nickname, row+1, col+1, room.GameState.LivesLeft)
+	case room.GameState.playerOut(playerID):
+		text = fmt.Sprintf("%s подорвался на мине на (%d, %d) 💣 и выбывает из игры", nickname, row+1, col+1)
+	default:
+		left := room.GameState.Lives - room.GameState.LivesLost[playerID]
+		text = fmt.Sprintf("%s подорвался на мине на (%d, %d) 💣 Осталось жизней: %d", nickname, row+1, col+1, left)
+	}
+	room.GameState.Mu.RUnlock()
+
+	log.Printf("Комната %s: игрок %s потерял жизнь на (%d, %d), игра продолжается", room.ID, playerID, row, col)
+	s.BroadcastToAll(room, Message{
+		Type:     "chat",
+		PlayerID: playerID,
+		Nickname: nickname,
+		Color:    playerColor,
+		Chat: &ChatMessage{
+			Text:     text,
+			IsSystem: true,
+			Action:   "explode",
+			Row:      row,
+			Col:      col,
+		},
+	})
+}
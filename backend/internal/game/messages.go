@@ -20,6 +20,26 @@ type ChatMessage struct {
 	Action   string // "flag", "reveal", "explode"
 	Row      int
 	Col      int
+	TeamOnly bool // Только своей команде (режим versus)
+}
+
+// TeamCommand представляет выбор команды в режиме versus
+type TeamCommand struct {
+	Team int // 1 или 2, 0 - зритель
+}
+
+// AckCommand подтверждает получение сообщений сессии с номером до Seq включительно
+// и применение версий поля до Version; Resync - клиент обнаружил пропуск версий
+type AckCommand struct {
+	Seq     uint64
+	Version uint64
+	Resync  bool
+}
+
+// BotCommand представляет команду добавления или удаления бота
+type BotCommand struct {
+	PlayerID string   // ID удаляемого бота
+	Skill    BotSkill // Настройки добавляемого бота
 }
 
 // Message представляет сообщение WebSocket
@@ -33,6 +53,9 @@ type Message struct {
 	Hint      *Hint
 	GameState *GameState
 	Chat      *ChatMessage
+	Bot       *BotCommand
+	Team      *TeamCommand
+	Ack       *AckCommand
 }
 
 // CursorPosition представляет позицию курсора
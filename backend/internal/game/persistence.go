@@ -41,6 +41,14 @@ func (rm *RoomManager) saveRoomUnsafe(room *Room) error {
 		GameMode:   room.GameMode,
 		QuickStart: room.QuickStart,
 		Chording:   room.Chording,
+		NoGuess:    room.NoGuess,
+		Lives:      room.Lives,
+		PerPlayerLives: room.PerPlayerLives,
+		ClockMode:  room.ClockMode,
+		TimeLimit:  room.TimeLimit,
+		TurnTimeout: room.TurnTimeout,
+		NoSpectators: room.NoSpectators,
+		MaxSpectators: room.MaxSpectators,
 		CreatorID:  room.CreatorID,
 		CreatedAt:  room.CreatedAt,
 		StartTime:  room.StartTime,
@@ -130,6 +138,14 @@ func (rm *RoomManager) LoadRooms() error {
 			gameMode,
 			dbRoom.QuickStart,
 			dbRoom.Chording,
+			dbRoom.NoGuess,
+			dbRoom.Lives,
+			dbRoom.PerPlayerLives,
+			dbRoom.ClockMode,
+			dbRoom.TimeLimit,
+			dbRoom.TurnTimeout,
+			dbRoom.NoSpectators,
+			dbRoom.MaxSpectators,
 			"", // seed="" при загрузке из БД (seed будет восстановлен из GameStateData)
 			false, // hasCustomSeed=false при загрузке из БД (по умолчанию)
 		)
@@ -143,7 +159,13 @@ func (rm *RoomManager) LoadRooms() error {
 				log.Printf("Ошибка декодирования GameState для комнаты %s: %v, создаем новое состояние", room.ID, err)
 				// Оставляем новое состояние, созданное в NewRoom
 			} else {
+				// Оставшиеся общие жизни сохраняются вместе с полем, остальное берется из настроек комнаты
+				livesLeft := gameState.LivesLeft
 				room.GameState = gameState
+				room.resetLives()
+				if livesLeft > 0 && livesLeft < gameState.LivesLeft {
+					gameState.LivesLeft = livesLeft
+				}
 				log.Printf("GameState восстановлен для комнаты %s, размер: %d байт", room.ID, len(dbRoom.GameStateData))
 			}
 		} else if len(dbRoom.GameStateData) > 0 {
@@ -0,0 +1,339 @@
+package game
+
+import (
+	"log"
+	"math/big"
+)
+
+// maxProbabilityNodes ограничение перебора при подсчете вероятностей
+// Вероятности считаются на каждом ходу (тепловая карта training, ход бота) под GameState.Mu,
+// поэтому перебор ограничен примерно десятью-двадцатью миллисекундами. На больших границах
+// перебор растет экспоненциально, тогда вероятности на этом ходу не считаются
+const maxProbabilityNodes = 250000
+
+// MineProbabilities вероятности мин для закрытых ячеек
+type MineProbabilities struct {
+	Boundary []float64 // Вероятность мины для ячеек границы (по индексу в LabelMap.boundary)
+	Outside  float64   // Вероятность мины для любой закрытой ячейки вне границы
+}
+
+// Probabilities считает точную вероятность мины для каждой закрытой ячейки
+// Перебираются все расстановки мин на границе, согласованные с метками, отдельно для каждой
+// компоненты границы; компоненты объединяются через число мин. Каждая расстановка
+// с k минами на границе весит C(numOutside, maxMines-k) - столько способов разложить
+// оставшиеся мины по ячейкам вне границы. Вероятность ячейки - доля веса расстановок,
+// где она мина. Считается в целых числах (big.Int), округляется только итоговое значение.
+// Вызывается после Run: доказанные решателем ячейки в перебор не входят.
+// Возвращает false, если расстановок нет или перебор превысил maxProbabilityNodes
+func (s *Solver) Probabilities() (*MineProbabilities, bool) {
+	numOutside := s.map_.numOutside
+	totalMines := s.maxMines
+
+	// Ячейки, значение которых уже известно, и свободные переменные
+	fixedMines := 0
+	varIndex := make([]int, s.numMines) // Индекс ячейки границы -> номер переменной, -1 если ячейка известна
+	for i := 0; i < s.numMines; i++ {
+		varIndex[i] = -1
+		if s.cache[i] != nil && *s.cache[i] {
+			fixedMines++
+		}
+	}
+
+	// Ограничения меток по свободным переменным
+	type constraint struct {
+		vars []int
+		need int
+	}
+	constraints := make([]constraint, 0, len(s.labels))
+	vars := make([]int, 0) // Номер переменной -> индекс ячейки границы
+	varConstraints := make([][]int, 0)
+	for l, label := range s.labels {
+		c := constraint{need: label}
+		for _, m := range s.labelToMine[l] {
+			if s.cache[m] != nil {
+				if *s.cache[m] {
+					c.need--
+				}
+				continue
+			}
+			if varIndex[m] == -1 {
+				varIndex[m] = len(vars)
+				vars = append(vars, m)
+				varConstraints = append(varConstraints, nil)
+			}
+			c.vars = append(c.vars, varIndex[m])
+		}
+		if c.need < 0 || c.need > len(c.vars) {
+			return nil, false
+		}
+		if len(c.vars) > 0 {
+			for _, v := range c.vars {
+				varConstraints[v] = append(varConstraints[v], len(constraints))
+			}
+			constraints = append(constraints, c)
+		}
+	}
+
+	// Ячейки границы без меток (возможны после Run) ведут себя как свободные
+	for i := 0; i < s.numMines; i++ {
+		if s.cache[i] == nil && varIndex[i] == -1 {
+			varIndex[i] = len(vars)
+			vars = append(vars, i)
+			varConstraints = append(varConstraints, nil)
+		}
+	}
+
+	// Компоненты: переменные, связанные общими метками. Внутри компоненты порядок перебора -
+	// обход в ширину, чтобы ограничения замыкались раньше. Компоненты перебираются отдельно
+	// и связаны только общим числом мин
+	components := make([][]int, 0)
+	visited := make([]bool, len(vars))
+	for start := range vars {
+		if visited[start] {
+			continue
+		}
+		visited[start] = true
+		order := make([]int, 0)
+		queue := []int{start}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			order = append(order, v)
+			for _, c := range varConstraints[v] {
+				for _, u := range constraints[c].vars {
+					if !visited[u] {
+						visited[u] = true
+						queue = append(queue, u)
+					}
+				}
+			}
+		}
+		components = append(components, order)
+	}
+
+	assigned := make([]int, len(constraints)) // Мин среди назначенных переменных метки
+	left := make([]int, len(constraints))     // Неназначенных переменных метки
+	for c := range constraints {
+		left[c] = len(constraints[c].vars)
+	}
+	value := make([]bool, len(vars))
+	nodes := 0
+	aborted := false
+
+	minTotal := totalMines - numOutside - fixedMines // Мин среди переменных не меньше
+	maxTotal := totalMines - fixedMines              // и не больше
+
+	// Перебор расстановок компоненты с подсчетом по количеству мин в ней
+	// solutions[k] - количество расстановок с k минами,
+	// mineCounts[k][j] - в скольких из них переменная order[j] - мина
+	enumerate := func(order []int) ([]uint64, [][]uint64) {
+		solutions := make([]uint64, len(order)+1)
+		mineCounts := make([][]uint64, len(order)+1)
+		othersSize := len(vars) - len(order)
+
+		var search func(pos, mines int)
+		search = func(pos, mines int) {
+			if aborted {
+				return
+			}
+			nodes++
+			if nodes > maxProbabilityNodes {
+				aborted = true
+				return
+			}
+			if mines > maxTotal || mines+len(order)-pos+othersSize < minTotal {
+				return
+			}
+			if pos == len(order) {
+				solutions[mines]++
+				if mineCounts[mines] == nil {
+					mineCounts[mines] = make([]uint64, len(order))
+				}
+				for j, v := range order {
+					if value[v] {
+						mineCounts[mines][j]++
+					}
+				}
+				return
+			}
+
+			v := order[pos]
+			for _, isMine := range []bool{false, true} {
+				ok := true
+				for _, c := range varConstraints[v] {
+					left[c]--
+					if isMine {
+						assigned[c]++
+					}
+					if assigned[c] > constraints[c].need || assigned[c]+left[c] < constraints[c].need {
+						ok = false
+					}
+				}
+				if ok {
+					value[v] = isMine
+					next := mines
+					if isMine {
+						next++
+					}
+					search(pos+1, next)
+					value[v] = false
+				}
+				for _, c := range varConstraints[v] {
+					left[c]++
+					if isMine {
+						assigned[c]--
+					}
+				}
+			}
+		}
+		search(0, 0)
+		return solutions, mineCounts
+	}
+
+	// Многочлены компонент: poly[c][k] - число расстановок компоненты c с k минами
+	polys := make([][]*big.Int, len(components))
+	mineCounts := make([][][]uint64, len(components))
+	for c, order := range components {
+		solutions, counts := enumerate(order)
+		if aborted {
+			log.Printf("Solver.Probabilities: перебор прерван после %d узлов (переменных=%d, компонент=%d)", maxProbabilityNodes, len(vars), len(components))
+			return nil, false
+		}
+		polys[c] = make([]*big.Int, len(solutions))
+		for k, count := range solutions {
+			polys[c][k] = new(big.Int).SetUint64(count)
+		}
+		mineCounts[c] = counts
+	}
+
+	// Произведение многочленов: all[j] - число расстановок всей границы с j минами
+	all := []*big.Int{big.NewInt(1)}
+	for _, poly := range polys {
+		all = multiplyPolys(all, poly)
+	}
+
+	// binom(m) - сколько способов разложить m мин по ячейкам вне границы
+	binoms := make(map[int]*big.Int)
+	binom := func(m int) *big.Int {
+		if m < 0 || m > numOutside {
+			return nil
+		}
+		if b, ok := binoms[m]; ok {
+			return b
+		}
+		b := new(big.Int).Binomial(int64(numOutside), int64(m))
+		binoms[m] = b
+		return b
+	}
+
+	// Суммируем веса: C(numOutside, оставшиеся мины)
+	totalWeight := new(big.Int)
+	outsideWeight := new(big.Int) // Сумма весов, умноженных на число мин вне границы
+	for j, count := range all {
+		weight := binom(maxTotal - j)
+		if weight == nil || count.Sign() == 0 {
+			continue
+		}
+		term := new(big.Int).Mul(weight, count)
+		totalWeight.Add(totalWeight, term)
+		outsideWeight.Add(outsideWeight, new(big.Int).Mul(term, big.NewInt(int64(maxTotal-j))))
+	}
+
+	if totalWeight.Sign() == 0 {
+		return nil, false
+	}
+
+	// Вес ячейки компоненты c: расстановки компоненты с k минами, где ячейка - мина,
+	// умноженные на вес остальных компонент и ячеек вне границы при k минах в c
+	cellWeights := make([]*big.Int, len(vars))
+	for c, order := range components {
+		others := dividePolys(all, polys[c])
+		for k := range polys[c] {
+			if mineCounts[c][k] == nil {
+				continue
+			}
+			weight := new(big.Int)
+			for j, count := range others {
+				if b := binom(maxTotal - k - j); b != nil && count.Sign() != 0 {
+					weight.Add(weight, new(big.Int).Mul(count, b))
+				}
+			}
+			if weight.Sign() == 0 {
+				continue
+			}
+			for pos, v := range order {
+				if cellWeights[v] == nil {
+					cellWeights[v] = new(big.Int)
+				}
+				if mineCount := mineCounts[c][k][pos]; mineCount > 0 {
+					cellWeights[v].Add(cellWeights[v], new(big.Int).Mul(weight, new(big.Int).SetUint64(mineCount)))
+				}
+			}
+		}
+	}
+
+	ratio := func(num, den *big.Int) float64 {
+		if num == nil {
+			return 0
+		}
+		f, _ := new(big.Rat).SetFrac(num, den).Float64()
+		return f
+	}
+
+	result := &MineProbabilities{
+		Boundary: make([]float64, s.numMines),
+	}
+	for i := 0; i < s.numMines; i++ {
+		if s.cache[i] != nil {
+			if *s.cache[i] {
+				result.Boundary[i] = 1
+			}
+			continue
+		}
+		result.Boundary[i] = ratio(cellWeights[varIndex[i]], totalWeight)
+	}
+	if numOutside > 0 {
+		result.Outside = ratio(outsideWeight, new(big.Int).Mul(totalWeight, big.NewInt(int64(numOutside))))
+	}
+
+	return result, true
+}
+
+// multiplyPolys перемножает многочлены, заданные коэффициентами
+func multiplyPolys(a, b []*big.Int) []*big.Int {
+	result := make([]*big.Int, len(a)+len(b)-1)
+	for i := range result {
+		result[i] = new(big.Int)
+	}
+	for i, x := range a {
+		if x.Sign() == 0 {
+			continue
+		}
+		for j, y := range b {
+			if y.Sign() != 0 {
+				result[i+j].Add(result[i+j], new(big.Int).Mul(x, y))
+			}
+		}
+	}
+	return result
+}
+
+// dividePolys делит многочлен a на b без остатка (a должен быть произведением b и частного)
+// Коэффициенты частного находятся по возрастанию степени от младшего ненулевого коэффициента b
+func dividePolys(a, b []*big.Int) []*big.Int {
+	low := 0
+	for low < len(b) && b[low].Sign() == 0 {
+		low++
+	}
+	result := make([]*big.Int, len(a)-len(b)+1)
+	for j := range result {
+		num := new(big.Int).Set(a[j+low])
+		for i := low + 1; i < len(b) && i <= j+low; i++ {
+			if b[i].Sign() != 0 {
+				num.Sub(num, new(big.Int).Mul(b[i], result[j+low-i]))
+			}
+		}
+		result[j] = num.Quo(num, b[low])
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package game
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// bruteForceProbabilities считает вероятности мин полным перебором расстановок границы
+// Расстановка с k минами на границе весит C(numOutside, mines-k)
+func bruteForceProbabilities(lm *LabelMap, mines int) (boundary []float64, outside float64, weighted bool) {
+	total := new(big.Int)
+	outsideWeight := new(big.Int)
+	cellWeights := make([]*big.Int, len(lm.boundary))
+	for i := range cellWeights {
+		cellWeights[i] = new(big.Int)
+	}
+	counts := make(map[int]bool)
+	boundaryLayouts(lm, mines, func(layout []bool, k int) {
+		counts[k] = true
+		weight := new(big.Int).Binomial(int64(lm.numOutside), int64(mines-k))
+		total.Add(total, weight)
+		outsideWeight.Add(outsideWeight, new(big.Int).Mul(weight, big.NewInt(int64(mines-k))))
+		for i, mine := range layout {
+			if mine {
+				cellWeights[i].Add(cellWeights[i], weight)
+			}
+		}
+	})
+
+	ratio := func(num, den *big.Int) float64 {
+		f, _ := new(big.Rat).SetFrac(num, den).Float64()
+		return f
+	}
+	boundary = make([]float64, len(cellWeights))
+	for i, w := range cellWeights {
+		boundary[i] = ratio(w, total)
+	}
+	if lm.numOutside > 0 {
+		outside = ratio(outsideWeight, new(big.Int).Mul(total, big.NewInt(int64(lm.numOutside))))
+	}
+	// Веса различаются, только если расстановки с разным числом мин оставляют разный остаток вне границы
+	weighted = len(counts) > 1 && lm.numOutside > 0
+	return boundary, outside, weighted
+}
+
+func TestProbabilitiesBruteForce(t *testing.T) {
+	const eps = 1e-12
+	positions := coupledPositions(2, 100)
+	if len(positions) < 100 {
+		t.Fatalf("найдено только %d позиций с несколькими связанными компонентами", len(positions))
+	}
+
+	weighted := 0
+	for _, pos := range positions {
+		wantBoundary, wantOutside, isWeighted := bruteForceProbabilities(pos.lm, pos.mines)
+		if isWeighted {
+			weighted++
+		}
+
+		probs, ok := MakeSolver(pos.lm, pos.mines).Probabilities()
+		if !ok {
+			t.Errorf("%s: вероятности не посчитаны", pos.name)
+			continue
+		}
+		for i, want := range wantBoundary {
+			if math.Abs(probs.Boundary[i]-want) > eps {
+				t.Errorf("%s: ячейка %d (%v): вероятность %v, перебор %v", pos.name, i, pos.lm.boundary[i], probs.Boundary[i], want)
+			}
+		}
+		if math.Abs(probs.Outside-wantOutside) > eps {
+			t.Errorf("%s: вероятность вне границы %v, перебор %v", pos.name, probs.Outside, wantOutside)
+		}
+	}
+	if weighted < 20 {
+		t.Fatalf("только %d позиций проверяют вес ячеек вне границы", weighted)
+	}
+}
@@ -16,11 +16,13 @@ func truncatePlayerID(playerID string) string {
 
 // Типы клеток для бинарного формата
 const (
-	CellTypeClosed  = byte(255) // Закрыта
-	CellTypeMine    = byte(9)   // Мина
-	CellTypeSafe    = byte(10)  // Зеленая (SAFE)
-	CellTypeUnknown = byte(11)  // Желтая (UNKNOWN)
-	CellTypeDanger  = byte(12)  // Красная (MINE)
+	CellTypeClosed      = byte(255) // Закрыта
+	CellTypeMine        = byte(9)   // Мина
+	CellTypeSafe        = byte(10)  // Зеленая (SAFE)
+	CellTypeUnknown     = byte(11)  // Желтая (UNKNOWN)
+	CellTypeDanger      = byte(12)  // Красная (MINE)
+	CellTypeProbability = byte(13)  // Вне границы, только вероятность мины
+	CellTypeExploded    = byte(14)  // Подорванная мина (режим жизней)
 )
 
 // byteToCellType преобразует byte в CellType enum
@@ -40,6 +42,12 @@ func byteToCellType(b byte) pb.CellType {
 	if b == CellTypeDanger {
 		return pb.CellType_CELL_TYPE_DANGER
 	}
+	if b == CellTypeProbability {
+		return pb.CellType_CELL_TYPE_PROBABILITY
+	}
+	if b == CellTypeExploded {
+		return pb.CellType_CELL_TYPE_EXPLODED
+	}
 	if b == CellTypeClosed {
 		return pb.CellType_CELL_TYPE_CLOSED
 	}
@@ -48,6 +56,11 @@ func byteToCellType(b byte) pb.CellType {
 
 // EncodeGameStateProtobuf кодирует game.GameState в protobuf формат
 func EncodeGameStateProtobuf(gs *GameState) ([]byte, error) {
+	return EncodeTeamGameStateProtobuf(gs, 0)
+}
+
+// EncodeTeamGameStateProtobuf кодирует поле команды team (режим versus) в protobuf формат
+func EncodeTeamGameStateProtobuf(gs *GameState, team int) ([]byte, error) {
 	gs.Mu.RLock()
 	defer gs.Mu.RUnlock()
 
@@ -62,6 +75,7 @@ func EncodeGameStateProtobuf(gs *GameState) ([]byte, error) {
 				IsFlagged:     cell.IsFlagged,
 				NeighborMines: int32(cell.NeighborMines),
 				FlagColor:     cell.FlagColor,
+				IsExploded:    cell.Exploded,
 			}
 		}
 		rows[i] = &pb.Row{Cells: cells}
@@ -78,9 +92,10 @@ func EncodeGameStateProtobuf(gs *GameState) ([]byte, error) {
 	cellHints := make([]*pb.CellHint, len(gs.CellHints))
 	for i, hint := range gs.CellHints {
 		cellHints[i] = &pb.CellHint{
-			Row:  int32(hint.Row),
-			Col:  int32(hint.Col),
-			Type: hint.Type,
+			Row:         int32(hint.Row),
+			Col:         int32(hint.Col),
+			Type:        hint.Type,
+			Probability: float32(hint.Probability),
 		}
 	}
 
@@ -98,6 +113,11 @@ func EncodeGameStateProtobuf(gs *GameState) ([]byte, error) {
 		CellHints:      cellHints,
 		LoserPlayerId:  truncatePlayerID(gs.LoserPlayerID),
 		LoserNickname:  gs.LoserNickname,
+		Team:           int32(team),
+		Lives:          int32(gs.Lives),
+		PerPlayerLives: gs.PerPlayerLives,
+		LivesLeft:      int32(gs.LivesLeft),
+		PlayerLives:    encodePlayerLives(gs),
 	}
 
 	wsMsg := &pb.WebSocketMessage{
@@ -111,9 +131,20 @@ func EncodeGameStateProtobuf(gs *GameState) ([]byte, error) {
 
 // CellUpdate представляет обновление одной клетки
 type CellUpdate struct {
-	Row  int
-	Col  int
-	Type byte
+	Row         int
+	Col         int
+	Type        byte
+	Probability float64 // Вероятность мины для закрытой клетки с подсказкой
+}
+
+// findCellHint возвращает подсказку для клетки или nil
+func findCellHint(cellHints []CellHint, row, col int) *CellHint {
+	for i := range cellHints {
+		if cellHints[i].Row == row && cellHints[i].Col == col {
+			return &cellHints[i]
+		}
+	}
+	return nil
 }
 
 // getCellType возвращает тип клетки для бинарного формата
@@ -128,19 +159,22 @@ func getCellType(cell *Cell, row, col int, gameMode string, cellHints []CellHint
 		return byte(cell.NeighborMines)
 	}
 
+	if cell.Exploded {
+		return CellTypeExploded
+	}
+
 	if !cell.IsFlagged {
 		if gameMode == "training" || gameMode == "fair" {
-			for i := range cellHints {
-				if cellHints[i].Row == row && cellHints[i].Col == col {
-					switch cellHints[i].Type {
-					case "SAFE":
-						return CellTypeSafe
-					case "UNKNOWN":
-						return CellTypeUnknown
-					case "MINE":
-						return CellTypeDanger
-					}
-					break
+			if hint := findCellHint(cellHints, row, col); hint != nil {
+				switch hint.Type {
+				case "SAFE":
+					return CellTypeSafe
+				case "UNKNOWN":
+					return CellTypeUnknown
+				case "MINE":
+					return CellTypeDanger
+				case "PROBABILITY":
+					return CellTypeProbability
 				}
 			}
 		}
@@ -152,16 +186,19 @@ func getCellType(cell *Cell, row, col int, gameMode string, cellHints []CellHint
 
 // CollectCellUpdates собирает измененные клетки из gameState
 func CollectCellUpdates(room *Room, changedCells map[[2]int]bool) []CellUpdate {
-	updates := make([]CellUpdate, 0)
+	return CollectBoardUpdates(room.GameState, room.GameMode, changedCells)
+}
 
-	room.GameState.Mu.RLock()
-	cellHints := room.GameState.CellHints
-	board := room.GameState.Board
-	rows := room.GameState.Rows
-	cols := room.GameState.Cols
-	room.GameState.Mu.RUnlock()
+// CollectBoardUpdates собирает измененные клетки поля gs (общего или поля игрока в гонке)
+func CollectBoardUpdates(gs *GameState, gameMode string, changedCells map[[2]int]bool) []CellUpdate {
+	updates := make([]CellUpdate, 0)
 
-	gameMode := room.GameMode
+	gs.Mu.RLock()
+	cellHints := gs.CellHints
+	board := gs.Board
+	rows := gs.Rows
+	cols := gs.Cols
+	gs.Mu.RUnlock()
 
 	for pos := range changedCells {
 		row, col := pos[0], pos[1]
@@ -172,11 +209,17 @@ func CollectCellUpdates(room *Room, changedCells map[[2]int]bool) []CellUpdate {
 		cell := &board[row][col]
 		cellType := getCellType(cell, row, col, gameMode, cellHints)
 
-		updates = append(updates, CellUpdate{
+		update := CellUpdate{
 			Row:  row,
 			Col:  col,
 			Type: cellType,
-		})
+		}
+		if cellType != CellTypeClosed && !cell.IsRevealed {
+			if hint := findCellHint(cellHints, row, col); hint != nil {
+				update.Probability = hint.Probability
+			}
+		}
+		updates = append(updates, update)
 	}
 
 	return updates
@@ -184,12 +227,48 @@ func CollectCellUpdates(room *Room, changedCells map[[2]int]bool) []CellUpdate {
 
 // EncodeCellUpdateProtobuf кодирует обновления клеток в protobuf формат
 func EncodeCellUpdateProtobuf(updates []CellUpdate, gameOver bool, gameWon bool, revealed int, hintsUsed int, loserPlayerID string, loserNickname string) ([]byte, error) {
+	return encodeCellUpdateProtobuf(updates, gameOver, gameWon, revealed, hintsUsed, loserPlayerID, loserNickname, 0, nil)
+}
+
+// EncodeTeamCellUpdateProtobuf кодирует обновления клеток поля команды team (режим versus)
+func EncodeTeamCellUpdateProtobuf(updates []CellUpdate, revealed int, team int) ([]byte, error) {
+	return encodeCellUpdateProtobuf(updates, false, false, revealed, 0, "", "", team, nil)
+}
+
+// EncodeLivesCellUpdateProtobuf кодирует обновления клеток общего поля gs вместе с жизнями (режим жизней)
+func EncodeLivesCellUpdateProtobuf(updates []CellUpdate, gameOver bool, gameWon bool, revealed int, hintsUsed int, loserPlayerID string, loserNickname string, gs *GameState) ([]byte, error) {
+	return encodeCellUpdateProtobuf(updates, gameOver, gameWon, revealed, hintsUsed, loserPlayerID, loserNickname, 0, gs)
+}
+
+// encodePlayerLives собирает потерянные жизни по игрокам
+// Вызывается под блокировкой GameState
+func encodePlayerLives(gs *GameState) []*pb.PlayerLives {
+	if !gs.livesEnabled() {
+		return nil
+	}
+	playerLives := make([]*pb.PlayerLives, 0, len(gs.LivesLost))
+	for playerID, lost := range gs.LivesLost {
+		entry := &pb.PlayerLives{
+			PlayerId:  truncatePlayerID(playerID),
+			LivesLost: int32(lost),
+		}
+		if gs.PerPlayerLives {
+			entry.LivesLeft = int32(gs.Lives - lost)
+		}
+		playerLives = append(playerLives, entry)
+	}
+	return playerLives
+}
+
+// encodeCellUpdateProtobuf кодирует обновления клеток; livesState - поле, жизни которого отправляются (или nil)
+func encodeCellUpdateProtobuf(updates []CellUpdate, gameOver bool, gameWon bool, revealed int, hintsUsed int, loserPlayerID string, loserNickname string, team int, livesState *GameState) ([]byte, error) {
 	cellUpdates := make([]*pb.CellUpdate, len(updates))
 	for i, update := range updates {
 		cellUpdates[i] = &pb.CellUpdate{
-			Row:  int32(update.Row),
-			Col:  int32(update.Col),
-			Type: byteToCellType(update.Type),
+			Row:         int32(update.Row),
+			Col:         int32(update.Col),
+			Type:        byteToCellType(update.Type),
+			Probability: float32(update.Probability),
 		}
 	}
 
@@ -201,6 +280,15 @@ func EncodeCellUpdateProtobuf(updates []CellUpdate, gameOver bool, gameWon bool,
 		LoserPlayerId:   truncatePlayerID(loserPlayerID),
 		LoserNickname:   loserNickname,
 		Updates:         cellUpdates,
+		Team:            int32(team),
+	}
+	if livesState != nil {
+		livesState.Mu.RLock()
+		if livesState.livesEnabled() {
+			cellUpdateMsg.LivesLeft = int32(livesState.LivesLeft)
+			cellUpdateMsg.PlayerLives = encodePlayerLives(livesState)
+		}
+		livesState.Mu.RUnlock()
 	}
 
 	wsMsg := &pb.WebSocketMessage{
@@ -1,6 +1,10 @@
 package game
 
 import (
+	"sort"
+	"strconv"
+	"time"
+
 	pb "minesweeperonline/proto"
 
 	"google.golang.org/protobuf/proto"
@@ -17,6 +21,7 @@ func EncodeChatProtobuf(msg *Message) ([]byte, error) {
 		Action:   msg.Chat.Action,
 		Row:      int32(msg.Chat.Row),
 		Col:      int32(msg.Chat.Col),
+		TeamOnly: msg.Chat.TeamOnly,
 	}
 
 	wsMsg := &pb.WebSocketMessage{
@@ -48,18 +53,25 @@ func EncodeCursorProtobuf(msg *Message) ([]byte, error) {
 }
 
 // EncodePlayersProtobuf кодирует список игроков в protobuf формат
+// Зрители (spectator=true) передаются отдельным списком
 func EncodePlayersProtobuf(players []map[string]string) ([]byte, error) {
-	playerList := make([]*pb.Player, len(players))
-	for i, p := range players {
-		playerList[i] = &pb.Player{
+	playersMsg := &pb.PlayersMessage{
+		Players: make([]*pb.Player, 0, len(players)),
+	}
+	for _, p := range players {
+		team, _ := strconv.Atoi(p["team"])
+		player := &pb.Player{
 			Id:       truncatePlayerID(p["id"]),
 			Nickname: p["nickname"],
 			Color:    p["color"],
+			IsBot:    p["isBot"] == "true",
+			Team:     int32(team),
+		}
+		if p["spectator"] == "true" {
+			playersMsg.Spectators = append(playersMsg.Spectators, player)
+		} else {
+			playersMsg.Players = append(playersMsg.Players, player)
 		}
-	}
-
-	playersMsg := &pb.PlayersMessage{
-		Players: playerList,
 	}
 
 	wsMsg := &pb.WebSocketMessage{
@@ -71,3 +83,194 @@ func EncodePlayersProtobuf(players []map[string]string) ([]byte, error) {
 	return proto.Marshal(wsMsg)
 }
 
+
+// EncodeHintExplanationProtobuf кодирует объяснение подсказки в protobuf формат
+func EncodeHintExplanationProtobuf(explanation *HintExplanation) ([]byte, error) {
+	toCells := func(cells []CellPos) []*pb.ExplanationCell {
+		result := make([]*pb.ExplanationCell, len(cells))
+		for i, c := range cells {
+			result[i] = &pb.ExplanationCell{
+				Row: int32(c.Row),
+				Col: int32(c.Col),
+			}
+		}
+		return result
+	}
+
+	explanationMsg := &pb.HintExplanationMessage{
+		Row:     int32(explanation.Row),
+		Col:     int32(explanation.Col),
+		IsMine:  explanation.IsMine,
+		Pattern: explanation.Pattern,
+		Labels:  toCells(explanation.Labels),
+		Cells:   toCells(explanation.Cells),
+	}
+
+	wsMsg := &pb.WebSocketMessage{
+		Message: &pb.WebSocketMessage_HintExplanation{
+			HintExplanation: explanationMsg,
+		},
+	}
+
+	return proto.Marshal(wsMsg)
+}
+
+// EncodeRaceStandingsProtobuf кодирует таблицу гонки в protobuf формат
+func EncodeRaceStandingsProtobuf(race *RaceState) ([]byte, error) {
+	race.Mu.Lock()
+	standingsMsg := &pb.RaceStandingsMessage{
+		Status:   race.Status,
+		WinnerId: truncatePlayerID(race.WinnerID),
+	}
+	switch race.Status {
+	case RaceCountdown:
+		if left := time.Until(race.StartsAt); left > 0 {
+			standingsMsg.StartsInMs = left.Milliseconds()
+		}
+	case RaceRunning:
+		standingsMsg.ElapsedMs = time.Since(race.StartedAt).Milliseconds()
+	case RaceFinished:
+		if winner := race.Racers[race.WinnerID]; winner != nil {
+			standingsMsg.ElapsedMs = int64(winner.FinishTime * 1000)
+		}
+	}
+	for _, racer := range race.standings() {
+		totalSafe := racer.Board.Rows*racer.Board.Cols - racer.Board.Mines
+		progress := &pb.RaceProgress{
+			PlayerId:   truncatePlayerID(racer.PlayerID),
+			Nickname:   racer.Nickname,
+			Color:      racer.Color,
+			Revealed:   int32(racer.Board.Revealed),
+			TotalSafe:  int32(totalSafe),
+			Flags:      int32(racer.Flags),
+			Deaths:     int32(racer.Deaths),
+			Finished:   racer.Finished,
+			Place:      int32(racer.Place),
+			FinishTime: racer.FinishTime,
+		}
+		if totalSafe > 0 {
+			progress.RevealedPercent = float32(racer.Board.Revealed) * 100 / float32(totalSafe)
+		}
+		standingsMsg.Racers = append(standingsMsg.Racers, progress)
+	}
+	race.Mu.Unlock()
+
+	wsMsg := &pb.WebSocketMessage{
+		Message: &pb.WebSocketMessage_RaceStandings{
+			RaceStandings: standingsMsg,
+		},
+	}
+
+	return proto.Marshal(wsMsg)
+}
+
+// EncodeVersusStatusProtobuf кодирует счет матча команд в protobuf формат
+func EncodeVersusStatusProtobuf(versus *VersusState, teamPlayers [versusTeams]int) ([]byte, error) {
+	versus.Mu.Lock()
+	statusMsg := &pb.VersusStatusMessage{
+		WinnerTeam: int32(versus.WinnerTeam),
+	}
+	for i, t := range versus.Teams {
+		t.Board.Mu.RLock()
+		revealed := t.Board.Revealed
+		totalSafe := t.Board.Rows*t.Board.Cols - t.Board.Mines
+		t.Board.Mu.RUnlock()
+		statusMsg.Teams = append(statusMsg.Teams, &pb.TeamProgress{
+			Team:      int32(i + 1),
+			Revealed:  int32(revealed),
+			TotalSafe: int32(totalSafe),
+			Deaths:    int32(t.Deaths),
+			Cleared:   t.Cleared,
+			ClearTime: t.ClearTime,
+			Players:   int32(teamPlayers[i]),
+		})
+	}
+	versus.Mu.Unlock()
+
+	wsMsg := &pb.WebSocketMessage{
+		Message: &pb.WebSocketMessage_VersusStatus{
+			VersusStatus: statusMsg,
+		},
+	}
+
+	return proto.Marshal(wsMsg)
+}
+
+// EncodeTimeSyncProtobuf кодирует состояние часов комнаты на момент now в protobuf формат
+func EncodeTimeSyncProtobuf(clock *RoomClock, now time.Time, clockMode string, timeLimit int, running bool, score int) ([]byte, error) {
+	syncMsg := &pb.TimeSyncMessage{
+		ServerTimeMs: now.UnixMilli(),
+		ElapsedMs:    now.Sub(clock.StartedAt).Milliseconds(),
+		ClockMode:    clockMode,
+		TimeLimit:    int32(timeLimit),
+		Running:      running,
+		Score:        int32(score),
+	}
+	if !clock.Deadline.IsZero() {
+		if left := clock.Deadline.Sub(now); left > 0 {
+			syncMsg.TimeLeftMs = left.Milliseconds()
+		}
+	}
+
+	wsMsg := &pb.WebSocketMessage{
+		Message: &pb.WebSocketMessage_TimeSync{
+			TimeSync: syncMsg,
+		},
+	}
+
+	return proto.Marshal(wsMsg)
+}
+
+// EncodeTurnStatusProtobuf кодирует очередь ходов и счет пошагового режима
+// Счет идет в порядке очереди, затем ушедшие игроки (их очки сохраняются до конца игры)
+func EncodeTurnStatusProtobuf(turns *TurnState, players map[string]Player, now time.Time) ([]byte, error) {
+	turns.Mu.Lock()
+	statusMsg := &pb.TurnStatusMessage{
+		CurrentPlayerId: turns.currentPlayer(),
+		Turn:            int32(turns.Turn),
+		TurnTimeout:     int32(turns.Timeout / time.Second),
+		Finished:        turns.Finished,
+	}
+	if turns.Finished {
+		statusMsg.CurrentPlayerId = ""
+	} else if !turns.TurnEndsAt.IsZero() {
+		if left := turns.TurnEndsAt.Sub(now); left > 0 {
+			statusMsg.TimeLeftMs = left.Milliseconds()
+		}
+	}
+
+	ids := make([]string, 0, len(turns.Scores))
+	listed := make(map[string]bool, len(turns.Order))
+	for _, id := range turns.Order {
+		ids = append(ids, id)
+		listed[id] = true
+	}
+	left := make([]string, 0)
+	for id := range turns.Scores {
+		if !listed[id] {
+			left = append(left, id)
+		}
+	}
+	sort.Strings(left)
+	ids = append(ids, left...)
+
+	for _, id := range ids {
+		player := players[id]
+		statusMsg.Scores = append(statusMsg.Scores, &pb.PlayerScore{
+			PlayerId: id,
+			Nickname: player.Nickname,
+			Color:    player.Color,
+			Score:    int32(turns.Scores[id]),
+			Place:    int32(turns.Places[id]),
+		})
+	}
+	turns.Mu.Unlock()
+
+	wsMsg := &pb.WebSocketMessage{
+		Message: &pb.WebSocketMessage_TurnStatus{
+			TurnStatus: statusMsg,
+		},
+	}
+
+	return proto.Marshal(wsMsg)
+}
@@ -0,0 +1,405 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// raceCountdown обратный отсчет перед стартом гонки
+const raceCountdown = 5 * time.Second
+
+// Состояния гонки
+const (
+	RaceCountdown = "countdown"
+	RaceRunning   = "running"
+	RaceFinished  = "finished"
+)
+
+// Racer участник гонки со своим полем
+type Racer struct {
+	PlayerID   string
+	UserID     int
+	Nickname   string
+	Color      string
+	IsBot      bool
+	Board      *GameState
+	Flags      int
+	Deaths     int // Подрывы: после подрыва поле начинается заново
+	Finished   bool
+	FinishTime float64 // Секунды от старта
+	Place      int     // Место после окончания гонки
+}
+
+// RaceState гонка (режим race): у каждого участника свое поле, сгенерированное по одному seed
+// Участники - игроки, которые были в комнате в момент старта; подключившиеся позже только смотрят
+type RaceState struct {
+	Status    string
+	StartsAt  time.Time
+	StartedAt time.Time
+	Seed      string
+	Racers    map[string]*Racer
+	WinnerID  string
+	timer     *time.Timer
+	Mu        sync.Mutex
+}
+
+// StartRace запускает обратный отсчет новой гонки
+// Вызывается при начале новой игры в комнате с режимом race
+func (s *Service) StartRace(room *Room) {
+	if room.GameMode != "race" {
+		return
+	}
+
+	race := &RaceState{
+		Status:   RaceCountdown,
+		StartsAt: time.Now().Add(raceCountdown),
+		Racers:   make(map[string]*Racer),
+	}
+
+	room.Mu.Lock()
+	if old := room.Race; old != nil {
+		old.Mu.Lock()
+		if old.timer != nil {
+			old.timer.Stop()
+		}
+		old.Mu.Unlock()
+	}
+	room.Race = race
+	room.StartTime = nil
+	room.resetClock()
+	room.Mu.Unlock()
+
+	race.Mu.Lock()
+	race.timer = time.AfterFunc(raceCountdown, func() {
+		s.beginRace(room, race)
+	})
+	race.Mu.Unlock()
+
+	log.Printf("Гонка в комнате %s: старт через %v", room.ID, raceCountdown)
+	s.BroadcastRaceStandings(room)
+}
+
+// beginRace стартует гонку после обратного отсчета: каждому игроку комнаты создается свое поле
+func (s *Service) beginRace(room *Room, race *RaceState) {
+	room.Mu.Lock()
+	if room.Race != race {
+		room.Mu.Unlock()
+		return
+	}
+	now := time.Now()
+	room.StartTime = &now
+	seed := room.GameState.Seed
+
+	race.Mu.Lock()
+	race.Status = RaceRunning
+	race.StartedAt = now
+	race.Seed = seed
+	for id, p := range room.Players {
+		if p.Spectator {
+			continue
+		}
+		race.Racers[id] = &Racer{
+			PlayerID: id,
+			UserID:   p.UserID,
+			Nickname: p.Nickname,
+			Color:    p.Color,
+			IsBot:    p.IsBot,
+			Board:    newSeededBoard(room.Rows, room.Cols, room.Mines, "race", seed),
+		}
+	}
+	racers := len(race.Racers)
+	race.Mu.Unlock()
+	room.Mu.Unlock()
+
+	log.Printf("Гонка в комнате %s началась: участников %d, seed=%s", room.ID, racers, seed)
+	s.startClock(room)
+	s.BroadcastGameState(room)
+	s.BroadcastRaceStandings(room)
+}
+
+// racerBoard возвращает поле участника идущей гонки или nil
+func (s *Service) racerBoard(room *Room, playerID string) *GameState {
+	room.Mu.RLock()
+	race := room.Race
+	room.Mu.RUnlock()
+	if race == nil {
+		return nil
+	}
+
+	race.Mu.Lock()
+	defer race.Mu.Unlock()
+	racer := race.Racers[playerID]
+	if race.Status != RaceRunning || racer == nil || racer.Finished {
+		return nil
+	}
+	return racer.Board
+}
+
+// handleRaceClick обрабатывает клик участника гонки по его собственному полю
+// Подрыв не заканчивает гонку для игрока: поле начинается заново, подрыв засчитывается
+func (s *Service) handleRaceClick(room *Room, playerID string, click *CellClick) error {
+	room.Mu.RLock()
+	race := room.Race
+	chording := room.Chording
+	rows, cols, mines := room.Rows, room.Cols, room.Mines
+	player := room.Players[playerID]
+	room.Mu.RUnlock()
+	if race == nil {
+		return nil
+	}
+
+	race.Mu.Lock()
+	racer := race.Racers[playerID]
+	if race.Status != RaceRunning || racer == nil || racer.Finished {
+		race.Mu.Unlock()
+		return nil
+	}
+	var nickname, playerColor string
+	if player != nil {
+		nickname = player.Nickname
+		playerColor = player.Color
+	}
+
+	gs := racer.Board
+	row, col := click.Row, click.Col
+	result, err := s.applyBoardClick(room, gs, playerID, playerColor, click, chording)
+	if err != nil {
+		race.Mu.Unlock()
+		return err
+	}
+	racer.Flags += result.flagDelta
+
+	if result.exploded {
+		racer.Deaths++
+		racer.Flags = 0
+		racer.Board = newSeededBoard(rows, cols, mines, "race", race.Seed)
+		racer.Board.ClickStats = gs.ClickStats // Клики считаются за всю гонку
+	} else if result.cleared {
+		racer.Finished = true
+		racer.FinishTime = time.Since(race.StartedAt).Seconds()
+	}
+	board := racer.Board
+	race.Mu.Unlock()
+
+	if result.exploded {
+		log.Printf("Гонка в комнате %s: игрок %s подорвался на (%d, %d), поле начинается заново", room.ID, playerID, row, col)
+		s.sendGameStateToPlayerID(playerID, board)
+		if nickname != "" {
+			s.BroadcastToAll(room, Message{
+				Type:     "chat",
+				PlayerID: playerID,
+				Nickname: nickname,
+				Color:    playerColor,
+				Chat: &ChatMessage{
+					Text:     fmt.Sprintf("%s подорвался и начинает заново 💣", nickname),
+					IsSystem: true,
+					Action:   "race",
+					Row:      row,
+					Col:      col,
+				},
+			})
+		}
+	} else if result.flagDelta != 0 {
+		s.sendGameStateToPlayerID(playerID, board)
+	} else if len(result.changedCells) > 0 {
+		s.sendCellUpdatesToPlayerID(room, playerID, board, result.changedCells)
+	}
+
+	if result.cleared {
+		s.finishRace(room, race, playerID)
+	}
+	s.BroadcastRaceStandings(room)
+	return nil
+}
+
+// finishRace завершает гонку победой winnerID и расставляет остальных по прогрессу
+func (s *Service) finishRace(room *Room, race *RaceState, winnerID string) {
+	race.Mu.Lock()
+	if race.Status != RaceRunning {
+		race.Mu.Unlock()
+		return
+	}
+	race.Status = RaceFinished
+	race.WinnerID = winnerID
+	elapsed := time.Since(race.StartedAt).Seconds()
+	standings := race.standings()
+	for i, racer := range standings {
+		racer.Place = i + 1
+		if !racer.Finished {
+			racer.FinishTime = elapsed
+		}
+	}
+	winner := race.Racers[winnerID]
+	race.Mu.Unlock()
+
+	log.Printf("Гонка в комнате %s завершена: победитель %s за %.2f сек", room.ID, winnerID, winner.FinishTime)
+	if winner.Nickname != "" {
+		s.BroadcastToAll(room, Message{
+			Type:     "chat",
+			PlayerID: winnerID,
+			Nickname: winner.Nickname,
+			Color:    winner.Color,
+			Chat: &ChatMessage{
+				Text:     fmt.Sprintf("🏁 %s выиграл гонку за %.2f сек", winner.Nickname, winner.FinishTime),
+				IsSystem: true,
+				Action:   "race",
+			},
+		})
+	}
+
+	s.recordRaceResults(room, race)
+}
+
+// standings возвращает участников в порядке мест: финишировавшие по времени,
+// остальные по числу открытых клеток и подрывам
+// ВАЖНО: вызывающий код должен удерживать race.Mu
+func (race *RaceState) standings() []*Racer {
+	racers := make([]*Racer, 0, len(race.Racers))
+	for _, racer := range race.Racers {
+		racers = append(racers, racer)
+	}
+	sort.Slice(racers, func(i, j int) bool {
+		a, b := racers[i], racers[j]
+		if a.Place != b.Place && a.Place > 0 && b.Place > 0 {
+			return a.Place < b.Place
+		}
+		if a.Finished != b.Finished {
+			return a.Finished
+		}
+		if a.Finished {
+			return a.FinishTime < b.FinishTime
+		}
+		if a.Board.Revealed != b.Board.Revealed {
+			return a.Board.Revealed > b.Board.Revealed
+		}
+		if a.Deaths != b.Deaths {
+			return a.Deaths < b.Deaths
+		}
+		return a.PlayerID < b.PlayerID
+	})
+	return racers
+}
+
+// recordRaceResults записывает результаты гонки с местами
+// Гонки с ботами в рейтинг не идут
+func (s *Service) recordRaceResults(room *Room, race *RaceState) {
+	if s.profileHandler == nil {
+		return
+	}
+
+	room.Mu.RLock()
+	botGame := room.hasBots()
+	cols, rows, mines := room.Cols, room.Rows, room.Mines
+	chording := room.Chording
+	roomID := room.ID
+	creatorID := room.CreatorID
+	hasCustomSeed := room.HasCustomSeed
+	room.Mu.RUnlock()
+
+	type raceResult struct {
+		racer   Racer
+		details GameResultDetails
+	}
+	race.Mu.Lock()
+	participants := make([]GameParticipant, 0)
+	results := make([]raceResult, 0)
+	standings := race.standings()
+	for _, racer := range standings {
+		if racer.IsBot {
+			botGame = true
+		}
+		if racer.UserID == 0 {
+			continue
+		}
+		participants = append(participants, GameParticipant{
+			UserID:   racer.UserID,
+			Nickname: racer.Nickname,
+			Color:    racer.Color,
+		})
+		racer.Board.Mu.RLock()
+		replay := racer.Board.BuildReplay()
+		details := GameResultDetails{
+			Replay:      replay,
+			GameMode:    "race",
+			FlagsPlaced: replay.FlagsPlacedBy(racer.PlayerID),
+			ThreeBV:     racer.Board.Calculate3BV(),
+			Clicks:      racer.Board.PlayerClickStats(racer.PlayerID),
+			Placement:   racer.Place,
+			Players:     len(standings),
+		}
+		racer.Board.Mu.RUnlock()
+		results = append(results, raceResult{racer: *racer, details: details})
+	}
+	seed := race.Seed
+	race.Mu.Unlock()
+
+	if botGame {
+		log.Printf("Гонка в комнате %s сыграна с ботами, результат не записывается", roomID)
+		return
+	}
+
+	go func() {
+		for _, result := range results {
+			// Стартовая область открыта для всех, поэтому гонка записывается как игра с QuickStart
+			unlocked, err := s.profileHandler.RecordGameResult(result.racer.UserID, cols, rows, mines, result.racer.FinishTime, result.racer.Place == 1, chording, true, roomID, seed, hasCustomSeed, creatorID, participants, result.details)
+			if err != nil {
+				log.Printf("Ошибка записи результата гонки: %v", err)
+				continue
+			}
+			s.announceAchievements(room, result.racer.PlayerID, result.racer.Nickname, result.racer.Color, unlocked)
+		}
+	}()
+}
+
+// BroadcastRaceStandings отправляет таблицу гонки всем игрокам комнаты
+func (s *Service) BroadcastRaceStandings(room *Room) {
+	room.Mu.RLock()
+	race := room.Race
+	room.Mu.RUnlock()
+	if race == nil {
+		return
+	}
+
+	binaryData, err := EncodeRaceStandingsProtobuf(race)
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка кодирования таблицы гонки: %v", err)
+		return
+	}
+	s.broadcastBinary(room, binaryData, "raceStandings")
+}
+
+// broadcastRaceBoards отправляет каждому игроку его поле; зрителям - общее поле комнаты
+func (s *Service) broadcastRaceBoards(room *Room) {
+	room.Mu.RLock()
+	race := room.Race
+	shared := room.GameState
+	playerIDs := make([]string, 0, len(room.Players))
+	for id, player := range room.Players {
+		if !player.IsBot {
+			playerIDs = append(playerIDs, id)
+		}
+	}
+	room.Mu.RUnlock()
+
+	boards := make(map[string]*GameState, len(playerIDs))
+	if race != nil {
+		race.Mu.Lock()
+		for _, id := range playerIDs {
+			if racer := race.Racers[id]; racer != nil {
+				boards[id] = racer.Board
+			}
+		}
+		race.Mu.Unlock()
+	}
+
+	for _, id := range playerIDs {
+		board := boards[id]
+		if board == nil {
+			board = shared
+		}
+		s.sendGameStateToPlayerID(id, board)
+	}
+}
@@ -0,0 +1,110 @@
+package game
+
+import "time"
+
+// MoveType тип хода в записи игры
+type MoveType string
+
+const (
+	MoveReveal MoveType = "reveal" // Открытие ячейки
+	MoveFlag   MoveType = "flag"   // Установка или снятие флага
+	MoveChord  MoveType = "chord"  // Chording по открытой цифре
+	MoveHint   MoveType = "hint"   // Использование подсказки
+)
+
+// ReplayCell ячейка, открытая в результате хода
+type ReplayCell struct {
+	Row           int  `json:"row"`
+	Col           int  `json:"col"`
+	NeighborMines int  `json:"n"`
+	IsMine        bool `json:"mine,omitempty"`
+}
+
+// Move один ход в записи игры
+type Move struct {
+	Type     MoveType     `json:"type"`
+	PlayerID string       `json:"playerId"`
+	Row      int          `json:"row"`
+	Col      int          `json:"col"`
+	Offset   int64        `json:"offset"`             // Миллисекунды от начала игры (Room.StartTime)
+	Flagged  bool         `json:"flagged,omitempty"`  // Состояние флага после хода (для flag и hint)
+	Revealed []ReplayCell `json:"revealed,omitempty"` // Ячейки, открытые этим ходом
+}
+
+// GameReplay полная запись игры для пошагового воспроизведения
+// Расположение мин сохраняется отдельно, так как в режимах training и fair
+// поле строится динамически и не восстанавливается по seed
+type GameReplay struct {
+	Moves      []Move   `json:"moves"`
+	MineLayout [][2]int `json:"mineLayout"` // Координаты мин [row, col] на момент окончания игры
+}
+
+// recordMove добавляет ход в запись игры
+// ВАЖНО: вызывающий код должен удерживать room.GameState.Mu
+func (s *Service) recordMove(room *Room, playerID string, moveType MoveType, row, col int, changedCells map[[2]int]bool) {
+	s.recordBoardMove(room, room.GameState, playerID, moveType, row, col, changedCells)
+}
+
+// recordBoardMove добавляет ход в запись игры на поле gs (в гонке у каждого игрока свое поле)
+// ВАЖНО: вызывающий код должен удерживать gs.Mu
+func (s *Service) recordBoardMove(room *Room, gs *GameState, playerID string, moveType MoveType, row, col int, changedCells map[[2]int]bool) {
+	move := Move{
+		Type:     moveType,
+		PlayerID: playerID,
+		Row:      row,
+		Col:      col,
+		Flagged:  gs.Board[row][col].IsFlagged,
+	}
+
+	room.Mu.RLock()
+	if room.StartTime != nil {
+		move.Offset = time.Since(*room.StartTime).Milliseconds()
+	}
+	room.Mu.RUnlock()
+
+	for pos := range changedCells {
+		cell := gs.Board[pos[0]][pos[1]]
+		if !cell.IsRevealed {
+			continue
+		}
+		move.Revealed = append(move.Revealed, ReplayCell{
+			Row:           pos[0],
+			Col:           pos[1],
+			NeighborMines: cell.NeighborMines,
+			IsMine:        cell.IsMine,
+		})
+	}
+
+	gs.Moves = append(gs.Moves, move)
+}
+
+// FlagsPlacedBy возвращает количество флагов, поставленных игроком вручную
+func (r *GameReplay) FlagsPlacedBy(playerID string) int {
+	count := 0
+	for _, move := range r.Moves {
+		if move.Type == MoveFlag && move.PlayerID == playerID && move.Flagged {
+			count++
+		}
+	}
+	return count
+}
+
+// BuildReplay собирает запись игры из накопленных ходов и текущего расположения мин
+// ВАЖНО: вызывающий код должен удерживать gs.Mu
+func (gs *GameState) BuildReplay() *GameReplay {
+	replay := &GameReplay{
+		Moves:      make([]Move, len(gs.Moves)),
+		MineLayout: make([][2]int, 0, gs.Mines),
+	}
+	copy(replay.Moves, gs.Moves)
+
+	for i := 0; i < gs.Rows; i++ {
+		for j := 0; j < gs.Cols; j++ {
+			if gs.Board[i][j].IsMine {
+				replay.MineLayout = append(replay.MineLayout, [2]int{i, j})
+			}
+		}
+	}
+
+	return replay
+}
@@ -13,12 +13,12 @@ func NewRoomManager() *RoomManager {
 	}
 }
 
-func NewRoom(id, name, password string, rows, cols, mines int, creatorID int, gameMode string, quickStart bool, chording bool, seed string, hasCustomSeed bool) *Room {
+func NewRoom(id, name, password string, rows, cols, mines int, creatorID int, gameMode string, quickStart bool, chording bool, noGuess bool, lives int, perPlayerLives bool, clockMode string, timeLimit int, turnTimeout int, noSpectators bool, maxSpectators int, seed string, hasCustomSeed bool) *Room {
 	// По умолчанию classic, если не указан
 	if gameMode == "" {
 		gameMode = "classic"
 	}
-	return &Room{
+	room := &Room{
 		ID:            id,
 		Name:          name,
 		Password:      password,
@@ -28,20 +28,33 @@ func NewRoom(id, name, password string, rows, cols, mines int, creatorID int, ga
 		GameMode:      gameMode,
 		QuickStart:    quickStart,
 		Chording:      chording,
+		NoGuess:       noGuess,
+		Lives:         lives,
+		PerPlayerLives: perPlayerLives,
+		ClockMode:     clockMode,
+		TimeLimit:     timeLimit,
+		TurnTimeout:   turnTimeout,
+		NoSpectators:  noSpectators,
+		MaxSpectators: maxSpectators,
 		CreatorID:     creatorID,
 		HasCustomSeed: hasCustomSeed,
 		Players:       make(map[string]*Player),
-		GameState:     NewGameState(rows, cols, mines, gameMode, seed),
+		GameState:     NewGameState(rows, cols, mines, gameMode, noGuess, seed),
 		CreatedAt:     time.Now(),
+		States:        newStateLog(),
 	}
+	room.resetVersus()
+	room.resetLives()
+	room.resetTurns()
+	return room
 }
 
-func (rm *RoomManager) CreateRoom(name, password string, rows, cols, mines int, creatorID int, gameMode string, quickStart bool, chording bool, seed string) *Room {
+func (rm *RoomManager) CreateRoom(name, password string, rows, cols, mines int, creatorID int, gameMode string, quickStart bool, chording bool, noGuess bool, lives int, perPlayerLives bool, clockMode string, timeLimit int, turnTimeout int, noSpectators bool, maxSpectators int, seed string) *Room {
 	roomID := utils.GenerateID()
 	// Определяем, был ли seed указан пользователем явно (непустая строка означает, что он был указан)
 	hasCustomSeed := seed != ""
 	log.Printf("RoomManager.CreateRoom: seed=%s, hasCustomSeed=%v", seed, hasCustomSeed)
-	room := NewRoom(roomID, name, password, rows, cols, mines, creatorID, gameMode, quickStart, chording, seed, hasCustomSeed)
+	room := NewRoom(roomID, name, password, rows, cols, mines, creatorID, gameMode, quickStart, chording, noGuess, lives, perPlayerLives, clockMode, timeLimit, turnTimeout, noSpectators, maxSpectators, seed, hasCustomSeed)
 	log.Printf("RoomManager.CreateRoom: комната создана, GameState.Seed=%s", room.GameState.Seed)
 	rm.mu.Lock()
 	rm.rooms[roomID] = room
@@ -70,7 +83,8 @@ func (rm *RoomManager) GetRoomsList() []map[string]interface{} {
 		log.Printf("[MUTEX] GetRoomsList: блокируем room.Mu.RLock() для комнаты %s", room.ID)
 		room.Mu.RLock()
 		log.Printf("[MUTEX] GetRoomsList: room.Mu.RLock() заблокирован для комнаты %s", room.ID)
-		playerCount := len(room.Players)
+		spectatorCount := room.spectatorCount()
+		playerCount := len(room.Players) - spectatorCount
 		log.Printf("[MUTEX] GetRoomsList: разблокируем room.Mu.RUnlock() для комнаты %s", room.ID)
 		room.Mu.RUnlock()
 		log.Printf("[MUTEX] GetRoomsList: room.Mu.RUnlock() разблокирован для комнаты %s", room.ID)
@@ -84,7 +98,16 @@ func (rm *RoomManager) GetRoomsList() []map[string]interface{} {
 			"gameMode":    room.GameMode,
 			"quickStart":  room.QuickStart,
 			"chording":    room.Chording,
+			"noGuess":     room.NoGuess,
+			"lives":       room.Lives,
+			"perPlayerLives": room.PerPlayerLives,
+			"clockMode":   room.ClockMode,
+			"timeLimit":   room.TimeLimit,
+			"turnTimeout": room.TurnTimeout,
+			"noSpectators": room.NoSpectators,
+			"maxSpectators": room.MaxSpectators,
 			"players":     playerCount,
+			"spectators":  spectatorCount,
 			"createdAt":   room.CreatedAt,
 			"creatorId":   room.CreatorID,
 		})
@@ -94,9 +117,19 @@ func (rm *RoomManager) GetRoomsList() []map[string]interface{} {
 
 func (rm *RoomManager) DeleteRoom(roomID string) {
 	rm.mu.Lock()
+	room := rm.rooms[roomID]
 	delete(rm.rooms, roomID)
 	rm.mu.Unlock()
 
+	// Останавливаем часы, чтобы не рассылать время удаленной комнате
+	if room != nil {
+		room.Mu.Lock()
+		room.resetClock()
+		room.resetTurns()
+		room.Mu.Unlock()
+		room.States.Close()
+	}
+
 	// Удаляем комнату из БД
 	if err := rm.DeleteRoomFromDB(roomID); err != nil {
 		log.Printf("Предупреждение: не удалось удалить комнату %s из БД: %v", roomID, err)
@@ -122,6 +155,14 @@ func (r *Room) ToResponse() map[string]interface{} {
 		"gameMode":    r.GameMode,
 		"quickStart":  r.QuickStart,
 		"chording":    r.Chording,
+		"noGuess":     r.NoGuess,
+		"lives":       r.Lives,
+		"perPlayerLives": r.PerPlayerLives,
+		"clockMode":   r.ClockMode,
+		"timeLimit":   r.TimeLimit,
+		"turnTimeout": r.TurnTimeout,
+		"noSpectators": r.NoSpectators,
+		"maxSpectators": r.MaxSpectators,
 		"creatorId":   r.CreatorID,
 		"createdAt":   r.CreatedAt,
 	}
@@ -154,6 +195,10 @@ func (r *Room) AddPlayer(playerID string, player *Player) {
 		r.Mu.Unlock()
 		log.Printf("[MUTEX] AddPlayer: room.Mu.Unlock() разблокирован для комнаты %s, игрок %s", r.ID, playerID)
 	}()
+	// В режиме versus новый игрок попадает в меньшую команду
+	if r.GameMode == "versus" {
+		player.Team = r.smallerTeam()
+	}
 	r.Players[playerID] = player
 }
 
@@ -183,6 +228,19 @@ func (r *Room) GetPlayerCount() int {
 	return len(r.Players)
 }
 
+// GetHumanCount возвращает количество игроков без учета ботов
+func (r *Room) GetHumanCount() int {
+	log.Printf("[MUTEX] GetHumanCount: блокируем room.Mu.RLock() для комнаты %s", r.ID)
+	r.Mu.RLock()
+	log.Printf("[MUTEX] GetHumanCount: room.Mu.RLock() заблокирован для комнаты %s", r.ID)
+	defer func() {
+		log.Printf("[MUTEX] GetHumanCount: разблокируем room.Mu.RUnlock() для комнаты %s", r.ID)
+		r.Mu.RUnlock()
+		log.Printf("[MUTEX] GetHumanCount: room.Mu.RUnlock() разблокирован для комнаты %s", r.ID)
+	}()
+	return len(r.Players) - len(r.bots)
+}
+
 // GetPlayer возвращает игрока по ID
 func (r *Room) GetPlayer(playerID string) *Player {
 	log.Printf("[MUTEX] GetPlayer: блокируем room.Mu.RLock() для комнаты %s, игрок %s", r.ID, playerID)
@@ -223,10 +281,14 @@ func (r *Room) ResetGame() {
 	select {
 	case <-locked:
 		log.Printf("ResetGame: room.Mu успешно заблокирован (Lock), создаем новый GameState с savedSeed=%s (len=%d)", savedSeed, len(savedSeed))
-		r.GameState = NewGameState(r.Rows, r.Cols, r.Mines, r.GameMode, savedSeed)
+		r.GameState = NewGameState(r.Rows, r.Cols, r.Mines, r.GameMode, r.NoGuess, savedSeed)
 		// При сбросе игры HasCustomSeed сохраняется (не сбрасывается)
 		log.Printf("ResetGame: новый GameState создан, seed=%s (len=%d), сбрасываем StartTime", r.GameState.Seed, len(r.GameState.Seed))
 		r.StartTime = nil
+		r.resetVersus()
+		r.resetLives()
+		r.resetClock()
+		r.resetTurns()
 		log.Printf("ResetGame: разблокируем room.Mu")
 		r.Mu.Unlock()
 		log.Printf("ResetGame: room.Mu разблокирован, завершено для комнаты %s", r.ID)
@@ -235,9 +297,13 @@ func (r *Room) ResetGame() {
 		// Все равно пытаемся продолжить, но это может быть проблемой
 		r.Mu.Lock()
 		log.Printf("ResetGame: room.Mu наконец заблокирован после ожидания")
-		r.GameState = NewGameState(r.Rows, r.Cols, r.Mines, r.GameMode, savedSeed)
+		r.GameState = NewGameState(r.Rows, r.Cols, r.Mines, r.GameMode, r.NoGuess, savedSeed)
 		// При сбросе игры HasCustomSeed сохраняется (не сбрасывается)
 		r.StartTime = nil
+		r.resetVersus()
+		r.resetLives()
+		r.resetClock()
+		r.resetTurns()
 		r.Mu.Unlock()
 		log.Printf("ResetGame: завершено для комнаты %s (с задержкой)", r.ID)
 	}
@@ -12,7 +12,7 @@ func (rm *RoomManager) SetServer(server interface{}) {
 }
 
 // UpdateRoom обновляет параметры комнаты
-func (rm *RoomManager) UpdateRoom(roomID string, name, password string, rows, cols, mines int, gameMode string, quickStart bool, chording bool) error {
+func (rm *RoomManager) UpdateRoom(roomID string, name, password string, rows, cols, mines int, gameMode string, quickStart bool, chording bool, noGuess bool, lives int, perPlayerLives bool, clockMode string, timeLimit int, turnTimeout int, noSpectators bool, maxSpectators int) error {
 	rm.mu.RLock()
 	room, exists := rm.rooms[roomID]
 	rm.mu.RUnlock()
@@ -44,6 +44,14 @@ func (rm *RoomManager) UpdateRoom(roomID string, name, password string, rows, co
 	room.GameMode = gameMode
 	room.QuickStart = quickStart
 	room.Chording = chording
+	room.NoGuess = noGuess
+	room.Lives = lives
+	room.PerPlayerLives = perPlayerLives
+	room.ClockMode = clockMode
+	room.TimeLimit = timeLimit
+	room.TurnTimeout = turnTimeout
+	room.NoSpectators = noSpectators
+	room.MaxSpectators = maxSpectators
 
 	// Сохраняем seed из текущего GameState, если он был указан пользователем
 	var savedSeed string = ""
@@ -53,10 +61,14 @@ func (rm *RoomManager) UpdateRoom(roomID string, name, password string, rows, co
 	}
 
 	// Пересоздаем игровое поле с новыми параметрами
-	room.GameState = NewGameState(rows, cols, mines, gameMode, savedSeed)
+	room.GameState = NewGameState(rows, cols, mines, gameMode, noGuess, savedSeed)
 	room.StartTime = nil // Сбрасываем время начала игры
+	room.resetVersus()
+	room.resetLives()
+	room.resetClock()
+	room.resetTurns()
 
-	log.Printf("Комната обновлена: %s (ID: %s, GameMode: %s, QuickStart: %v, Chording: %v)", name, roomID, gameMode, quickStart, chording)
+	log.Printf("Комната обновлена: %s (ID: %s, GameMode: %s, QuickStart: %v, Chording: %v, NoGuess: %v, Lives: %d, PerPlayerLives: %v, ClockMode: %s, TimeLimit: %d, TurnTimeout: %d, NoSpectators: %v, MaxSpectators: %d)", name, roomID, gameMode, quickStart, chording, noGuess, lives, perPlayerLives, clockMode, timeLimit, turnTimeout, noSpectators, maxSpectators)
 	
 	// Сохраняем обновленную комнату в БД
 	// Используем saveRoomUnsafe, так как room.Mu уже заблокирован
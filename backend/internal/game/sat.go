@@ -0,0 +1,590 @@
+package game
+
+// Параметры CDCL
+const (
+	satVarDecay        = 0.95 // Затухание активности переменных (VSIDS)
+	satRestartFirst    = 100  // Число конфликтов до первого рестарта
+	satRestartGrowth   = 1.5  // Рост интервала между рестартами
+	satActivityLimit   = 1e100
+	satActivityRescale = 1e-100
+)
+
+// Sat инкрементальный CDCL SAT-решатель (clause learning, VSIDS, watched literals)
+// Переменные нумеруются с 1, литерал - номер переменной со знаком (минус - отрицание).
+// Клаузы добавляются между запросами и остаются навсегда вместе с выученными, поэтому
+// один Sat переиспользуется для серии запросов SolveAssuming с разными предположениями:
+// выученные клаузы следуют из базы и без предположений, их можно не удалять
+type Sat struct {
+	numVars  int
+	clauses  [][]int   // Исходные и выученные клаузы, первые два литерала - наблюдаемые
+	watches  [][]int   // Индекс литерала -> клаузы, где он наблюдаемый
+	assigns  []int8    // Переменная -> 1 (true), -1 (false), 0 (не назначена)
+	level    []int     // Уровень решения, на котором назначена переменная
+	reason   []int     // Клауза, из которой выведено значение, -1 для решений и предположений
+	phase    []bool    // Последнее значение переменной (phase saving)
+	activity []float64 // Активность переменных для выбора ветвления
+	varInc   float64
+	heap     []int // Куча неназначенных переменных по активности
+	heapPos  []int // Переменная -> позиция в куче, -1 если нет
+	seen     []bool
+	trail    []int // Назначенные литералы в порядке назначения
+	trailLim []int // Начало каждого уровня решения в trail
+	qhead    int
+	unsat    bool // Противоречие на нулевом уровне: решений нет ни при каких предположениях
+}
+
+func NewSat(numVars int) *Sat {
+	s := &Sat{
+		watches:  make([][]int, 2),
+		assigns:  make([]int8, 1),
+		level:    make([]int, 1),
+		reason:   make([]int, 1),
+		phase:    make([]bool, 1),
+		activity: make([]float64, 1),
+		varInc:   1,
+		heapPos:  make([]int, 1),
+		seen:     make([]bool, 1),
+	}
+	for i := 0; i < numVars; i++ {
+		s.NewVar()
+	}
+	return s
+}
+
+// NewVar добавляет переменную и возвращает ее номер
+func (s *Sat) NewVar() int {
+	s.numVars++
+	v := s.numVars
+	s.watches = append(s.watches, nil, nil)
+	s.assigns = append(s.assigns, 0)
+	s.level = append(s.level, 0)
+	s.reason = append(s.reason, -1)
+	s.phase = append(s.phase, false)
+	s.activity = append(s.activity, 0)
+	s.heapPos = append(s.heapPos, -1)
+	s.seen = append(s.seen, false)
+	s.heapInsert(v)
+	return v
+}
+
+// Assert добавляет дизъюнкцию (clause)
+// Пустая клауза делает задачу невыполнимой
+func (s *Sat) Assert(vars []int) {
+	if s.unsat {
+		return
+	}
+	s.cancelUntil(0)
+
+	lits := make([]int, 0, len(vars))
+	for _, lit := range vars {
+		switch s.value(lit) {
+		case 1:
+			return // Уже выполнена на нулевом уровне
+		case -1:
+			continue
+		}
+		duplicate := false
+		for _, other := range lits {
+			if other == -lit {
+				return // Тавтология
+			}
+			if other == lit {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			lits = append(lits, lit)
+		}
+	}
+
+	switch len(lits) {
+	case 0:
+		s.unsat = true
+	case 1:
+		s.enqueue(lits[0], -1)
+		if s.propagate() != -1 {
+			s.unsat = true
+		}
+	default:
+		s.attach(lits)
+	}
+}
+
+// AssertAtLeast добавляет ограничение: хотя бы k переменных из vars должны быть true
+func (s *Sat) AssertAtLeast(vars []int, k int) {
+	if k <= 0 {
+		return
+	}
+	if k > len(vars) {
+		s.Assert(nil) // Противоречие
+		return
+	}
+	negated := make([]int, len(vars))
+	for i, v := range vars {
+		negated[i] = -v
+	}
+	s.AssertAtMost(negated, len(vars)-k)
+}
+
+// AssertAtMost добавляет ограничение: не более k переменных из vars могут быть true
+// Кодируется последовательным счетчиком (Sinz): O(n*k) клауз и вспомогательных переменных
+// вместо C(n, k+1) клауз прямого перебора
+func (s *Sat) AssertAtMost(vars []int, k int) {
+	n := len(vars)
+	if k < 0 {
+		s.Assert(nil) // Противоречие
+		return
+	}
+	if k >= n {
+		return // Всегда выполнимо
+	}
+	if k == 0 {
+		for _, v := range vars {
+			s.Assert([]int{-v})
+		}
+		return
+	}
+
+	// prev[j] истинна, если среди уже просмотренных переменных хотя бы j+1 истинны
+	prev := make([]int, k)
+	for j := range prev {
+		prev[j] = s.NewVar()
+	}
+	s.Assert([]int{-vars[0], prev[0]})
+	for j := 1; j < k; j++ {
+		s.Assert([]int{-prev[j]})
+	}
+	for i := 1; i < n-1; i++ {
+		cur := make([]int, k)
+		for j := range cur {
+			cur[j] = s.NewVar()
+		}
+		s.Assert([]int{-vars[i], cur[0]})
+		s.Assert([]int{-prev[0], cur[0]})
+		for j := 1; j < k; j++ {
+			s.Assert([]int{-vars[i], -prev[j-1], cur[j]})
+			s.Assert([]int{-prev[j], cur[j]})
+		}
+		s.Assert([]int{-vars[i], -prev[k-1]})
+		prev = cur
+	}
+	s.Assert([]int{-vars[n-1], -prev[k-1]})
+}
+
+// SolveAssuming решает SAT при временных предположениях (литералах)
+// Предположения не сохраняются, а выученные клаузы остаются для следующих запросов.
+// Возвращает значения переменных (индекс - номер переменной) или nil, если решений нет
+func (s *Sat) SolveAssuming(assumptions []int) *[]bool {
+	if s.unsat {
+		return nil
+	}
+	s.cancelUntil(0)
+
+	conflicts := 0
+	restartLimit := float64(satRestartFirst)
+	for {
+		confl := s.propagate()
+		if confl != -1 {
+			if len(s.trailLim) == 0 {
+				s.unsat = true
+				return nil
+			}
+			learnt, backtrackLevel := s.analyze(confl)
+			s.cancelUntil(backtrackLevel)
+			if len(learnt) == 1 {
+				s.enqueue(learnt[0], -1)
+			} else {
+				s.enqueue(learnt[0], s.attach(learnt))
+			}
+			s.varInc /= satVarDecay
+			conflicts++
+			continue
+		}
+
+		if float64(conflicts) >= restartLimit {
+			conflicts = 0
+			restartLimit *= satRestartGrowth
+			s.cancelUntil(0)
+			continue
+		}
+
+		// Сначала по одному уровню на каждое предположение
+		next := 0
+		for next == 0 && len(s.trailLim) < len(assumptions) {
+			lit := assumptions[len(s.trailLim)]
+			switch s.value(lit) {
+			case 1:
+				s.trailLim = append(s.trailLim, len(s.trail)) // Уже выполнено, пустой уровень
+			case -1:
+				s.cancelUntil(0)
+				return nil // Противоречит базе и предыдущим предположениям
+			default:
+				next = lit
+			}
+		}
+
+		if next == 0 {
+			next = s.pickBranch()
+			if next == 0 {
+				result := make([]bool, s.numVars+1)
+				for v := 1; v <= s.numVars; v++ {
+					result[v] = s.assigns[v] == 1
+				}
+				s.cancelUntil(0)
+				return &result
+			}
+		}
+
+		s.trailLim = append(s.trailLim, len(s.trail))
+		s.enqueue(next, -1)
+	}
+}
+
+// Solve решает SAT задачу без предположений
+func (s *Sat) Solve() *[]bool {
+	return s.SolveAssuming(nil)
+}
+
+// value возвращает значение литерала: 1, -1 или 0 (не назначен)
+func (s *Sat) value(lit int) int8 {
+	if lit < 0 {
+		return -s.assigns[-lit]
+	}
+	return s.assigns[lit]
+}
+
+// litIndex возвращает индекс литерала в watches
+func litIndex(lit int) int {
+	if lit < 0 {
+		return -2*lit + 1
+	}
+	return 2 * lit
+}
+
+func (s *Sat) attach(lits []int) int {
+	idx := len(s.clauses)
+	s.clauses = append(s.clauses, lits)
+	s.watches[litIndex(lits[0])] = append(s.watches[litIndex(lits[0])], idx)
+	s.watches[litIndex(lits[1])] = append(s.watches[litIndex(lits[1])], idx)
+	return idx
+}
+
+func (s *Sat) enqueue(lit int, from int) {
+	v := abs(lit)
+	if lit > 0 {
+		s.assigns[v] = 1
+	} else {
+		s.assigns[v] = -1
+	}
+	s.level[v] = len(s.trailLim)
+	s.reason[v] = from
+	s.trail = append(s.trail, lit)
+}
+
+// cancelUntil отменяет назначения выше уровня lvl
+func (s *Sat) cancelUntil(lvl int) {
+	if len(s.trailLim) <= lvl {
+		return
+	}
+	for i := len(s.trail) - 1; i >= s.trailLim[lvl]; i-- {
+		lit := s.trail[i]
+		v := abs(lit)
+		s.phase[v] = lit > 0
+		s.assigns[v] = 0
+		s.reason[v] = -1
+		if s.heapPos[v] == -1 {
+			s.heapInsert(v)
+		}
+	}
+	s.trail = s.trail[:s.trailLim[lvl]]
+	s.trailLim = s.trailLim[:lvl]
+	s.qhead = len(s.trail)
+}
+
+// propagate выполняет unit propagation по наблюдаемым литералам
+// Возвращает номер клаузы-конфликта или -1
+func (s *Sat) propagate() int {
+	for s.qhead < len(s.trail) {
+		falseLit := -s.trail[s.qhead]
+		s.qhead++
+
+		ws := s.watches[litIndex(falseLit)]
+		j := 0
+		for i := 0; i < len(ws); i++ {
+			ci := ws[i]
+			c := s.clauses[ci]
+			if c[0] == falseLit {
+				c[0], c[1] = c[1], c[0]
+			}
+			if s.value(c[0]) == 1 {
+				ws[j] = ci
+				j++
+				continue
+			}
+
+			// Ищем новый наблюдаемый литерал
+			moved := false
+			for k := 2; k < len(c); k++ {
+				if s.value(c[k]) != -1 {
+					c[1], c[k] = c[k], c[1]
+					s.watches[litIndex(c[1])] = append(s.watches[litIndex(c[1])], ci)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+
+			ws[j] = ci
+			j++
+			if s.value(c[0]) == -1 {
+				j += copy(ws[j:], ws[i+1:])
+				s.watches[litIndex(falseLit)] = ws[:j]
+				s.qhead = len(s.trail)
+				return ci
+			}
+			s.enqueue(c[0], ci)
+		}
+		s.watches[litIndex(falseLit)] = ws[:j]
+	}
+	return -1
+}
+
+// analyze строит выученную клаузу по первой точке сочленения (1UIP)
+// Возвращает клаузу (первый литерал - выводимый после отката) и уровень отката
+func (s *Sat) analyze(confl int) ([]int, int) {
+	learnt := []int{0}
+	current := len(s.trailLim)
+	pathCount := 0
+	lit := 0
+	idx := len(s.trail) - 1
+
+	for {
+		c := s.clauses[confl]
+		start := 0
+		if lit != 0 {
+			start = 1 // c[0] - сам выведенный литерал
+		}
+		for _, q := range c[start:] {
+			v := abs(q)
+			if s.seen[v] || s.level[v] == 0 {
+				continue
+			}
+			s.seen[v] = true
+			s.bumpVar(v)
+			if s.level[v] >= current {
+				pathCount++
+			} else {
+				learnt = append(learnt, q)
+			}
+		}
+
+		for !s.seen[abs(s.trail[idx])] {
+			idx--
+		}
+		lit = s.trail[idx]
+		idx--
+		confl = s.reason[abs(lit)]
+		s.seen[abs(lit)] = false
+		pathCount--
+		if pathCount == 0 {
+			break
+		}
+	}
+	learnt[0] = -lit
+
+	// Литерал с наибольшим уровнем ставим вторым наблюдаемым
+	backtrackLevel := 0
+	for i := 1; i < len(learnt); i++ {
+		s.seen[abs(learnt[i])] = false
+		if lvl := s.level[abs(learnt[i])]; lvl > backtrackLevel {
+			backtrackLevel = lvl
+			learnt[1], learnt[i] = learnt[i], learnt[1]
+		}
+	}
+	return learnt, backtrackLevel
+}
+
+// pickBranch выбирает неназначенную переменную с наибольшей активностью
+// Возвращает литерал с сохраненной фазой или 0, если все переменные назначены
+func (s *Sat) pickBranch() int {
+	for len(s.heap) > 0 {
+		v := s.heapPop()
+		if s.assigns[v] != 0 {
+			continue
+		}
+		if s.phase[v] {
+			return v
+		}
+		return -v
+	}
+	return 0
+}
+
+func (s *Sat) bumpVar(v int) {
+	s.activity[v] += s.varInc
+	if s.activity[v] > satActivityLimit {
+		for i := range s.activity {
+			s.activity[i] *= satActivityRescale
+		}
+		s.varInc *= satActivityRescale
+	}
+	if s.heapPos[v] != -1 {
+		s.heapUp(s.heapPos[v])
+	}
+}
+
+func (s *Sat) heapInsert(v int) {
+	s.heapPos[v] = len(s.heap)
+	s.heap = append(s.heap, v)
+	s.heapUp(len(s.heap) - 1)
+}
+
+func (s *Sat) heapPop() int {
+	top := s.heap[0]
+	last := s.heap[len(s.heap)-1]
+	s.heap = s.heap[:len(s.heap)-1]
+	s.heapPos[top] = -1
+	if len(s.heap) > 0 {
+		s.heap[0] = last
+		s.heapPos[last] = 0
+		s.heapDown(0)
+	}
+	return top
+}
+
+func (s *Sat) heapUp(i int) {
+	v := s.heap[i]
+	for i > 0 {
+		parent := (i - 1) / 2
+		if s.activity[s.heap[parent]] >= s.activity[v] {
+			break
+		}
+		s.heap[i] = s.heap[parent]
+		s.heapPos[s.heap[i]] = i
+		i = parent
+	}
+	s.heap[i] = v
+	s.heapPos[v] = i
+}
+
+func (s *Sat) heapDown(i int) {
+	v := s.heap[i]
+	for {
+		child := 2*i + 1
+		if child >= len(s.heap) {
+			break
+		}
+		if child+1 < len(s.heap) && s.activity[s.heap[child+1]] > s.activity[s.heap[child]] {
+			child++
+		}
+		if s.activity[s.heap[child]] <= s.activity[v] {
+			break
+		}
+		s.heap[i] = s.heap[child]
+		s.heapPos[s.heap[i]] = i
+		i = child
+	}
+	s.heap[i] = v
+	s.heapPos[v] = i
+}
+
+// AddCounter добавляет счетчик для переменных (для ограничений на количество мин)
+// Кодирование totalizer: counter[i] истинна, если истинны хотя бы i+1 переменных из vars
+func (s *Sat) AddCounter(vars []int) []int {
+	if len(vars) <= 1 {
+		return vars
+	}
+
+	mid := len(vars) / 2
+	left := s.AddCounter(vars[:mid])
+	right := s.AddCounter(vars[mid:])
+
+	counter := make([]int, len(vars))
+	for i := range counter {
+		counter[i] = s.NewVar()
+	}
+
+	// Добавляем ограничения для счетчика
+	for a := 0; a <= len(left); a++ {
+		for b := 0; b <= len(right); b++ {
+			if a > 0 && b > 0 {
+				s.Assert([]int{-left[a-1], -right[b-1], counter[a+b-1]})
+			} else if a > 0 {
+				s.Assert([]int{-left[a-1], counter[a-1]})
+			} else if b > 0 {
+				s.Assert([]int{-right[b-1], counter[b-1]})
+			}
+
+			if a < len(left) && b < len(right) {
+				s.Assert([]int{left[a], right[b], -counter[a+b]})
+			} else if a < len(left) {
+				s.Assert([]int{left[a], -counter[a+b]})
+			} else if b < len(right) {
+				s.Assert([]int{right[b], -counter[a+b]})
+			}
+		}
+	}
+
+	return counter
+}
+
+// CounterAtLeast возвращает предположения "хотя бы k переменных из счетчика true"
+// false, если ограничение невыполнимо
+func CounterAtLeast(counter []int, k int) ([]int, bool) {
+	if k > len(counter) {
+		return nil, false
+	}
+	lits := make([]int, 0)
+	for i := 0; i < k; i++ {
+		lits = append(lits, counter[i])
+	}
+	return lits, true
+}
+
+// CounterAtMost возвращает предположения "не более k переменных из счетчика true"
+// false, если ограничение невыполнимо
+func CounterAtMost(counter []int, k int) ([]int, bool) {
+	if k < 0 {
+		return nil, false
+	}
+	lits := make([]int, 0)
+	for i := k; i < len(counter); i++ {
+		lits = append(lits, -counter[i])
+	}
+	return lits, true
+}
+
+// AssertCounterAtLeast: хотя бы k переменных из счетчика должны быть true
+func (s *Sat) AssertCounterAtLeast(counter []int, k int) {
+	lits, ok := CounterAtLeast(counter, k)
+	if !ok {
+		s.Assert(nil)
+		return
+	}
+	for _, lit := range lits {
+		s.Assert([]int{lit})
+	}
+}
+
+// AssertCounterAtMost: не более k переменных из счетчика могут быть true
+func (s *Sat) AssertCounterAtMost(counter []int, k int) {
+	lits, ok := CounterAtMost(counter, k)
+	if !ok {
+		s.Assert(nil)
+		return
+	}
+	for _, lit := range lits {
+		s.Assert([]int{lit})
+	}
+}
+
+// Вспомогательные функции
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"log"
 	"time"
+
+	"minesweeperonline/internal/achievements"
 )
 
 // ProfileHandler интерфейс для работы с профилями
 type ProfileHandler interface {
-	RecordGameResult(userID, cols, rows, mines int, gameTime float64, won bool, chording, quickStart bool, roomID, seed string, hasCustomSeed bool, creatorID int, participants []GameParticipant) error
+	RecordGameResult(userID, cols, rows, mines int, gameTime float64, won bool, chording, quickStart bool, roomID, seed string, hasCustomSeed bool, creatorID int, participants []GameParticipant, details GameResultDetails) ([]achievements.Achievement, error)
 }
 
 // Service обрабатывает игровую логику
@@ -20,6 +22,7 @@ type Service struct {
 
 // WSPlayer интерфейс для WebSocket игрока
 type WSPlayer interface {
+	GetID() string
 	GetNickname() string
 	GetColor() string
 	GetUserID() int
@@ -27,6 +30,8 @@ type WSPlayer interface {
 	GetConn() interface{}
 	SetNickname(nickname string)
 	UpdateCursor(x, y float64) bool
+	// Send отправляет бинарное сообщение; replay - сообщение нумеруется и повторяется при возобновлении сессии
+	Send(binaryData []byte, replay bool) error
 }
 
 // WSManager интерфейс для доступа к WebSocket менеджеру
@@ -47,6 +52,21 @@ func NewService(roomManager *RoomManager, profileHandler ProfileHandler, wsManag
 func (s *Service) HandleCellClick(room *Room, playerID string, click *CellClick) error {
 	log.Printf("[GAME] HandleCellClick: начало, playerID=%s, row=%d, col=%d, flag=%v", playerID, click.Row, click.Col, click.Flag)
 	
+	// Зрители только смотрят
+	if room.IsSpectator(playerID) {
+		log.Printf("[GAME] HandleCellClick: %s - зритель, клик отклонен", playerID)
+		return fmt.Errorf("spectators can't play")
+	}
+
+	switch room.GameMode {
+	case "race":
+		return s.handleRaceClick(room, playerID, click)
+	case "versus":
+		return s.handleVersusClick(room, playerID, click)
+	case "turns":
+		return s.handleTurnsClick(room, playerID, click)
+	}
+
 	room.GameState.Mu.Lock()
 	log.Printf("[GAME] HandleCellClick: мьютекс заблокирован")
 
@@ -56,6 +76,12 @@ func (s *Service) HandleCellClick(room *Room, playerID string, click *CellClick)
 		return nil
 	}
 
+	if room.GameState.playerOut(playerID) {
+		log.Printf("[GAME] HandleCellClick: игрок %s потерял все жизни, клик игнорируется", playerID)
+		room.GameState.Mu.Unlock()
+		return nil
+	}
+
 	row, col := click.Row, click.Col
 	if row < 0 || row >= room.GameState.Rows || col < 0 || col >= room.GameState.Cols {
 		log.Printf("[GAME] HandleCellClick: некорректные координаты: row=%d, col=%d (размеры: rows=%d, cols=%d)", row, col, room.GameState.Rows, room.GameState.Cols)
@@ -92,8 +118,9 @@ func (s *Service) HandleCellClick(room *Room, playerID string, click *CellClick)
 // ВАЖНО: эта функция должна разблокировать room.GameState.Mu перед возвратом
 func (s *Service) handleFlagToggle(room *Room, playerID string, row, col int, cell *Cell, nickname, playerColor string) error {
 	log.Printf("[GAME] handleFlagToggle: начало, row=%d, col=%d", row, col)
-	if cell.IsRevealed {
-		log.Printf("[GAME] handleFlagToggle: нельзя поставить флаг на открытую ячейку: row=%d, col=%d", row, col)
+	if cell.IsRevealed || cell.Exploded {
+		log.Printf("[GAME] handleFlagToggle: нельзя поставить флаг на открытую ячейку или подорванную мину: row=%d, col=%d", row, col)
+		room.GameState.countClick(playerID, ClickRight, false)
 		room.GameState.Mu.Unlock()
 		return nil
 	}
@@ -108,6 +135,7 @@ func (s *Service) handleFlagToggle(room *Room, playerID string, row, col int, ce
 				timeSinceFlagSet := now.Sub(flagInfo.SetTime)
 				if timeSinceFlagSet < 1*time.Second {
 					log.Printf("[GAME] handleFlagToggle: нельзя снять флаг сразу после установки другим игроком: row=%d, col=%d", row, col)
+					room.GameState.countClick(playerID, ClickRight, false)
 					room.GameState.Mu.Unlock()
 					return nil
 				}
@@ -125,6 +153,11 @@ func (s *Service) handleFlagToggle(room *Room, playerID string, row, col int, ce
 
 	cell.IsFlagged = !cell.IsFlagged
 	log.Printf("[GAME] handleFlagToggle: флаг переключен: row=%d, col=%d, flagged=%v", row, col, cell.IsFlagged)
+	s.recordMove(room, playerID, MoveFlag, row, col, nil)
+	room.GameState.countClick(playerID, ClickRight, true)
+	if cell.IsFlagged {
+		room.GameState.countFlag(playerID, cell.IsMine)
+	}
 
 	gameMode := room.GameMode
 	room.GameState.Mu.Unlock()
@@ -171,6 +204,7 @@ func (s *Service) handleCellReveal(room *Room, playerID string, row, col int, ce
 	log.Printf("[GAME] handleCellReveal: начало, row=%d, col=%d", row, col)
 	if cell.IsFlagged {
 		log.Printf("[GAME] handleCellReveal: нельзя открыть ячейку с флагом: row=%d, col=%d", row, col)
+		room.GameState.countClick(playerID, ClickLeft, false)
 		room.GameState.Mu.Unlock()
 		return nil
 	}
@@ -184,6 +218,7 @@ func (s *Service) handleCellReveal(room *Room, playerID string, row, col int, ce
 
 	if cell.IsRevealed {
 		log.Printf("[GAME] handleCellReveal: клик на открытую клетку без chording, игнорируем")
+		room.GameState.countClick(playerID, ClickLeft, false)
 		// Разблокируем мьютекс перед возвратом
 		room.GameState.Mu.Unlock()
 		return nil
@@ -195,11 +230,35 @@ func (s *Service) handleCellReveal(room *Room, playerID string, row, col int, ce
 		now := time.Now()
 		room.StartTime = &now
 		log.Printf("StartTime установлен при первом клике: %v", now)
-	}
-
-	// Для classic режима с QuickStart: делаем первую клетку нулевой
-	// Применяем QuickStart всегда, когда он включен, независимо от seed
-	if gameMode == "classic" && isFirstClick && room.QuickStart {
+		// Часы ждут освобождения GameState.Mu, поэтому запускаются в горутине
+		go s.startClock(room)
+	}
+
+	// Для classic режима без угадываний: мины размещаются сейчас, первая клетка всегда нулевая
+	// QuickStart в этом случае не нужен (и сломал бы проверку решаемости)
+	if gameMode == "classic" && isFirstClick && room.NoGuess {
+		gs := room.GameState
+		log.Printf("[GAME] handleCellReveal: NoGuess включен, генерируем решаемое поле от (%d, %d) (seed=%s)", row, col, gs.Seed)
+		// Генерация с проверкой решаемости долгая, остальные действия в комнате ее не ждут
+		seed, rows, cols, mines := gs.Seed, gs.Rows, gs.Cols, gs.Mines
+		gs.Mu.Unlock()
+		board, solvable := GenerateNoGuessBoard(seed, rows, cols, mines, row, col)
+		gs.Mu.Lock()
+		// Пока поле генерировалось, игру могли начать заново или закончить
+		if room.GameState != gs || gs.GameOver {
+			gs.Mu.Unlock()
+			return nil
+		}
+		// Другой первый клик успел разместить мины или на клетку поставили флаг: клик обрабатывается заново
+		if gs.Revealed > 0 || gs.Board[row][col].IsFlagged {
+			return s.handleCellReveal(room, playerID, row, col, &gs.Board[row][col], nickname, playerColor)
+		}
+		gs.PlaceNoGuessMines(board, solvable)
+		cell = &room.GameState.Board[row][col]
+		log.Printf("[GAME] handleCellReveal: поле без угадываний готово, noGuess=%v, neighborMines=%d", room.GameState.NoGuess, cell.NeighborMines)
+	} else if gameMode == "classic" && isFirstClick && room.QuickStart {
+		// Для classic режима с QuickStart: делаем первую клетку нулевой
+		// Применяем QuickStart всегда, когда он включен, независимо от seed
 		log.Printf("[GAME] handleCellReveal: QuickStart включен, делаем первую клетку нулевой (seed=%s)", room.GameState.Seed)
 		room.GameState.Mu.Unlock()
 		room.GameState.EnsureFirstClickSafe(row, col)
@@ -219,14 +278,27 @@ func (s *Service) handleCellReveal(room *Room, playerID string, row, col int, ce
 	}
 
 	// Открываем ячейку
+	revealedBefore := room.GameState.Revealed
 	cell.IsRevealed = true
 	room.GameState.Revealed++
 	changedCells[[2]int{row, col}] = true
+	room.GameState.countClick(playerID, ClickLeft, true)
 	log.Printf("Ячейка открыта: row=%d, col=%d, isMine=%v", row, col, cell.IsMine)
 
 	if cell.IsMine {
+		room.GameState.contribution(playerID).MinesHit++
+		s.recordMove(room, playerID, MoveReveal, row, col, changedCells)
+		if s.loseLife(room, playerID, row, col) {
+			revealed := room.GameState.Revealed
+			hintsUsed := room.GameState.HintsUsed
+			room.GameState.Mu.Unlock()
+			s.announceLifeLost(room, playerID, row, col, nickname, playerColor)
+			s.BroadcastCellUpdates(room, changedCells, false, false, revealed, hintsUsed, "", "")
+			return nil
+		}
+		replay := room.GameState.BuildReplay()
 		room.GameState.Mu.Unlock()
-		err := s.handleMineExplosion(room, playerID, row, col, nickname, playerColor)
+		err := s.handleMineExplosion(room, playerID, row, col, nickname, playerColor, replay)
 		if err != nil {
 			return err
 		}
@@ -239,6 +311,8 @@ func (s *Service) handleCellReveal(room *Room, playerID string, row, col int, ce
 	if cell.NeighborMines == 0 {
 		s.revealNeighbors(room, row, col, changedCells)
 	}
+	room.GameState.contribution(playerID).CellsRevealed += room.GameState.Revealed - revealedBefore
+	s.recordMove(room, playerID, MoveReveal, row, col, changedCells)
 
 	// В режиме training пересчитываем подсказки асинхронно
 	if gameMode == "training" {
@@ -322,13 +396,17 @@ func (s *Service) handleChording(room *Room, playerID string, row, col int, cell
 
 	if flagCount != cell.NeighborMines {
 		log.Printf("[GAME] handleChording: не активирован (флагов: %d, нужно: %d)", flagCount, cell.NeighborMines)
+		room.GameState.countClick(playerID, ClickChord, false)
 		// Разблокируем мьютекс перед возвратом
 		room.GameState.Mu.Unlock()
 		return nil
 	}
 
 	log.Printf("[GAME] handleChording: активирован, открываем соседние клетки")
+	revealedBefore := room.GameState.Revealed
+	contribution := room.GameState.contribution(playerID)
 	changedCells := make(map[[2]int]bool)
+	exploded := make([][2]int, 0) // Мины, стоившие жизни (режим жизней)
 	for di := -1; di <= 1; di++ {
 		for dj := -1; dj <= 1; dj++ {
 			if di == 0 && dj == 0 {
@@ -342,10 +420,21 @@ func (s *Service) handleChording(room *Room, playerID string, row, col int, cell
 					room.GameState.Revealed++
 					changedCells[[2]int{ni, nj}] = true
 
+					if neighborCell.IsMine {
+						contribution.MinesHit++
+					}
+					if neighborCell.IsMine && s.loseLife(room, playerID, ni, nj) {
+						exploded = append(exploded, [2]int{ni, nj})
+						continue
+					}
 					if neighborCell.IsMine {
 						room.GameState.GameOver = true
+						room.GameState.countClick(playerID, ClickChord, true)
+						contribution.Chords++
+						contribution.CellsRevealed += room.GameState.Revealed - revealedBefore - 1
 						s.setLoserInfo(room, playerID)
-						s.recordGameResult(room, playerID, false)
+						s.recordMove(room, playerID, MoveChord, row, col, changedCells)
+						s.recordGameResult(room, playerID, false, false, room.GameState.BuildReplay())
 						room.GameState.Mu.Unlock()
 						go func() {
 							s.BroadcastGameState(room)
@@ -361,6 +450,13 @@ func (s *Service) handleChording(room *Room, playerID string, row, col int, cell
 		}
 	}
 
+	s.recordMove(room, playerID, MoveChord, row, col, changedCells)
+	room.GameState.countClick(playerID, ClickChord, len(changedCells) > 0)
+	if len(changedCells) > 0 {
+		contribution.Chords++
+		contribution.CellsRevealed += room.GameState.Revealed - revealedBefore
+	}
+
 	// Проверка победы
 	totalCells := room.GameState.Rows * room.GameState.Cols
 	if room.GameState.Revealed == totalCells-room.GameState.Mines {
@@ -370,6 +466,9 @@ func (s *Service) handleChording(room *Room, playerID string, row, col int, cell
 
 	// Разблокируем мьютекс перед отправкой обновлений
 	room.GameState.Mu.Unlock()
+	for _, pos := range exploded {
+		s.announceLifeLost(room, playerID, pos[0], pos[1], nickname, playerColor)
+	}
 	go func() {
 		s.BroadcastGameState(room)
 	}()
@@ -434,7 +533,7 @@ func (s *Service) handleDynamicMinePlacement(room *Room, clickRow, clickCol int)
 }
 
 // handleMineExplosion обрабатывает взрыв мины
-func (s *Service) handleMineExplosion(room *Room, playerID string, row, col int, nickname, playerColor string) error {
+func (s *Service) handleMineExplosion(room *Room, playerID string, row, col int, nickname, playerColor string, replay *GameReplay) error {
 	room.GameState.GameOver = true
 	s.setLoserInfo(room, playerID)
 
@@ -451,7 +550,7 @@ func (s *Service) handleMineExplosion(room *Room, playerID string, row, col int,
 		}
 
 		if userID > 0 {
-			s.recordGameResult(room, playerID, false)
+			s.recordGameResult(room, playerID, false, false, replay)
 		}
 	}
 
@@ -490,29 +589,64 @@ func (s *Service) handleMineExplosion(room *Room, playerID string, row, col int,
 }
 
 // handleGameWin обрабатывает победу
+// ВАЖНО: вызывается с заблокированным room.GameState.Mu
 func (s *Service) handleGameWin(room *Room, playerID string) {
+	replay := room.GameState.BuildReplay()
+	hintsUsed := room.GameState.HintsUsed
+	livesLost := room.GameState.TotalLivesLost()
+	revealed := room.GameState.Revealed
+	threeBV := room.GameState.Calculate3BV()
+	clickStats := make(map[string]ClickStats, len(room.GameState.ClickStats))
+	for id, stats := range room.GameState.ClickStats {
+		clickStats[id] = *stats
+	}
+	contributions := room.GameState.ContributionsSnapshot()
+	// С жизнями у каждого игрока потерявшие все жизни выбыли: поле открыли без них
+	outPlayers := make(map[string]bool)
+	for id := range room.GameState.LivesLost {
+		if room.GameState.playerOut(id) {
+			outPlayers[id] = true
+		}
+	}
+
 	var gameTime float64
 	room.Mu.RLock()
 	if room.StartTime != nil {
 		gameTime = time.Since(*room.StartTime).Seconds()
 	}
 	loserID := room.GameState.LoserPlayerID
+	noGuess := room.NoGuess && room.GameState.NoGuess
+	botGame := room.GameState.BotsPlayed || room.hasBots()
+	scoreGame := room.timeLimited() && room.ClockMode == ClockScore
 	room.Mu.RUnlock()
+	if botGame {
+		log.Printf("Игра в комнате %s сыграна с ботами, результат не записывается", room.ID)
+	}
 
 	go func() {
 		room.Mu.RLock()
 		participants := make([]GameParticipant, 0)
+		playerIDs := make([]string, 0, len(room.Players))
 		for _, p := range room.Players {
+			if p.Spectator {
+				continue
+			}
+			if !p.IsBot {
+				playerIDs = append(playerIDs, p.ID)
+			}
 			if p.UserID > 0 {
+				contribution := contributions[p.ID]
 				participants = append(participants, GameParticipant{
-					UserID:   p.UserID,
-					Nickname: p.Nickname,
-					Color:    p.Color,
+					UserID:       p.UserID,
+					Nickname:     p.Nickname,
+					Color:        p.Color,
+					Contribution: &contribution,
 				})
 			}
 		}
 		chording := room.Chording
 		quickStart := room.QuickStart
+		gameMode := room.GameMode
 		roomID := room.ID
 		creatorID := room.CreatorID
 		hasCustomSeed := room.HasCustomSeed
@@ -523,10 +657,34 @@ func (s *Service) handleGameWin(room *Room, playerID string) {
 		room.Mu.RUnlock()
 
 		for _, p := range room.Players {
-			if p.ID != loserID && p.UserID > 0 && s.profileHandler != nil {
-				if err := s.profileHandler.RecordGameResult(p.UserID, room.Cols, room.Rows, room.Mines, gameTime, true, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants); err != nil {
+			if !botGame && !p.Spectator && p.ID != loserID && p.UserID > 0 && s.profileHandler != nil {
+				details := GameResultDetails{
+					Replay:      replay,
+					GameMode:    gameMode,
+					HintsUsed:   hintsUsed,
+					FlagsPlaced: replay.FlagsPlacedBy(p.ID),
+					ThreeBV:     threeBV,
+					Clicks:      clickStats[p.ID],
+					NoGuess:     noGuess,
+					LivesLost:   livesLost,
+					Players:     len(playerIDs),
+				}
+				if scoreGame {
+					details.Score = revealed
+				}
+				// В совместной игре рейтинг зависит от вклада: бездействие не дает полного рейтинга
+				if len(playerIDs) > 1 {
+					share := ContributionShare(contributions, p.ID, playerIDs)
+					details.ContributionShare = &share
+				}
+				// Выбывший игрок записывается проигравшим
+				won := !outPlayers[p.ID]
+				unlocked, err := s.profileHandler.RecordGameResult(p.UserID, room.Cols, room.Rows, room.Mines, gameTime, won, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants, details)
+				if err != nil {
 					log.Printf("Ошибка записи результата игры: %v", err)
+					continue
 				}
+				s.announceAchievements(room, p.ID, p.Nickname, p.Color, unlocked)
 			}
 		}
 
@@ -563,7 +721,8 @@ func (s *Service) setLoserInfo(room *Room, playerID string) {
 }
 
 // recordGameResult записывает результат игры
-func (s *Service) recordGameResult(room *Room, playerID string, won bool) {
+// completed - игра окончена без победы и поражения (время вышло в игре на счет)
+func (s *Service) recordGameResult(room *Room, playerID string, won, completed bool, replay *GameReplay) {
 	var userID int
 	if s.wsManager != nil {
 		wsPlayer := s.wsManager.GetWSPlayer(playerID)
@@ -588,17 +747,31 @@ func (s *Service) recordGameResult(room *Room, playerID string, won bool) {
 
 	var gameTime float64
 	room.Mu.RLock()
+	if room.GameState.BotsPlayed || room.hasBots() {
+		room.Mu.RUnlock()
+		log.Printf("Игра в комнате %s сыграна с ботами, результат не записывается", room.ID)
+		return
+	}
 	if room.StartTime != nil {
 		gameTime = time.Since(*room.StartTime).Seconds()
 	}
 	participants := make([]GameParticipant, 0)
+	humans := 0
 	for _, p := range room.Players {
-		if p.UserID > 0 {
-			participants = append(participants, GameParticipant{
+		if !p.IsBot && !p.Spectator {
+			humans++
+		}
+		if p.UserID > 0 && !p.Spectator {
+			participant := GameParticipant{
 				UserID:   p.UserID,
 				Nickname: p.Nickname,
 				Color:    p.Color,
-			})
+			}
+			if room.GameState != nil {
+				contribution := room.GameState.PlayerContribution(p.ID)
+				participant.Contribution = &contribution
+			}
+			participants = append(participants, participant)
 		}
 	}
 	chording := room.Chording
@@ -606,15 +779,40 @@ func (s *Service) recordGameResult(room *Room, playerID string, won bool) {
 	roomID := room.ID
 	creatorID := room.CreatorID
 	hasCustomSeed := room.HasCustomSeed
+	details := GameResultDetails{
+		Replay:    replay,
+		GameMode:  room.GameMode,
+		Players:   humans,
+		Completed: completed,
+	}
 	seed := ""
 	if room.GameState != nil {
 		seed = room.GameState.Seed
+		details.NoGuess = room.NoGuess && room.GameState.NoGuess
+		details.HintsUsed = room.GameState.HintsUsed
+		details.ThreeBV = room.GameState.Calculate3BV()
+		details.Clicks = room.GameState.PlayerClickStats(playerID)
+		details.LivesLost = room.GameState.TotalLivesLost()
+		if room.timeLimited() && room.ClockMode == ClockScore {
+			details.Score = room.GameState.Revealed
+		}
+	}
+	if replay != nil {
+		details.FlagsPlaced = replay.FlagsPlacedBy(playerID)
+	}
+	var nickname, color string
+	if player := room.Players[playerID]; player != nil {
+		nickname = player.Nickname
+		color = player.Color
 	}
 	room.Mu.RUnlock()
 
 	go func() {
-		if err := s.profileHandler.RecordGameResult(userID, room.Cols, room.Rows, room.Mines, gameTime, won, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants); err != nil {
+		unlocked, err := s.profileHandler.RecordGameResult(userID, room.Cols, room.Rows, room.Mines, gameTime, won, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants, details)
+		if err != nil {
 			log.Printf("Ошибка записи результата игры: %v", err)
+		} else {
+			s.announceAchievements(room, playerID, nickname, color, unlocked)
 		}
 		if err := s.roomManager.SaveRoom(room); err != nil {
 			log.Printf("Предупреждение: не удалось сохранить комнату %s: %v", roomID, err)
@@ -644,3 +842,22 @@ func (s *Service) revealNeighbors(room *Room, row, col int, changedCells map[[2]
 		}
 	}
 }
+
+// announceAchievements объявляет в чате комнаты о полученных игроком достижениях
+func (s *Service) announceAchievements(room *Room, playerID, nickname, playerColor string, unlocked []achievements.Achievement) {
+	for _, achievement := range unlocked {
+		log.Printf("Игрок %s получил достижение %s", nickname, achievement.ID)
+		chatMsg := Message{
+			Type:     "chat",
+			PlayerID: playerID,
+			Nickname: nickname,
+			Color:    playerColor,
+			Chat: &ChatMessage{
+				Text:     fmt.Sprintf("🏆 %s получил достижение «%s»: %s", nickname, achievement.Title, achievement.Description),
+				IsSystem: true,
+				Action:   "achievement",
+			},
+		}
+		s.BroadcastToAll(room, chatMsg)
+	}
+}
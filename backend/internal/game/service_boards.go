@@ -0,0 +1,150 @@
+package game
+
+import (
+	"fmt"
+	"log"
+)
+
+// boardClickResult результат клика по отдельному полю (гонка, командная игра)
+type boardClickResult struct {
+	changedCells map[[2]int]bool
+	flagDelta    int  // +1 флаг поставлен, -1 снят
+	exploded     bool // Открыта мина
+	cleared      bool // Открыты все безопасные клетки
+}
+
+// newSeededBoard создает поле с минами по seed и одинаковой открытой стартовой областью,
+// чтобы на одинаковых полях первый клик не решал исход соревнования
+func newSeededBoard(rows, cols, mines int, gameMode, seed string) *GameState {
+	gs := NewGameState(rows, cols, mines, gameMode, false, seed)
+
+	// Стартовая область - пустая клетка, ближайшая к центру
+	bestRow, bestCol, bestDist := -1, -1, rows+cols
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if gs.Board[i][j].IsMine || gs.Board[i][j].NeighborMines != 0 {
+				continue
+			}
+			if dist := abs(i-rows/2) + abs(j-cols/2); dist < bestDist {
+				bestRow, bestCol, bestDist = i, j, dist
+			}
+		}
+	}
+	if bestRow != -1 {
+		gs.Board[bestRow][bestCol].IsRevealed = true
+		gs.Revealed++
+		gs.RevealNeighbors(bestRow, bestCol, make(map[[2]int]bool))
+	}
+	return gs
+}
+
+// applyBoardClick применяет клик к полю gs: флаг, открытие или chording
+// В отличие от общего поля комнаты, подрыв не заканчивает игру - решает вызывающий код
+func (s *Service) applyBoardClick(room *Room, gs *GameState, playerID, playerColor string, click *CellClick, chording bool) (boardClickResult, error) {
+	result := boardClickResult{changedCells: make(map[[2]int]bool)}
+	row, col := click.Row, click.Col
+
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	if !gs.isValidCell(row, col) {
+		return result, fmt.Errorf("invalid coordinates")
+	}
+
+	cell := &gs.Board[row][col]
+	switch {
+	case click.Flag:
+		if cell.IsRevealed {
+			gs.countClick(playerID, ClickRight, false)
+			break
+		}
+		cell.IsFlagged = !cell.IsFlagged
+		if cell.IsFlagged {
+			cell.FlagColor = playerColor
+			result.flagDelta = 1
+		} else {
+			cell.FlagColor = ""
+			result.flagDelta = -1
+		}
+		gs.countClick(playerID, ClickRight, true)
+		s.recordBoardMove(room, gs, playerID, MoveFlag, row, col, nil)
+
+	case cell.IsRevealed:
+		flags := 0
+		for di := -1; di <= 1; di++ {
+			for dj := -1; dj <= 1; dj++ {
+				if gs.isValidCell(row+di, col+dj) && gs.Board[row+di][col+dj].IsFlagged {
+					flags++
+				}
+			}
+		}
+		if !chording || cell.NeighborMines == 0 || flags != cell.NeighborMines {
+			gs.countClick(playerID, ClickChord, false)
+			break
+		}
+		for di := -1; di <= 1; di++ {
+			for dj := -1; dj <= 1; dj++ {
+				ni, nj := row+di, col+dj
+				if !gs.isValidCell(ni, nj) {
+					continue
+				}
+				neighbor := &gs.Board[ni][nj]
+				if neighbor.IsRevealed || neighbor.IsFlagged {
+					continue
+				}
+				neighbor.IsRevealed = true
+				gs.Revealed++
+				result.changedCells[[2]int{ni, nj}] = true
+				if neighbor.IsMine {
+					result.exploded = true
+				} else if neighbor.NeighborMines == 0 {
+					gs.RevealNeighbors(ni, nj, result.changedCells)
+				}
+			}
+		}
+		gs.countClick(playerID, ClickChord, len(result.changedCells) > 0)
+		s.recordBoardMove(room, gs, playerID, MoveChord, row, col, result.changedCells)
+
+	case cell.IsFlagged:
+		gs.countClick(playerID, ClickLeft, false)
+
+	default:
+		cell.IsRevealed = true
+		gs.Revealed++
+		result.changedCells[[2]int{row, col}] = true
+		if cell.IsMine {
+			result.exploded = true
+		} else if cell.NeighborMines == 0 {
+			gs.RevealNeighbors(row, col, result.changedCells)
+		}
+		gs.countClick(playerID, ClickLeft, true)
+		s.recordBoardMove(room, gs, playerID, MoveReveal, row, col, result.changedCells)
+	}
+
+	result.cleared = !result.exploded && gs.Revealed == gs.Rows*gs.Cols-gs.Mines
+	return result, nil
+}
+
+// sendGameStateToPlayerID отправляет игроку состояние поля gs
+func (s *Service) sendGameStateToPlayerID(playerID string, gs *GameState) {
+	binaryData, err := EncodeGameStateProtobuf(gs)
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка кодирования gameState: %v", err)
+		return
+	}
+	s.sendToPlayer(playerID, binaryData, "gameState")
+}
+
+// sendCellUpdatesToPlayerID отправляет игроку измененные клетки его поля
+func (s *Service) sendCellUpdatesToPlayerID(room *Room, playerID string, gs *GameState, changedCells map[[2]int]bool) {
+	updates := CollectBoardUpdates(gs, room.GameMode, changedCells)
+	gs.Mu.RLock()
+	revealed := gs.Revealed
+	gs.Mu.RUnlock()
+	binaryData, err := EncodeCellUpdateProtobuf(updates, false, false, revealed, 0, "", "")
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка кодирования обновлений клеток: %v", err)
+		return
+	}
+	s.sendToPlayer(playerID, binaryData, "cellUpdate")
+}
@@ -2,52 +2,31 @@ package game
 
 import (
 	"log"
-
-	gorillaWS "github.com/gorilla/websocket"
+	"strconv"
 )
 
 // BroadcastGameState отправляет состояние игры всем игрокам
 func (s *Service) BroadcastGameState(room *Room) {
-	binaryData, err := EncodeGameStateProtobuf(room.GameState)
-	if err != nil {
-		log.Printf("[WS OUT] Ошибка кодирования gameState: %v", err)
+	// В гонке у каждого игрока свое поле, в матче команд - у каждой команды
+	switch room.GameMode {
+	case "race":
+		s.broadcastRaceBoards(room)
 		return
+	case "versus":
+		s.broadcastVersusBoards(room)
+		return
+	case "turns":
+		// Вместе с полем уходит очередь ходов (в том числе после новой игры)
+		s.syncTurns(room)
+		defer s.BroadcastTurnStatus(room)
 	}
 
-	room.Mu.RLock()
-	playerIDs := make([]string, 0, len(room.Players))
-	for id := range room.Players {
-		playerIDs = append(playerIDs, id)
-	}
-	room.Mu.RUnlock()
-
-	log.Printf("[WS OUT] BroadcastGameState: отправка всем игрокам (количество=%d), размер=%d байт", len(playerIDs), len(binaryData))
-
-	for _, id := range playerIDs {
-		wsPlayer := s.wsManager.GetWSPlayer(id)
-		if wsPlayer != nil {
-			mu := wsPlayer.GetMu()
-			conn := wsPlayer.GetConn()
-			if conn != nil {
-				if wsConn, ok := conn.(*gorillaWS.Conn); ok {
-					if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-						muVal.Lock()
-					}
-					if err := wsConn.WriteMessage(gorillaWS.BinaryMessage, binaryData); err != nil {
-						log.Printf("[WS OUT] Ошибка отправки gameState игроку %s: %v", id, err)
-					} else {
-						log.Printf("[WS OUT] Игрок %s: отправлен gameState, размер=%d байт", id, len(binaryData))
-					}
-					if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-						muVal.Unlock()
-					}
-				}
-			} else {
-				log.Printf("[WS OUT] Игрок %s: соединение nil, пропуск отправки gameState", id)
-			}
-		} else {
-			log.Printf("[WS OUT] Игрок %s: wsPlayer не найден, пропуск отправки gameState", id)
-		}
+	// Поле кодируется под блокировкой версий: более старое состояние не обгонит более новое изменение
+	err := s.publishState(room, "gameState", func() ([]byte, error) {
+		return EncodeGameStateProtobuf(room.GameState)
+	})
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка кодирования gameState: %v", err)
 	}
 }
 
@@ -59,53 +38,20 @@ func (s *Service) BroadcastCellUpdates(room *Room, changedCells map[[2]int]bool,
 	}
 
 	log.Printf("[WS OUT] BroadcastCellUpdates: отправка обновлений (changedCells=%d, gameOver=%v, gameWon=%v, revealed=%d)", len(changedCells), gameOver, gameWon, revealed)
-	updates := CollectCellUpdates(room, changedCells)
-	log.Printf("[WS OUT] BroadcastCellUpdates: собрано обновлений клеток: %d", len(updates))
-	binaryData, err := EncodeCellUpdateProtobuf(updates, gameOver, gameWon, revealed, hintsUsed, loserPlayerID, loserNickname)
+	// Клетки и счетчики читаются в момент кодирования под блокировкой версий,
+	// поэтому изменение с большей версией всегда содержит более новое состояние
+	err := s.publishState(room, "cellUpdate", func() ([]byte, error) {
+		updates := CollectCellUpdates(room, changedCells)
+		log.Printf("[WS OUT] BroadcastCellUpdates: собрано обновлений клеток: %d", len(updates))
+		gs := room.GameState
+		gs.Mu.RLock()
+		revealed, hintsUsed := gs.Revealed, gs.HintsUsed
+		gs.Mu.RUnlock()
+		return EncodeLivesCellUpdateProtobuf(updates, gameOver, gameWon, revealed, hintsUsed, loserPlayerID, loserNickname, gs)
+	})
 	if err != nil {
 		log.Printf("[WS OUT] Ошибка кодирования обновлений клеток: %v", err)
 		s.BroadcastGameState(room)
-		return
-	}
-
-	room.Mu.RLock()
-	playerIDs := make([]string, 0, len(room.Players))
-	for id := range room.Players {
-		playerIDs = append(playerIDs, id)
-	}
-	room.Mu.RUnlock()
-
-	log.Printf("[WS OUT] BroadcastCellUpdates: отправка всем игрокам (количество=%d), размер=%d байт", len(playerIDs), len(binaryData))
-
-	for _, id := range playerIDs {
-		log.Printf("[WS OUT] BroadcastCellUpdates: поиск wsPlayer для id=%s", id)
-		wsPlayer := s.wsManager.GetWSPlayer(id)
-		if wsPlayer != nil {
-			log.Printf("[WS OUT] BroadcastCellUpdates: wsPlayer найден для id=%s", id)
-			mu := wsPlayer.GetMu()
-			conn := wsPlayer.GetConn()
-			if conn != nil {
-				if wsConn, ok := conn.(*gorillaWS.Conn); ok {
-					if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-						muVal.Lock()
-					}
-					if err := wsConn.WriteMessage(gorillaWS.BinaryMessage, binaryData); err != nil {
-						log.Printf("[WS OUT] Ошибка отправки cellUpdate игроку %s: %v", id, err)
-					} else {
-						log.Printf("[WS OUT] Игрок %s: отправлен cellUpdate, размер=%d байт, обновлений=%d", id, len(binaryData), len(updates))
-					}
-					if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-						muVal.Unlock()
-					}
-				} else {
-					log.Printf("[WS OUT] Игрок %s: соединение не является *gorillaWS.Conn, пропуск", id)
-				}
-			} else {
-				log.Printf("[WS OUT] Игрок %s: соединение nil, пропуск отправки cellUpdate", id)
-			}
-		} else {
-			log.Printf("[WS OUT] Игрок %s: wsPlayer не найден в wsPlayers, пропуск отправки cellUpdate", id)
-		}
 	}
 }
 
@@ -114,6 +60,8 @@ func (s *Service) BroadcastToAll(room *Room, msg Message) {
 	var binaryData []byte
 	var err error
 	if msg.Type == "chat" && msg.Chat != nil {
+		// Сообщения только своей команде бывают лишь в режиме versus
+		msg.Chat.TeamOnly = msg.Chat.TeamOnly && room.GameMode == "versus"
 		binaryData, err = EncodeChatProtobuf(&msg)
 		if err != nil {
 			log.Printf("[WS OUT] Ошибка кодирования чата: %v", err)
@@ -125,35 +73,22 @@ func (s *Service) BroadcastToAll(room *Room, msg Message) {
 	}
 
 	room.Mu.RLock()
+	senderTeam := 0
+	if sender := room.Players[msg.PlayerID]; sender != nil {
+		senderTeam = sender.Team
+	}
 	playerIDs := make([]string, 0, len(room.Players))
-	for id := range room.Players {
-		playerIDs = append(playerIDs, id)
+	for id, player := range room.Players {
+		if !player.IsBot && (!msg.Chat.TeamOnly || player.Team == senderTeam) {
+			playerIDs = append(playerIDs, id)
+		}
 	}
 	room.Mu.RUnlock()
 
 	log.Printf("[WS OUT] BroadcastToAll (chat): отправка всем игрокам (количество=%d), размер=%d байт, текст=%s", len(playerIDs), len(binaryData), msg.Chat.Text)
 
 	for _, id := range playerIDs {
-		wsPlayer := s.wsManager.GetWSPlayer(id)
-		if wsPlayer != nil {
-			mu := wsPlayer.GetMu()
-			conn := wsPlayer.GetConn()
-			if conn != nil {
-				if wsConn, ok := conn.(*gorillaWS.Conn); ok {
-					if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-						muVal.Lock()
-					}
-					if err := wsConn.WriteMessage(gorillaWS.BinaryMessage, binaryData); err != nil {
-						log.Printf("[WS OUT] Ошибка отправки chat игроку %s: %v", id, err)
-					} else {
-						log.Printf("[WS OUT] Игрок %s: отправлен chat, размер=%d байт", id, len(binaryData))
-					}
-					if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-						muVal.Unlock()
-					}
-				}
-			}
-		}
+		s.sendToPlayer(id, binaryData, "chat")
 	}
 }
 
@@ -177,9 +112,15 @@ func (s *Service) BroadcastToOthers(room *Room, senderID string, msg Message) {
 	}
 
 	room.Mu.RLock()
+	// В режиме versus курсор видят только своя команда и зрители
+	versus := room.GameMode == "versus"
+	senderTeam := 0
+	if sender := room.Players[senderID]; sender != nil {
+		senderTeam = sender.Team
+	}
 	playerIDs := make([]string, 0, len(room.Players))
-	for id := range room.Players {
-		if id != senderID {
+	for id, player := range room.Players {
+		if id != senderID && !player.IsBot && (!versus || player.Team == senderTeam || player.Team == 0) {
 			playerIDs = append(playerIDs, id)
 		}
 	}
@@ -190,28 +131,24 @@ func (s *Service) BroadcastToOthers(room *Room, senderID string, msg Message) {
 
 	for _, id := range playerIDs {
 		wsPlayer := s.wsManager.GetWSPlayer(id)
-		if wsPlayer != nil {
-			mu := wsPlayer.GetMu()
-			conn := wsPlayer.GetConn()
-			if conn != nil {
-				if wsConn, ok := conn.(*gorillaWS.Conn); ok {
-					if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-						muVal.Lock()
-					}
-					if err := wsConn.WriteMessage(gorillaWS.BinaryMessage, binaryData); err != nil {
-						log.Printf("[WS OUT] Ошибка отправки cursor игроку %s: %v", id, err)
-					}
-					if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-						muVal.Unlock()
-					}
-				}
-			}
+		if wsPlayer == nil {
+			continue
+		}
+		// Курсор сразу устаревает: не нумеруется и не повторяется при возобновлении сессии
+		if err := wsPlayer.Send(binaryData, false); err != nil {
+			log.Printf("[WS OUT] Ошибка отправки cursor игроку %s: %v", id, err)
 		}
 	}
 }
 
 // BroadcastPlayerList отправляет список игроков всем игрокам
 func (s *Service) BroadcastPlayerList(room *Room) {
+	// Очередь ходов следует за составом комнаты
+	if room.GameMode == "turns" {
+		s.syncTurns(room)
+		defer s.BroadcastTurnStatus(room)
+	}
+
 	room.Mu.RLock()
 	playersList := make([]map[string]string, 0, len(room.Players))
 	for _, player := range room.Players {
@@ -219,6 +156,9 @@ func (s *Service) BroadcastPlayerList(room *Room) {
 			"id":       player.ID,
 			"nickname": player.Nickname,
 			"color":    player.Color,
+			"isBot":    strconv.FormatBool(player.IsBot),
+			"team":     strconv.Itoa(player.Team),
+			"spectator": strconv.FormatBool(player.Spectator),
 		})
 	}
 	room.Mu.RUnlock()
@@ -231,61 +171,54 @@ func (s *Service) BroadcastPlayerList(room *Room) {
 
 	room.Mu.RLock()
 	playerIDs := make([]string, 0, len(room.Players))
-	for id := range room.Players {
-		playerIDs = append(playerIDs, id)
+	for id, player := range room.Players {
+		if !player.IsBot {
+			playerIDs = append(playerIDs, id)
+		}
 	}
 	room.Mu.RUnlock()
 
 	log.Printf("[WS OUT] BroadcastPlayerList: отправка всем игрокам (количество=%d), размер=%d байт, игроков в списке=%d", len(playerIDs), len(binaryData), len(playersList))
 
 	for _, id := range playerIDs {
-		wsPlayer := s.wsManager.GetWSPlayer(id)
-		if wsPlayer != nil {
-			mu := wsPlayer.GetMu()
-			conn := wsPlayer.GetConn()
-			if conn != nil {
-				if wsConn, ok := conn.(*gorillaWS.Conn); ok {
-					if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-						muVal.Lock()
-					}
-					if err := wsConn.WriteMessage(gorillaWS.BinaryMessage, binaryData); err != nil {
-						log.Printf("[WS OUT] Ошибка отправки players игроку %s: %v", id, err)
-					} else {
-						log.Printf("[WS OUT] Игрок %s: отправлен players, размер=%d байт", id, len(binaryData))
-					}
-					if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-						muVal.Unlock()
-					}
-				}
-			}
-		}
+		s.sendToPlayer(id, binaryData, "players")
 	}
 }
 
 // SendGameStateToPlayer отправляет состояние игры конкретному игроку
 func (s *Service) SendGameStateToPlayer(room *Room, player WSPlayer) {
-	binaryData, err := EncodeGameStateProtobuf(room.GameState)
-	if err != nil {
-		log.Printf("[WS OUT] Ошибка кодирования gameState: %v", err)
-		return
+	// Часы комнаты, восстановленной из БД, запускаются при первом подключении
+	s.startClock(room)
+	defer s.sendTimeSyncToPlayer(room, player)
+
+	if room.GameMode == "turns" {
+		s.syncTurns(room)
+		defer s.sendTurnStatusToPlayer(room, player)
 	}
 
-	mu := player.GetMu()
-	conn := player.GetConn()
-	if conn != nil {
-		if wsConn, ok := conn.(*gorillaWS.Conn); ok {
-			if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-				muVal.Lock()
-				defer muVal.Unlock()
-			}
-			if err := wsConn.WriteMessage(gorillaWS.BinaryMessage, binaryData); err != nil {
-				log.Printf("[WS OUT] Ошибка отправки gameState игроку: %v", err)
-			} else {
-				log.Printf("[WS OUT] Отправлен gameState игроку, размер=%d байт", len(binaryData))
+	if room.GameMode == "versus" {
+		s.sendVersusView(room, player)
+		return
+	}
+	if room.GameMode == "race" {
+		// Подключившийся во время гонки видит общее поле и таблицу гонки
+		room.Mu.RLock()
+		race := room.Race
+		room.Mu.RUnlock()
+		if race != nil {
+			if binaryData, err := EncodeRaceStandingsProtobuf(race); err == nil {
+				if err := writeBinary(player, binaryData); err != nil {
+					log.Printf("[WS OUT] Ошибка отправки raceStandings игроку: %v", err)
+				}
 			}
 		}
+	}
+
+	// Снимок несет текущую версию поля: от нее клиент отсчитывает следующие изменения
+	if err := s.sendSnapshot(room, player); err != nil {
+		log.Printf("[WS OUT] Ошибка отправки gameState игроку: %v", err)
 	} else {
-		log.Printf("[WS OUT] Соединение nil, пропуск отправки gameState")
+		log.Printf("[WS OUT] Отправлен gameState игроку %s", player.GetID())
 	}
 }
 
@@ -298,6 +231,9 @@ func (s *Service) SendPlayerListToPlayer(room *Room, player WSPlayer) {
 			"id":       p.ID,
 			"nickname": p.Nickname,
 			"color":    p.Color,
+			"isBot":    strconv.FormatBool(p.IsBot),
+			"team":     strconv.Itoa(p.Team),
+			"spectator": strconv.FormatBool(p.Spectator),
 		})
 	}
 	room.Mu.RUnlock()
@@ -308,22 +244,66 @@ func (s *Service) SendPlayerListToPlayer(room *Room, player WSPlayer) {
 		return
 	}
 
-	mu := player.GetMu()
-	conn := player.GetConn()
-	if conn != nil {
-		if wsConn, ok := conn.(*gorillaWS.Conn); ok {
-			if muVal, ok := mu.(interface{ Lock(); Unlock() }); ok {
-				muVal.Lock()
-				defer muVal.Unlock()
-			}
-			if err := wsConn.WriteMessage(gorillaWS.BinaryMessage, binaryData); err != nil {
-				log.Printf("[WS OUT] Ошибка отправки players игроку: %v", err)
-			} else {
-				log.Printf("[WS OUT] Отправлен players игроку, размер=%d байт, игроков в списке=%d", len(binaryData), len(playersList))
-			}
-		}
+	if err := writeBinary(player, binaryData); err != nil {
+		log.Printf("[WS OUT] Ошибка отправки players игроку: %v", err)
+	} else {
+		log.Printf("[WS OUT] Отправлен players игроку, размер=%d байт, игроков в списке=%d", len(binaryData), len(playersList))
+	}
+}
+
+
+// SendHintExplanation отправляет объяснение подсказки только запросившему игроку
+func (s *Service) SendHintExplanation(playerID string, explanation *HintExplanation) {
+	binaryData, err := EncodeHintExplanationProtobuf(explanation)
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка кодирования объяснения подсказки: %v", err)
+		return
+	}
+
+	wsPlayer := s.wsManager.GetWSPlayer(playerID)
+	if wsPlayer == nil {
+		log.Printf("[WS OUT] Игрок %s: wsPlayer не найден, пропуск отправки объяснения подсказки", playerID)
+		return
+	}
+	if err := writeBinary(wsPlayer, binaryData); err != nil {
+		log.Printf("[WS OUT] Ошибка отправки объяснения подсказки игроку %s: %v", playerID, err)
+	} else {
+		log.Printf("[WS OUT] Игрок %s: отправлено объяснение подсказки (%s), размер=%d байт", playerID, explanation.Pattern, len(binaryData))
+	}
+}
+
+// sendToPlayer отправляет бинарное сообщение одному игроку; kind - тип сообщения для логов
+func (s *Service) sendToPlayer(playerID string, binaryData []byte, kind string) {
+	wsPlayer := s.wsManager.GetWSPlayer(playerID)
+	if wsPlayer == nil {
+		log.Printf("[WS OUT] Игрок %s: wsPlayer не найден, пропуск отправки %s", playerID, kind)
+		return
+	}
+	if err := writeBinary(wsPlayer, binaryData); err != nil {
+		log.Printf("[WS OUT] Ошибка отправки %s игроку %s: %v", kind, playerID, err)
 	} else {
-		log.Printf("[WS OUT] Соединение nil, пропуск отправки players")
+		log.Printf("[WS OUT] Игрок %s: отправлен %s, размер=%d байт", playerID, kind, len(binaryData))
 	}
 }
 
+// broadcastBinary отправляет бинарное сообщение всем игрокам комнаты
+func (s *Service) broadcastBinary(room *Room, binaryData []byte, kind string) {
+	room.Mu.RLock()
+	playerIDs := make([]string, 0, len(room.Players))
+	for id, player := range room.Players {
+		if !player.IsBot {
+			playerIDs = append(playerIDs, id)
+		}
+	}
+	room.Mu.RUnlock()
+
+	for _, id := range playerIDs {
+		s.sendToPlayer(id, binaryData, kind)
+	}
+}
+
+// writeBinary отправляет игроку бинарное сообщение с номером в его сессии
+// Пока игрок переподключается, сообщение копится и будет повторено при возобновлении сессии
+func writeBinary(player WSPlayer, binaryData []byte) error {
+	return player.Send(binaryData, true)
+}
@@ -8,6 +8,14 @@ import (
 
 // HandleHint обрабатывает подсказку
 func (s *Service) HandleHint(room *Room, playerID string, hint *Hint) error {
+	// В гонке, в матче команд и в пошаговом режиме подсказок нет: у всех одинаковые условия
+	if room.GameMode == "race" || room.GameMode == "versus" || room.GameMode == "turns" {
+		return nil
+	}
+	if room.IsSpectator(playerID) {
+		return fmt.Errorf("spectators can't take hints")
+	}
+
 	room.GameState.Mu.Lock()
 
 	if room.GameState.GameOver || room.GameState.GameWon {
@@ -16,12 +24,25 @@ func (s *Service) HandleHint(room *Room, playerID string, hint *Hint) error {
 		return nil
 	}
 
+	if room.GameState.playerOut(playerID) {
+		log.Printf("Игрок %s потерял все жизни, подсказка игнорируется", playerID)
+		room.GameState.Mu.Unlock()
+		return nil
+	}
+
 	if room.GameState.HintsUsed >= 3 {
 		log.Printf("Лимит подсказок исчерпан (использовано: %d)", room.GameState.HintsUsed)
 		room.GameState.Mu.Unlock()
 		return nil
 	}
 
+	// В режиме без угадываний мины размещаются только при первом клике
+	if room.GameMode == "classic" && room.NoGuess && room.GameState.Revealed == 0 {
+		log.Printf("Подсказка до первого клика в режиме без угадываний игнорируется")
+		room.GameState.Mu.Unlock()
+		return nil
+	}
+
 	row, col := hint.Row, hint.Col
 	if row < 0 || row >= room.GameState.Rows || col < 0 || col >= room.GameState.Cols {
 		log.Printf("Некорректные координаты подсказки: row=%d, col=%d", row, col)
@@ -37,6 +58,9 @@ func (s *Service) HandleHint(room *Room, playerID string, hint *Hint) error {
 		return nil
 	}
 
+	// Объяснение строится по открытым клеткам до применения подсказки
+	explanation := s.explainHint(room, row, col)
+
 	room.Mu.RLock()
 	player := room.Players[playerID]
 	var nickname string
@@ -51,8 +75,10 @@ func (s *Service) HandleHint(room *Room, playerID string, hint *Hint) error {
 		cell.IsFlagged = true
 		cell.FlagColor = playerColor
 		room.GameState.HintsUsed++
+		room.GameState.contribution(playerID).HintsUsed++
 		changedCells := make(map[[2]int]bool)
 		changedCells[[2]int{row, col}] = true
+		s.recordMove(room, playerID, MoveHint, row, col, nil)
 		room.GameState.Mu.Unlock()
 
 		s.BroadcastCellUpdates(room, changedCells, room.GameState.GameOver, room.GameState.GameWon, room.GameState.Revealed, room.GameState.HintsUsed, room.GameState.LoserPlayerID, room.GameState.LoserNickname)
@@ -85,6 +111,7 @@ func (s *Service) HandleHint(room *Room, playerID string, hint *Hint) error {
 			}
 			s.BroadcastToAll(room, chatMsg)
 		}
+		s.SendHintExplanation(playerID, explanation)
 		return nil
 	}
 
@@ -94,10 +121,12 @@ func (s *Service) HandleHint(room *Room, playerID string, hint *Hint) error {
 	cell.IsRevealed = true
 	room.GameState.Revealed++
 	room.GameState.HintsUsed++
+	room.GameState.contribution(playerID).HintsUsed++
 
 	if cell.NeighborMines == 0 {
 		s.revealNeighbors(room, row, col, changedCells)
 	}
+	s.recordMove(room, playerID, MoveHint, row, col, changedCells)
 
 	// Проверка победы
 	totalCells := room.GameState.Rows * room.GameState.Cols
@@ -137,11 +166,31 @@ func (s *Service) HandleHint(room *Room, playerID string, hint *Hint) error {
 		}
 		s.BroadcastToAll(room, chatMsg)
 	}
+	s.SendHintExplanation(playerID, explanation)
 
 	return nil
 }
 
-// CalculateCellHints вычисляет подсказки только для ячеек на границе
+// explainHint строит объяснение подсказки для закрытой клетки (row, col)
+// Вызывается под блокировкой GameState
+func (s *Service) explainHint(room *Room, row, col int) *HintExplanation {
+	lm := NewLabelMap(room.GameState.Cols, room.GameState.Rows)
+	for i := 0; i < room.GameState.Rows; i++ {
+		for j := 0; j < room.GameState.Cols; j++ {
+			if room.GameState.Board[i][j].IsRevealed {
+				lm.labels[i][j] = room.GameState.Board[i][j].NeighborMines
+			}
+		}
+	}
+	lm.Recalc()
+
+	explanation := ExplainCell(lm, room.GameState.Mines, row, col, room.GameState.Board[row][col].IsMine)
+	log.Printf("Объяснение подсказки (%d, %d): шаблон %s, чисел %d", row, col, explanation.Pattern, len(explanation.Labels))
+	return explanation
+}
+
+// CalculateCellHints вычисляет подсказки для ячеек на границе
+// В режиме training дополнительно считаются вероятности мин для всех закрытых ячеек
 func (s *Service) CalculateCellHints(room *Room) {
 	room.GameState.Mu.Lock()
 	defer room.GameState.Mu.Unlock()
@@ -182,6 +231,29 @@ func (s *Service) CalculateCellHints(room *Room) {
 		})
 	}
 
+	// В режиме training добавляем точные вероятности мин для тепловой карты
+	if room.GameMode == "training" {
+		if probs, ok := solver.Probabilities(); ok {
+			for i := range hints {
+				idx := lm.GetBoundaryIndex(hints[i].Row, hints[i].Col)
+				hints[i].Probability = probs.Boundary[idx]
+			}
+			for i := 0; i < room.GameState.Rows; i++ {
+				for j := 0; j < room.GameState.Cols; j++ {
+					if room.GameState.Board[i][j].IsRevealed || lm.GetBoundaryIndex(i, j) != -1 {
+						continue
+					}
+					hints = append(hints, CellHint{
+						Row:         i,
+						Col:         j,
+						Type:        "PROBABILITY",
+						Probability: probs.Outside,
+					})
+				}
+			}
+		}
+	}
+
 	room.GameState.CellHints = hints
 	log.Printf("Вычислены подсказки для %d ячеек на границе", len(hints))
 }
@@ -0,0 +1,56 @@
+package game
+
+import (
+	"fmt"
+	"log"
+)
+
+// MaxSpectatorsLimit наибольшее ограничение числа зрителей, которое можно задать комнате
+const MaxSpectatorsLimit = 100
+
+// AddSpectator добавляет в комнату зрителя
+// Зритель получает поле, обновления, курсоры и чат, но не ходит и не попадает в результаты игры
+// Возвращает ошибку, если создатель комнаты запретил зрителей или их уже максимум
+func (r *Room) AddSpectator(playerID string, player *Player) error {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+
+	if r.NoSpectators {
+		return fmt.Errorf("spectators are not allowed in this room")
+	}
+	if r.MaxSpectators > 0 && r.spectatorCount() >= r.MaxSpectators {
+		return fmt.Errorf("room is full of spectators")
+	}
+	player.Spectator = true
+	player.Team = 0
+	r.Players[playerID] = player
+	log.Printf("Зритель %s подключен к комнате %s", playerID, r.ID)
+	return nil
+}
+
+// IsSpectator является ли участник комнаты зрителем
+func (r *Room) IsSpectator(playerID string) bool {
+	r.Mu.RLock()
+	defer r.Mu.RUnlock()
+	player := r.Players[playerID]
+	return player != nil && player.Spectator
+}
+
+// GetSpectatorCount возвращает количество зрителей
+func (r *Room) GetSpectatorCount() int {
+	r.Mu.RLock()
+	defer r.Mu.RUnlock()
+	return r.spectatorCount()
+}
+
+// spectatorCount возвращает количество зрителей
+// ВАЖНО: вызывающий код должен удерживать r.Mu
+func (r *Room) spectatorCount() int {
+	count := 0
+	for _, player := range r.Players {
+		if player.Spectator {
+			count++
+		}
+	}
+	return count
+}
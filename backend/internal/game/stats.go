@@ -0,0 +1,225 @@
+package game
+
+import "math"
+
+// ClickKind тип клика для статистики
+type ClickKind int
+
+const (
+	ClickLeft  ClickKind = iota // Открытие ячейки
+	ClickRight                  // Установка или снятие флага
+	ClickChord                  // Chording по открытой цифре
+)
+
+// ClickStats счетчики полезных и бесполезных кликов игрока
+// Полезный клик изменяет состояние поля, бесполезный - нет
+type ClickStats struct {
+	LeftUseful  int
+	LeftWasted  int
+	RightUseful int
+	RightWasted int
+	ChordUseful int
+	ChordWasted int
+}
+
+// Left возвращает общее количество левых кликов
+func (c ClickStats) Left() int {
+	return c.LeftUseful + c.LeftWasted
+}
+
+// Right возвращает общее количество правых кликов
+func (c ClickStats) Right() int {
+	return c.RightUseful + c.RightWasted
+}
+
+// Chord возвращает общее количество chording кликов
+func (c ClickStats) Chord() int {
+	return c.ChordUseful + c.ChordWasted
+}
+
+// Useful возвращает количество полезных кликов
+func (c ClickStats) Useful() int {
+	return c.LeftUseful + c.RightUseful + c.ChordUseful
+}
+
+// Wasted возвращает количество бесполезных кликов
+func (c ClickStats) Wasted() int {
+	return c.LeftWasted + c.RightWasted + c.ChordWasted
+}
+
+// countClick учитывает клик игрока
+// ВАЖНО: вызывающий код должен удерживать gs.Mu
+func (gs *GameState) countClick(playerID string, kind ClickKind, useful bool) {
+	if gs.ClickStats == nil {
+		gs.ClickStats = make(map[string]*ClickStats)
+	}
+	stats := gs.ClickStats[playerID]
+	if stats == nil {
+		stats = &ClickStats{}
+		gs.ClickStats[playerID] = stats
+	}
+
+	switch kind {
+	case ClickLeft:
+		if useful {
+			stats.LeftUseful++
+		} else {
+			stats.LeftWasted++
+		}
+	case ClickRight:
+		if useful {
+			stats.RightUseful++
+		} else {
+			stats.RightWasted++
+		}
+	case ClickChord:
+		if useful {
+			stats.ChordUseful++
+		} else {
+			stats.ChordWasted++
+		}
+	}
+}
+
+// PlayerClickStats возвращает копию счетчиков кликов игрока
+// ВАЖНО: вызывающий код должен удерживать gs.Mu
+func (gs *GameState) PlayerClickStats(playerID string) ClickStats {
+	if stats := gs.ClickStats[playerID]; stats != nil {
+		return *stats
+	}
+	return ClickStats{}
+}
+
+// Contribution вклад игрока в совместную игру на общем поле
+type Contribution struct {
+	CellsRevealed  int // Открытые клетки, включая каскад и chording
+	FlagsCorrect   int // Флаги, поставленные на мины
+	FlagsIncorrect int // Флаги, поставленные мимо мин
+	Chords         int // Полезные chording-клики
+	HintsUsed      int // Взятые подсказки
+	MinesHit       int // Открытые мины (в режиме жизней их может быть несколько)
+}
+
+// Work возвращает объем полезной работы игрока: открытые клетки и верные флаги
+func (c Contribution) Work() int {
+	return c.CellsRevealed + c.FlagsCorrect
+}
+
+// contribution возвращает счетчики вклада игрока, создавая их при необходимости
+// ВАЖНО: вызывающий код должен удерживать gs.Mu
+func (gs *GameState) contribution(playerID string) *Contribution {
+	if gs.Contributions == nil {
+		gs.Contributions = make(map[string]*Contribution)
+	}
+	c := gs.Contributions[playerID]
+	if c == nil {
+		c = &Contribution{}
+		gs.Contributions[playerID] = c
+	}
+	return c
+}
+
+// countFlag учитывает установленный игроком флаг
+// В training и fair мины под закрытыми клетками еще могут переместиться, поэтому флаг оценивается по текущему полю
+// ВАЖНО: вызывающий код должен удерживать gs.Mu
+func (gs *GameState) countFlag(playerID string, correct bool) {
+	if correct {
+		gs.contribution(playerID).FlagsCorrect++
+	} else {
+		gs.contribution(playerID).FlagsIncorrect++
+	}
+}
+
+// PlayerContribution возвращает копию счетчиков вклада игрока
+// ВАЖНО: вызывающий код должен удерживать gs.Mu
+func (gs *GameState) PlayerContribution(playerID string) Contribution {
+	if c := gs.Contributions[playerID]; c != nil {
+		return *c
+	}
+	return Contribution{}
+}
+
+// ContributionsSnapshot возвращает копию вклада всех игроков
+// ВАЖНО: вызывающий код должен удерживать gs.Mu
+func (gs *GameState) ContributionsSnapshot() map[string]Contribution {
+	snapshot := make(map[string]Contribution, len(gs.Contributions))
+	for id, c := range gs.Contributions {
+		snapshot[id] = *c
+	}
+	return snapshot
+}
+
+// ContributionShare возвращает множитель рейтинга за вклад игрока среди playerIDs:
+// отношение его работы к средней по игрокам, не больше 1
+// Игрок, сделавший не меньше среднего, получает полный рейтинг, бездействовавший - нулевой
+func ContributionShare(contributions map[string]Contribution, playerID string, playerIDs []string) float64 {
+	total := 0
+	for _, id := range playerIDs {
+		total += contributions[id].Work()
+	}
+	if total == 0 || len(playerIDs) == 0 {
+		return 1
+	}
+	average := float64(total) / float64(len(playerIDs))
+	return math.Min(1, float64(contributions[playerID].Work())/average)
+}
+
+// Calculate3BV вычисляет 3BV поля - минимальное количество кликов для его открытия
+// Каждая область нулевых ячеек (вместе с ее границей) открывается одним кликом,
+// каждая ненулевая ячейка, не граничащая с такой областью, требует отдельного клика
+// ВАЖНО: вызывающий код должен удерживать gs.Mu
+func (gs *GameState) Calculate3BV() int {
+	counts := make([][]int, gs.Rows)
+	for i := 0; i < gs.Rows; i++ {
+		counts[i] = make([]int, gs.Cols)
+		for j := 0; j < gs.Cols; j++ {
+			counts[i][j] = gs.countNeighborMines(i, j)
+		}
+	}
+
+	marked := make([][]bool, gs.Rows)
+	for i := range marked {
+		marked[i] = make([]bool, gs.Cols)
+	}
+
+	bbbv := 0
+
+	// Открытия: каждая связная область нулей считается одним кликом
+	for i := 0; i < gs.Rows; i++ {
+		for j := 0; j < gs.Cols; j++ {
+			if marked[i][j] || gs.Board[i][j].IsMine || counts[i][j] != 0 {
+				continue
+			}
+			bbbv++
+			stack := [][2]int{{i, j}}
+			marked[i][j] = true
+			for len(stack) > 0 {
+				pos := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				for di := -1; di <= 1; di++ {
+					for dj := -1; dj <= 1; dj++ {
+						ni, nj := pos[0]+di, pos[1]+dj
+						if !gs.isValidCell(ni, nj) || marked[ni][nj] || gs.Board[ni][nj].IsMine {
+							continue
+						}
+						marked[ni][nj] = true
+						if counts[ni][nj] == 0 {
+							stack = append(stack, [2]int{ni, nj})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Оставшиеся безопасные ячейки открываются по одной
+	for i := 0; i < gs.Rows; i++ {
+		for j := 0; j < gs.Cols; j++ {
+			if !marked[i][j] && !gs.Board[i][j].IsMine {
+				bbbv++
+			}
+		}
+	}
+
+	return bbbv
+}
@@ -0,0 +1,601 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxTurnTimeout наибольшее время на ход (секунды)
+const MaxTurnTimeout = 300
+
+// Очки пошагового режима, как в соревновательном сапере "Flags":
+// открывать безопасно выгодно понемногу, флаг - рискованная ставка, мина - крупный штраф
+const (
+	turnPointsCell       = 1   // За каждую открытую безопасную клетку
+	turnPointsFlag       = 5   // За флаг на мине
+	turnPenaltyWrongFlag = -3  // За флаг не на мине: флаг не ставится
+	turnPenaltyMine      = -25 // За открытую мину: мина помечается подорванной, игра продолжается
+)
+
+// TurnState очередь ходов и счет пошагового режима (turns)
+// Все игроки комнаты ходят по очереди на общем поле, каждое действие передает ход
+// Mu берется раньше GameState.Mu; room.Mu под Mu берется только на чтение
+type TurnState struct {
+	Order      []string       // Очередь ходов (playerID)
+	Current    int            // Индекс текущего игрока в Order
+	Turn       int            // Номер хода: устаревший таймер ничего не делает
+	Timeout    time.Duration  // Время на ход, 0 - без ограничения
+	TurnEndsAt time.Time      // Конец текущего хода (при ограничении времени)
+	Scores     map[string]int // Очки по игрокам (ключ: playerID)
+	Finished   bool
+	Places     map[string]int // Места после окончания игры
+	timer      *time.Timer
+	Mu         sync.Mutex
+}
+
+// currentPlayer возвращает игрока, чей сейчас ход
+// ВАЖНО: вызывающий код должен удерживать turns.Mu
+func (turns *TurnState) currentPlayer() string {
+	if len(turns.Order) == 0 {
+		return ""
+	}
+	return turns.Order[turns.Current]
+}
+
+// resetTurns создает очередь ходов новой игры: игроки комнаты в порядке ID
+// Таймер прошлой очереди не останавливается: сработав, он увидит, что очередь сменилась
+// ВАЖНО: вызывающий код должен удерживать r.Mu
+func (r *Room) resetTurns() {
+	if r.GameMode != "turns" {
+		r.Turns = nil
+		return
+	}
+
+	turns := &TurnState{
+		Order:   make([]string, 0, len(r.Players)),
+		Timeout: time.Duration(r.TurnTimeout) * time.Second,
+		Scores:  make(map[string]int),
+	}
+	for id, player := range r.Players {
+		if player.Spectator {
+			continue
+		}
+		turns.Order = append(turns.Order, id)
+		turns.Scores[id] = 0
+	}
+	sort.Strings(turns.Order)
+	r.Turns = turns
+}
+
+// startTurnLocked начинает ход текущего игрока и запускает таймер хода
+// ВАЖНО: вызывающий код должен удерживать turns.Mu
+func (s *Service) startTurnLocked(room *Room, turns *TurnState) {
+	turns.Turn++
+	if turns.timer != nil {
+		turns.timer.Stop()
+		turns.timer = nil
+	}
+	turns.TurnEndsAt = time.Time{}
+	if turns.Timeout <= 0 || turns.Finished || len(turns.Order) == 0 {
+		return
+	}
+
+	turns.TurnEndsAt = time.Now().Add(turns.Timeout)
+	turn := turns.Turn
+	turns.timer = time.AfterFunc(turns.Timeout, func() {
+		s.turnTimeout(room, turns, turn)
+	})
+}
+
+// advanceTurnLocked передает ход следующему игроку
+// ВАЖНО: вызывающий код должен удерживать turns.Mu
+func (s *Service) advanceTurnLocked(room *Room, turns *TurnState) {
+	if len(turns.Order) > 0 {
+		turns.Current = (turns.Current + 1) % len(turns.Order)
+	}
+	s.startTurnLocked(room, turns)
+}
+
+// turnTimeout пропускает ход игрока, не успевшего сходить
+func (s *Service) turnTimeout(room *Room, turns *TurnState, turn int) {
+	room.Mu.RLock()
+	current := room.Turns == turns
+	room.Mu.RUnlock()
+	if !current {
+		return
+	}
+
+	turns.Mu.Lock()
+	if turns.Finished || turns.Turn != turn {
+		turns.Mu.Unlock()
+		return
+	}
+	skipped := turns.currentPlayer()
+	s.advanceTurnLocked(room, turns)
+	turns.Mu.Unlock()
+
+	log.Printf("Комната %s: игрок %s не успел сходить, ход передан", room.ID, skipped)
+	if player := room.GetPlayer(skipped); player != nil && player.Nickname != "" {
+		s.BroadcastToAll(room, Message{
+			Type:     "chat",
+			PlayerID: skipped,
+			Nickname: player.Nickname,
+			Color:    player.Color,
+			Chat: &ChatMessage{
+				Text:     fmt.Sprintf("⌛ %s не успел сходить, ход переходит дальше", player.Nickname),
+				IsSystem: true,
+				Action:   "turn",
+			},
+		})
+	}
+	s.BroadcastTurnStatus(room)
+}
+
+// syncTurns приводит очередь ходов в соответствие с игроками комнаты:
+// ушедшие убираются, новые встают в конец очереди
+// Если ушел игрок, чей был ход, ход получает следующий. Таймер первого хода запускается здесь же
+func (s *Service) syncTurns(room *Room) {
+	room.Mu.RLock()
+	turns := room.Turns
+	present := make(map[string]bool, len(room.Players))
+	joined := make([]string, 0)
+	for id, player := range room.Players {
+		if player.Spectator {
+			continue
+		}
+		present[id] = true
+		joined = append(joined, id)
+	}
+	room.Mu.RUnlock()
+	if turns == nil {
+		return
+	}
+	sort.Strings(joined)
+
+	turns.Mu.Lock()
+	defer turns.Mu.Unlock()
+
+	current := turns.currentPlayer()
+	order := make([]string, 0, len(joined))
+	next := 0 // Позиция игрока, который ходит после текущего
+	inOrder := make(map[string]bool, len(turns.Order))
+	for i, id := range turns.Order {
+		inOrder[id] = true
+		if !present[id] {
+			continue
+		}
+		if i < turns.Current {
+			next = len(order) + 1
+		}
+		order = append(order, id)
+	}
+	for _, id := range joined {
+		if !inOrder[id] {
+			order = append(order, id)
+			if _, ok := turns.Scores[id]; !ok {
+				turns.Scores[id] = 0
+			}
+		}
+	}
+	turns.Order = order
+
+	if len(order) == 0 {
+		turns.Current = 0
+		return
+	}
+	if current != "" && present[current] {
+		for i, id := range order {
+			if id == current {
+				turns.Current = i
+			}
+		}
+		if turns.Timeout > 0 && turns.timer == nil && !turns.Finished {
+			s.startTurnLocked(room, turns)
+		}
+		return
+	}
+
+	// Игрок, чей был ход, ушел (или очередь была пуста): ход получает следующий
+	turns.Current = next % len(order)
+	if !turns.Finished {
+		s.startTurnLocked(room, turns)
+	}
+}
+
+// isCurrentTurn сейчас ход игрока playerID (пошаговый режим)
+func (s *Service) isCurrentTurn(room *Room, playerID string) bool {
+	room.Mu.RLock()
+	turns := room.Turns
+	room.Mu.RUnlock()
+	if turns == nil {
+		return false
+	}
+	turns.Mu.Lock()
+	defer turns.Mu.Unlock()
+	return !turns.Finished && turns.currentPlayer() == playerID
+}
+
+// handleTurnsClick обрабатывает ход в пошаговом режиме
+// Клики не в свой ход отклоняются. Ход передается только после действия, которое изменило поле
+func (s *Service) handleTurnsClick(room *Room, playerID string, click *CellClick) error {
+	room.Mu.RLock()
+	turns := room.Turns
+	chording := room.Chording
+	quickStart := room.QuickStart
+	player := room.Players[playerID]
+	room.Mu.RUnlock()
+	if turns == nil {
+		return nil
+	}
+	var nickname, playerColor string
+	if player != nil {
+		nickname = player.Nickname
+		playerColor = player.Color
+	}
+
+	turns.Mu.Lock()
+	if turns.Finished || turns.currentPlayer() != playerID {
+		turns.Mu.Unlock()
+		log.Printf("[GAME] handleTurnsClick: сейчас не ход игрока %s, клик отклонен", playerID)
+		return nil
+	}
+
+	gs := room.GameState
+	row, col := click.Row, click.Col
+	gs.Mu.RLock()
+	if !gs.isValidCell(row, col) {
+		gs.Mu.RUnlock()
+		turns.Mu.Unlock()
+		return fmt.Errorf("invalid coordinates")
+	}
+	firstClick := gs.Revealed == 0 && !click.Flag && !gs.Board[row][col].IsFlagged
+	gs.Mu.RUnlock()
+
+	// Быстрый старт: первая открытая клетка всегда нулевая
+	if firstClick && quickStart {
+		gs.EnsureFirstClickSafe(row, col)
+	}
+
+	gs.Mu.Lock()
+	if gs.GameOver || gs.GameWon {
+		gs.Mu.Unlock()
+		turns.Mu.Unlock()
+		return nil
+	}
+
+	cell := &gs.Board[row][col]
+	changedCells := make(map[[2]int]bool)
+	points := 0
+	acted := false
+	var text string
+	switch {
+	case click.Flag:
+		// Флаги проверяются сразу и остаются навсегда: снять флаг нельзя
+		if cell.IsRevealed || cell.IsFlagged {
+			gs.countClick(playerID, ClickRight, false)
+			break
+		}
+		acted = true
+		if cell.IsMine {
+			cell.IsFlagged = true
+			cell.FlagColor = playerColor
+			points = turnPointsFlag
+			text = fmt.Sprintf("%s поставил верный флаг на (%d, %d): %+d", nickname, row+1, col+1, points)
+			s.recordMove(room, playerID, MoveFlag, row, col, nil)
+		} else {
+			points = turnPenaltyWrongFlag
+			text = fmt.Sprintf("%s ошибся с флагом на (%d, %d): %+d", nickname, row+1, col+1, points)
+		}
+		changedCells[[2]int{row, col}] = true
+		gs.countClick(playerID, ClickRight, cell.IsMine)
+
+	case cell.IsRevealed:
+		if !chording || cell.NeighborMines == 0 {
+			gs.countClick(playerID, ClickChord, false)
+			break
+		}
+		flags := 0
+		for di := -1; di <= 1; di++ {
+			for dj := -1; dj <= 1; dj++ {
+				if gs.isValidCell(row+di, col+dj) && gs.Board[row+di][col+dj].IsFlagged {
+					flags++
+				}
+			}
+		}
+		if flags != cell.NeighborMines {
+			gs.countClick(playerID, ClickChord, false)
+			break
+		}
+		// Флаги проверены при установке, поэтому chording открывает только безопасные клетки
+		for di := -1; di <= 1; di++ {
+			for dj := -1; dj <= 1; dj++ {
+				ni, nj := row+di, col+dj
+				if !gs.isValidCell(ni, nj) || gs.Board[ni][nj].IsRevealed || gs.Board[ni][nj].IsFlagged {
+					continue
+				}
+				gs.Board[ni][nj].IsRevealed = true
+				gs.Revealed++
+				changedCells[[2]int{ni, nj}] = true
+				if gs.Board[ni][nj].NeighborMines == 0 {
+					s.revealNeighbors(room, ni, nj, changedCells)
+				}
+			}
+		}
+		gs.countClick(playerID, ClickChord, len(changedCells) > 0)
+		if len(changedCells) == 0 {
+			break
+		}
+		acted = true
+		points = len(changedCells) * turnPointsCell
+		text = fmt.Sprintf("%s открыл %d кл. вокруг (%d, %d): %+d", nickname, len(changedCells), row+1, col+1, points)
+		s.recordMove(room, playerID, MoveChord, row, col, changedCells)
+
+	case cell.IsFlagged:
+		gs.countClick(playerID, ClickLeft, false)
+
+	case cell.IsMine:
+		acted = true
+		cell.IsFlagged = true
+		cell.Exploded = true
+		points = turnPenaltyMine
+		changedCells[[2]int{row, col}] = true
+		text = fmt.Sprintf("%s подорвался на мине на (%d, %d) 💣 %+d", nickname, row+1, col+1, points)
+		gs.countClick(playerID, ClickLeft, true)
+		s.recordMove(room, playerID, MoveReveal, row, col, changedCells)
+
+	default:
+		acted = true
+		cell.IsRevealed = true
+		gs.Revealed++
+		changedCells[[2]int{row, col}] = true
+		if cell.NeighborMines == 0 {
+			s.revealNeighbors(room, row, col, changedCells)
+		}
+		points = len(changedCells) * turnPointsCell
+		text = fmt.Sprintf("%s открыл %d кл. с (%d, %d): %+d", nickname, len(changedCells), row+1, col+1, points)
+		gs.countClick(playerID, ClickLeft, true)
+		s.recordMove(room, playerID, MoveReveal, row, col, changedCells)
+	}
+
+	if !acted {
+		gs.Mu.Unlock()
+		turns.Mu.Unlock()
+		return nil
+	}
+
+	turns.Scores[playerID] += points
+	if gs.Revealed == gs.Rows*gs.Cols-gs.Mines {
+		gs.GameWon = true
+		turns.Finished = true
+		if turns.timer != nil {
+			turns.timer.Stop()
+			turns.timer = nil
+		}
+	} else {
+		s.advanceTurnLocked(room, turns)
+	}
+	finished := turns.Finished
+	revealed := gs.Revealed
+	hintsUsed := gs.HintsUsed
+	gs.Mu.Unlock()
+	turns.Mu.Unlock()
+
+	room.Mu.Lock()
+	started := room.StartTime == nil
+	if started {
+		now := time.Now()
+		room.StartTime = &now
+	}
+	room.Mu.Unlock()
+	if started {
+		s.startClock(room)
+	}
+
+	if nickname != "" {
+		s.BroadcastToAll(room, Message{
+			Type:     "chat",
+			PlayerID: playerID,
+			Nickname: nickname,
+			Color:    playerColor,
+			Chat: &ChatMessage{
+				Text:     text,
+				IsSystem: true,
+				Action:   "turn",
+				Row:      row,
+				Col:      col,
+			},
+		})
+	}
+
+	if finished {
+		s.finishTurns(room, turns)
+	}
+
+	// Флаги не передаются обновлениями клеток, поэтому после флага отправляется все поле
+	// (BroadcastGameState отправляет и очередь ходов)
+	if click.Flag || finished {
+		s.BroadcastGameState(room)
+		return nil
+	}
+	s.BroadcastCellUpdates(room, changedCells, false, false, revealed, hintsUsed, "", "")
+	s.BroadcastTurnStatus(room)
+	return nil
+}
+
+// turnStandings возвращает игроков по убыванию очков
+// ВАЖНО: вызывающий код должен удерживать turns.Mu
+func (turns *TurnState) turnStandings() []string {
+	ids := make([]string, 0, len(turns.Scores))
+	for id := range turns.Scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if turns.Scores[ids[i]] != turns.Scores[ids[j]] {
+			return turns.Scores[ids[i]] > turns.Scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// finishTurns расставляет места по очкам и записывает результаты
+// Побеждает игрок с наибольшим счетом (при равенстве очков места делятся)
+func (s *Service) finishTurns(room *Room, turns *TurnState) {
+	room.Mu.RLock()
+	players := make(map[string]Player, len(room.Players))
+	for id, p := range room.Players {
+		if !p.Spectator {
+			players[id] = *p
+		}
+	}
+	botGame := room.hasBots() || room.GameState.BotsPlayed
+	cols, rows, mines := room.Cols, room.Rows, room.Mines
+	chording := room.Chording
+	quickStart := room.QuickStart
+	roomID := room.ID
+	creatorID := room.CreatorID
+	hasCustomSeed := room.HasCustomSeed
+	var gameTime float64
+	if room.StartTime != nil {
+		gameTime = time.Since(*room.StartTime).Seconds()
+	}
+	room.Mu.RUnlock()
+
+	turns.Mu.Lock()
+	turns.Places = make(map[string]int, len(turns.Scores))
+	standings := turns.turnStandings()
+	for i, id := range standings {
+		place := i + 1
+		if i > 0 && turns.Scores[id] == turns.Scores[standings[i-1]] {
+			place = turns.Places[standings[i-1]]
+		}
+		turns.Places[id] = place
+	}
+	scores := make(map[string]int, len(turns.Scores))
+	places := make(map[string]int, len(turns.Places))
+	for id, score := range turns.Scores {
+		scores[id] = score
+		places[id] = turns.Places[id]
+	}
+	turns.Mu.Unlock()
+
+	if len(standings) > 0 {
+		winner := players[standings[0]]
+		if winner.Nickname != "" {
+			s.BroadcastToAll(room, Message{
+				Type:     "chat",
+				PlayerID: winner.ID,
+				Nickname: winner.Nickname,
+				Color:    winner.Color,
+				Chat: &ChatMessage{
+					Text:     fmt.Sprintf("🏆 Поле открыто! Побеждает %s со счетом %d", winner.Nickname, scores[winner.ID]),
+					IsSystem: true,
+					Action:   "turn",
+				},
+			})
+		}
+	}
+
+	if botGame || s.profileHandler == nil {
+		if botGame {
+			log.Printf("Игра в комнате %s сыграна с ботами, результат не записывается", roomID)
+		}
+		return
+	}
+
+	gs := room.GameState
+	gs.Mu.RLock()
+	replay := gs.BuildReplay()
+	threeBV := gs.Calculate3BV()
+	seed := gs.Seed
+	clickStats := make(map[string]ClickStats, len(gs.ClickStats))
+	for id, stats := range gs.ClickStats {
+		clickStats[id] = *stats
+	}
+	gs.Mu.RUnlock()
+
+	participants := make([]GameParticipant, 0, len(players))
+	for id, p := range players {
+		if p.UserID == 0 {
+			continue
+		}
+		score := scores[id]
+		participants = append(participants, GameParticipant{
+			UserID:   p.UserID,
+			Nickname: p.Nickname,
+			Color:    p.Color,
+			Score:    &score,
+		})
+	}
+
+	go func() {
+		for id, p := range players {
+			if p.UserID == 0 {
+				continue
+			}
+			details := GameResultDetails{
+				Replay:      replay,
+				GameMode:    "turns",
+				FlagsPlaced: replay.FlagsPlacedBy(id),
+				ThreeBV:     threeBV,
+				Clicks:      clickStats[id],
+				Placement:   places[id],
+				Score:       scores[id],
+				Players:     len(players),
+			}
+			unlocked, err := s.profileHandler.RecordGameResult(p.UserID, cols, rows, mines, gameTime, places[id] == 1, chording, quickStart, roomID, seed, hasCustomSeed, creatorID, participants, details)
+			if err != nil {
+				log.Printf("Ошибка записи результата пошаговой игры: %v", err)
+				continue
+			}
+			s.announceAchievements(room, id, p.Nickname, p.Color, unlocked)
+		}
+		if err := s.roomManager.SaveRoom(room); err != nil {
+			log.Printf("Предупреждение: не удалось сохранить комнату %s после игры: %v", roomID, err)
+		}
+	}()
+}
+
+// BroadcastTurnStatus отправляет всем игрокам комнаты очередь ходов и счет
+func (s *Service) BroadcastTurnStatus(room *Room) {
+	binaryData, err := s.encodeRoomTurnStatus(room)
+	if err != nil || binaryData == nil {
+		if err != nil {
+			log.Printf("[WS OUT] Ошибка кодирования turnStatus: %v", err)
+		}
+		return
+	}
+	s.broadcastBinary(room, binaryData, "turnStatus")
+}
+
+// sendTurnStatusToPlayer отправляет подключившемуся игроку очередь ходов и счет
+func (s *Service) sendTurnStatusToPlayer(room *Room, player WSPlayer) {
+	binaryData, err := s.encodeRoomTurnStatus(room)
+	if err != nil || binaryData == nil {
+		if err != nil {
+			log.Printf("[WS OUT] Ошибка кодирования turnStatus: %v", err)
+		}
+		return
+	}
+	if err := writeBinary(player, binaryData); err != nil {
+		log.Printf("[WS OUT] Ошибка отправки turnStatus игроку: %v", err)
+	}
+}
+
+// encodeRoomTurnStatus кодирует очередь ходов комнаты (nil, если режим не пошаговый)
+func (s *Service) encodeRoomTurnStatus(room *Room) ([]byte, error) {
+	room.Mu.RLock()
+	turns := room.Turns
+	players := make(map[string]Player, len(room.Players))
+	for id, p := range room.Players {
+		players[id] = *p
+	}
+	room.Mu.RUnlock()
+	if turns == nil {
+		return nil, nil
+	}
+	return EncodeTurnStatusProtobuf(turns, players, time.Now())
+}
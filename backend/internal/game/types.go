@@ -19,9 +19,10 @@ type SafeCell struct {
 
 // CellHint представляет подсказку для ячейки
 type CellHint struct {
-	Row  int    `json:"r"`
-	Col  int    `json:"c"`
-	Type string `json:"t"` // "MINE", "SAFE", "UNKNOWN"
+	Row         int     `json:"r"`
+	Col         int     `json:"c"`
+	Type        string  `json:"t"`           // "MINE", "SAFE", "UNKNOWN", "PROBABILITY"
+	Probability float64 `json:"p,omitempty"` // Точная вероятность мины (режим training)
 }
 
 // Cell представляет ячейку игрового поля
@@ -31,6 +32,7 @@ type Cell struct {
 	IsFlagged     bool   `json:"f"`
 	NeighborMines int    `json:"n"`
 	FlagColor     string `json:"fc,omitempty"` // Цвет игрока, который поставил флаг
+	Exploded      bool   `json:"ex,omitempty"` // Подорванная мина (режим жизней): остается закрытой с флагом
 }
 
 // GameState представляет состояние игры
@@ -44,11 +46,20 @@ type GameState struct {
 	GameWon       bool        `json:"gw"`
 	Revealed      int         `json:"rv"`
 	HintsUsed     int         `json:"hu"`              // Количество использованных подсказок
+	NoGuess       bool        `json:"-"`               // Поле сгенерировано и проверено как решаемое без угадываний
 	SafeCells     []SafeCell  `json:"sc,omitempty"`      // Безопасные ячейки для режима без угадываний
 	CellHints     []CellHint  `json:"hints,omitempty"`   // Подсказки для ячеек
 	LoserPlayerID string      `json:"lpid,omitempty"`
 	LoserNickname string      `json:"ln,omitempty"`
 	FlagSetInfo   map[int]FlagInfo // Информация об установке флага (ключ: row*cols + col)
+	Moves         []Move           `json:"-"` // Запись ходов для воспроизведения
+	ClickStats    map[string]*ClickStats `json:"-"` // Статистика кликов по игрокам (ключ: playerID)
+	Contributions map[string]*Contribution `json:"-"` // Вклад игроков в совместную игру (ключ: playerID)
+	BotsPlayed    bool             `json:"-"` // В игре ходил бот: результат не записывается в рейтинг
+	Lives         int              `json:"-"` // Жизней в начале игры (режим жизней), 0 - первая мина заканчивает игру
+	PerPlayerLives bool            `json:"-"` // Жизни у каждого игрока свои
+	LivesLeft     int              `json:"-"` // Осталось общих жизней
+	LivesLost     map[string]int   `json:"-"` // Потерянные жизни по игрокам (ключ: playerID)
 	Mu            sync.RWMutex     // Экспортировано для доступа из main.go
 }
 
@@ -60,9 +71,17 @@ type Room struct {
 	Rows          int                `json:"rows"`
 	Cols          int                `json:"cols"`
 	Mines         int                `json:"mines"`
-	GameMode      string             `json:"gameMode"`  // "classic", "training", "fair"
+	GameMode      string             `json:"gameMode"`  // "classic", "training", "fair", "race", "versus", "turns"
 	QuickStart    bool               `json:"quickStart"` // Быстрый старт - первая клетка всегда нулевая
 	Chording      bool               `json:"chording"`  // Chording - открытие соседних клеток при клике на открытую клетку с цифрой
+	NoGuess       bool               `json:"noGuess"`   // Без угадываний - поле classic решается логически с первого клика
+	Lives         int                `json:"lives"`     // Жизни: мина стоит жизни вместо конца игры (0 или 1 - обычная игра)
+	PerPlayerLives bool              `json:"perPlayerLives"` // Жизни у каждого игрока свои, а не общие
+	ClockMode     string             `json:"clockMode"` // Игра на время: "", "deadline" или "score"
+	TimeLimit     int                `json:"timeLimit"` // Ограничение времени в секундах (0 - без ограничения)
+	TurnTimeout   int                `json:"turnTimeout"` // Время на ход в режиме turns в секундах (0 - без ограничения)
+	NoSpectators  bool               `json:"noSpectators"` // Зрители запрещены
+	MaxSpectators int                `json:"maxSpectators"` // Наибольшее число зрителей (0 - без ограничения)
 	CreatorID     int                `json:"creatorId"`
 	HasCustomSeed bool               `json:"-"`        // Флаг: был ли seed указан пользователем явно
 	Players       map[string]*Player `json:"-"`        // Используется только в WebSocket контексте
@@ -71,6 +90,12 @@ type Room struct {
 	StartTime     *time.Time         `json:"-"`        // Время начала игры
 	deleteTimer   *time.Timer        // Таймер для отложенного удаления
 	deleteTimerMu sync.Mutex         // Мьютекс для безопасной работы с таймером
+	bots          map[string]*Bot    // Боты комнаты (ключ: playerID), защищено Mu
+	Race          *RaceState         `json:"-"`        // Текущая гонка (режим race), защищено Mu
+	Versus        *VersusState       `json:"-"`        // Поля команд (режим versus), защищено Mu
+	Clock         *RoomClock         `json:"-"`        // Часы идущей игры, защищено Mu
+	Turns         *TurnState         `json:"-"`        // Очередь ходов и счет (режим turns), защищено Mu
+	States        *StateLog          `json:"-"`        // Версии общего поля, защищено States.Mu
 	Mu            sync.RWMutex        // Экспортировано для доступа из main.go
 }
 
@@ -80,6 +105,9 @@ type Player struct {
 	UserID   int    `json:"userId,omitempty"`
 	Nickname string `json:"nickname"`
 	Color    string `json:"color"`
+	IsBot    bool   `json:"isBot,omitempty"` // Бот на сервере, без WebSocket соединения
+	Team     int    `json:"team,omitempty"`  // Команда в режиме versus (1 или 2), 0 - зритель
+	Spectator bool  `json:"spectator,omitempty"` // Подключился зрителем: видит игру, но не ходит
 }
 
 // GameStateEncoder кодирует GameState в бинарный формат
@@ -0,0 +1,275 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// stateHistorySize сколько последних изменений поля хранится для досылки отставшим клиентам
+const stateHistorySize = 256
+
+// stateQueueSize сколько изменений поля может ждать отправки одному игроку
+const stateQueueSize = 64
+
+// versionFieldNumber номер поля version в WebSocketMessage
+const versionFieldNumber = 15
+
+// errStateQueueFull игрок не успевает получать изменения поля
+var errStateQueueFull = errors.New("очередь отправки переполнена")
+
+// stateEntry закодированное изменение поля с его версией
+type stateEntry struct {
+	version uint64
+	data    []byte
+}
+
+// stateSend сообщение в очереди отправки игроку
+type stateSend struct {
+	player WSPlayer
+	data   []byte
+}
+
+// stateQueue очередь отправки изменений поля одному игроку
+// Запись в сокет идет в отдельной горутине, поэтому медленный клиент не задерживает остальных и не держит StateLog.Mu
+type stateQueue struct {
+	ch chan stateSend
+}
+
+// newStateQueue создает очередь и запускает горутину отправки
+func newStateQueue(playerID string) *stateQueue {
+	q := &stateQueue{ch: make(chan stateSend, stateQueueSize)}
+	go q.run(playerID)
+	return q
+}
+
+// run отправляет сообщения из очереди по порядку
+// Сообщение с ошибкой записи остается в outbox сессии и повторится при возобновлении
+func (q *stateQueue) run(playerID string) {
+	for msg := range q.ch {
+		if err := writeBinary(msg.player, msg.data); err != nil {
+			log.Printf("[WS OUT] Ошибка отправки состояния игроку %s: %v", playerID, err)
+		}
+	}
+}
+
+// StateLog версии общего поля комнаты
+// Изменение кодируется, получает версию и ставится в очереди игроков под Mu, поэтому каждый клиент получает изменения
+// в порядке версий, а полное состояние не может прийти позже более нового изменения
+type StateLog struct {
+	Mu        sync.Mutex
+	Version   uint64                 // Версия последнего изменения
+	history   []stateEntry           // Последние изменения (кольцевой буфер)
+	delivered map[string]uint64      // Последняя версия, поставленная в очередь игрока
+	queues    map[string]*stateQueue // Очереди отправки по ID игрока
+}
+
+// newStateLog создает журнал версий поля
+func newStateLog() *StateLog {
+	return &StateLog{delivered: make(map[string]uint64), queues: make(map[string]*stateQueue)}
+}
+
+// enqueueLocked ставит сообщение в очередь отправки игроку, не дожидаясь записи в сокет
+// ВАЖНО: вызывающий код должен удерживать Mu
+func (l *StateLog) enqueueLocked(player WSPlayer, data []byte) error {
+	id := player.GetID()
+	q := l.queues[id]
+	if q == nil {
+		q = newStateQueue(id)
+		l.queues[id] = q
+	}
+	select {
+	case q.ch <- stateSend{player: player, data: data}:
+		return nil
+	default:
+		return errStateQueueFull
+	}
+}
+
+// dropPlayerLocked перестает отслеживать игрока и останавливает его очередь
+// ВАЖНО: вызывающий код должен удерживать Mu
+func (l *StateLog) dropPlayerLocked(playerID string) {
+	delete(l.delivered, playerID)
+	if q := l.queues[playerID]; q != nil {
+		close(q.ch)
+		delete(l.queues, playerID)
+	}
+}
+
+// Close останавливает очереди отправки всех игроков (при удалении комнаты)
+func (l *StateLog) Close() {
+	l.Mu.Lock()
+	defer l.Mu.Unlock()
+	for id := range l.queues {
+		l.dropPlayerLocked(id)
+	}
+}
+
+// appendVersion дописывает к закодированному WebSocketMessage версию поля
+func appendVersion(data []byte, version uint64) []byte {
+	out := make([]byte, len(data), len(data)+protowire.SizeTag(versionFieldNumber)+protowire.SizeVarint(version))
+	copy(out, data)
+	out = protowire.AppendTag(out, versionFieldNumber, protowire.VarintType)
+	return protowire.AppendVarint(out, version)
+}
+
+// publishState кодирует изменение поля, присваивает ему следующую версию и ставит в очереди всех игроков комнаты
+// Игроку, пропустившему версии, вместо изменения досылаются все недостающие или полный снимок
+func (s *Service) publishState(room *Room, kind string, encode func() ([]byte, error)) error {
+	states := room.States
+	states.Mu.Lock()
+	defer states.Mu.Unlock()
+
+	data, err := encode()
+	if err != nil {
+		return err
+	}
+	states.Version++
+	entry := stateEntry{version: states.Version, data: appendVersion(data, states.Version)}
+	states.history = append(states.history, entry)
+	if len(states.history) > stateHistorySize {
+		states.history = append(states.history[:0], states.history[len(states.history)-stateHistorySize:]...)
+	}
+
+	room.Mu.RLock()
+	playerIDs := make([]string, 0, len(room.Players))
+	for id, player := range room.Players {
+		if !player.IsBot {
+			playerIDs = append(playerIDs, id)
+		}
+	}
+	room.Mu.RUnlock()
+
+	log.Printf("[WS OUT] %s v%d: отправка всем игрокам (количество=%d), размер=%d байт", kind, entry.version, len(playerIDs), len(entry.data))
+
+	present := make(map[string]bool, len(playerIDs))
+	for _, id := range playerIDs {
+		present[id] = true
+		s.deliverStateLocked(room, id, entry, kind)
+	}
+	// Ушедшие игроки больше не отслеживаются
+	for id := range states.delivered {
+		if !present[id] {
+			states.dropPlayerLocked(id)
+		}
+	}
+	for id := range states.queues {
+		if !present[id] {
+			states.dropPlayerLocked(id)
+		}
+	}
+	return nil
+}
+
+// deliverStateLocked ставит в очередь игрока изменение поля, если он получил все предыдущие версии
+// ВАЖНО: вызывающий код должен удерживать room.States.Mu
+func (s *Service) deliverStateLocked(room *Room, playerID string, entry stateEntry, kind string) {
+	wsPlayer := s.wsManager.GetWSPlayer(playerID)
+	if wsPlayer == nil {
+		log.Printf("[WS OUT] Игрок %s: wsPlayer не найден, пропуск отправки %s", playerID, kind)
+		return
+	}
+
+	states := room.States
+	var err error
+	last, known := states.delivered[playerID]
+	if !known || last+1 == entry.version {
+		err = states.enqueueLocked(wsPlayer, entry.data)
+	} else {
+		log.Printf("[STATE] Игрок %s пропустил версии %d..%d, досылка", playerID, last+1, entry.version-1)
+		err = s.sendStateSinceLocked(room, wsPlayer, last)
+	}
+	if err != nil {
+		// Версия не считается доставленной: со следующим изменением игроку дошлется пропущенное
+		log.Printf("[WS OUT] Ошибка постановки в очередь %s v%d игроку %s: %v", kind, entry.version, playerID, err)
+		return
+	}
+	states.delivered[playerID] = entry.version
+}
+
+// sendStateSinceLocked ставит в очередь игрока все изменения поля после версии since
+// Если часть из них уже вытеснена из буфера, отправляется полный снимок текущей версии
+// Поставленные изменения сразу отмечаются доставленными, чтобы при переполнении очереди не отправить их повторно
+// ВАЖНО: вызывающий код должен удерживать room.States.Mu
+func (s *Service) sendStateSinceLocked(room *Room, player WSPlayer, since uint64) error {
+	states := room.States
+	if since == states.Version {
+		return nil
+	}
+	if since < states.Version && len(states.history) > 0 && states.history[0].version <= since+1 {
+		for _, entry := range states.history {
+			if entry.version <= since {
+				continue
+			}
+			if err := states.enqueueLocked(player, entry.data); err != nil {
+				return err
+			}
+			states.delivered[player.GetID()] = entry.version
+		}
+		return nil
+	}
+	return s.sendSnapshotLocked(room, player)
+}
+
+// sendSnapshotLocked ставит в очередь игрока полное состояние поля с текущей версией
+// ВАЖНО: вызывающий код должен удерживать room.States.Mu
+func (s *Service) sendSnapshotLocked(room *Room, player WSPlayer) error {
+	states := room.States
+	binaryData, err := EncodeGameStateProtobuf(room.GameState)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования gameState: %w", err)
+	}
+	if err := states.enqueueLocked(player, appendVersion(binaryData, states.Version)); err != nil {
+		return err
+	}
+	states.delivered[player.GetID()] = states.Version
+	return nil
+}
+
+// sendSnapshot отправляет игроку полное состояние поля с текущей версией
+func (s *Service) sendSnapshot(room *Room, player WSPlayer) error {
+	room.States.Mu.Lock()
+	defer room.States.Mu.Unlock()
+	return s.sendSnapshotLocked(room, player)
+}
+
+// AckState обрабатывает подтверждение версии поля от клиента
+// resync - клиент обнаружил пропуск: ему досылаются изменения после version или полный снимок
+func (s *Service) AckState(room *Room, playerID string, version uint64, resync bool) {
+	wsPlayer := s.wsManager.GetWSPlayer(playerID)
+	if wsPlayer == nil {
+		return
+	}
+
+	// В гонке и матче команд у игроков свои поля без общей версии
+	if room.GameMode == "race" || room.GameMode == "versus" {
+		if resync {
+			s.SendGameStateToPlayer(room, wsPlayer)
+		}
+		return
+	}
+
+	states := room.States
+	states.Mu.Lock()
+	defer states.Mu.Unlock()
+
+	// Версия новее известной серверу бывает у клиента, пережившего перезапуск сервера
+	if !resync && version <= states.Version {
+		return
+	}
+	log.Printf("[STATE] Игрок %s запросил досылку после версии %d (текущая %d)", playerID, version, states.Version)
+	var err error
+	if version > states.Version {
+		err = s.sendSnapshotLocked(room, wsPlayer)
+	} else {
+		err = s.sendStateSinceLocked(room, wsPlayer, version)
+	}
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка досылки состояния игроку %s: %v", playerID, err)
+		return
+	}
+	states.delivered[playerID] = states.Version
+}
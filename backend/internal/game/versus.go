@@ -0,0 +1,474 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// versusTeams число команд в режиме versus
+const versusTeams = 2
+
+// VersusTeam поле команды и ее результат
+type VersusTeam struct {
+	Board     *GameState
+	Deaths    int // Подрывы: после подрыва поле команды начинается заново
+	Cleared   bool
+	ClearTime float64 // Секунды от первого клика матча
+}
+
+// VersusState матч команд (режим versus): каждая команда вместе играет свою копию поля
+// с одним seed, побеждает команда, первой открывшая все безопасные клетки
+// Команда игрока хранится в Player.Team, игроки без команды смотрят матч
+type VersusState struct {
+	Seed       string
+	Teams      [versusTeams]*VersusTeam // Индекс - номер команды минус 1
+	WinnerTeam int                      // 0 - матч идет
+	Mu         sync.Mutex
+}
+
+// newVersusState создает поля команд
+func newVersusState(rows, cols, mines int, seed string) *VersusState {
+	versus := &VersusState{Seed: seed}
+	for i := range versus.Teams {
+		versus.Teams[i] = &VersusTeam{Board: newSeededBoard(rows, cols, mines, "versus", seed)}
+	}
+	return versus
+}
+
+// resetVersus пересоздает поля команд после создания или сброса игры
+// При переходе в режим versus игроки распределяются по командам
+// ВАЖНО: вызывающий код должен удерживать r.Mu
+func (r *Room) resetVersus() {
+	if r.GameMode != "versus" {
+		r.Versus = nil
+		for _, player := range r.Players {
+			player.Team = 0
+		}
+		return
+	}
+
+	wasVersus := r.Versus != nil
+	r.Versus = newVersusState(r.Rows, r.Cols, r.Mines, r.GameState.Seed)
+	if !wasVersus {
+		for _, player := range r.Players {
+			player.Team = 0
+		}
+		for _, player := range r.Players {
+			if !player.Spectator {
+				player.Team = r.smallerTeam()
+			}
+		}
+	}
+}
+
+// smallerTeam возвращает команду с наименьшим числом игроков
+// ВАЖНО: вызывающий код должен удерживать r.Mu
+func (r *Room) smallerTeam() int {
+	var counts [versusTeams + 1]int
+	for _, player := range r.Players {
+		counts[player.Team]++
+	}
+	best := 1
+	for team := 2; team <= versusTeams; team++ {
+		if counts[team] < counts[best] {
+			best = team
+		}
+	}
+	return best
+}
+
+// SetTeam переводит игрока в команду team (0 - зрители)
+// Во время матча сменить команду нельзя, можно только уйти в зрители
+func (s *Service) SetTeam(room *Room, playerID string, team int) error {
+	if team < 0 || team > versusTeams {
+		return fmt.Errorf("invalid team")
+	}
+
+	room.Mu.Lock()
+	if room.GameMode != "versus" || room.Versus == nil {
+		room.Mu.Unlock()
+		return fmt.Errorf("teams are only available in versus mode")
+	}
+	player := room.Players[playerID]
+	if player == nil {
+		room.Mu.Unlock()
+		return fmt.Errorf("player not found")
+	}
+	if player.Spectator && team != 0 {
+		room.Mu.Unlock()
+		return fmt.Errorf("spectators can't join a team")
+	}
+	room.Versus.Mu.Lock()
+	matchRunning := room.StartTime != nil && room.Versus.WinnerTeam == 0
+	room.Versus.Mu.Unlock()
+	if team != 0 && matchRunning {
+		room.Mu.Unlock()
+		return fmt.Errorf("team can't be changed during a match")
+	}
+	player.Team = team
+	room.Mu.Unlock()
+
+	log.Printf("Игрок %s в комнате %s перешел в команду %d", playerID, room.ID, team)
+	s.BroadcastPlayerList(room)
+	if wsPlayer := s.wsManager.GetWSPlayer(playerID); wsPlayer != nil {
+		s.sendVersusView(room, wsPlayer)
+	}
+	return nil
+}
+
+// teamBoard возвращает поле команды игрока в идущем матче или nil
+func (s *Service) teamBoard(room *Room, playerID string) *GameState {
+	room.Mu.RLock()
+	versus := room.Versus
+	team := 0
+	if player := room.Players[playerID]; player != nil {
+		team = player.Team
+	}
+	room.Mu.RUnlock()
+	if versus == nil || team == 0 {
+		return nil
+	}
+
+	versus.Mu.Lock()
+	defer versus.Mu.Unlock()
+	if versus.WinnerTeam != 0 {
+		return nil
+	}
+	return versus.Teams[team-1].Board
+}
+
+// handleVersusClick обрабатывает клик игрока по полю его команды
+// Подрыв не заканчивает матч: поле команды начинается заново, подрыв засчитывается
+func (s *Service) handleVersusClick(room *Room, playerID string, click *CellClick) error {
+	room.Mu.Lock()
+	versus := room.Versus
+	player := room.Players[playerID]
+	if versus == nil || player == nil || player.Team == 0 {
+		room.Mu.Unlock()
+		return nil
+	}
+	team := player.Team
+	nickname, playerColor := player.Nickname, player.Color
+	chording := room.Chording
+	rows, cols, mines := room.Rows, room.Cols, room.Mines
+	firstClick := room.StartTime == nil
+	if firstClick {
+		now := time.Now()
+		room.StartTime = &now
+	}
+	startTime := *room.StartTime
+	room.Mu.Unlock()
+	if firstClick {
+		s.startClock(room)
+	}
+
+	versus.Mu.Lock()
+	if versus.WinnerTeam != 0 {
+		versus.Mu.Unlock()
+		return nil
+	}
+	t := versus.Teams[team-1]
+	gs := t.Board
+	result, err := s.applyBoardClick(room, gs, playerID, playerColor, click, chording)
+	if err != nil {
+		versus.Mu.Unlock()
+		return err
+	}
+
+	if result.exploded {
+		t.Deaths++
+		t.Board = newSeededBoard(rows, cols, mines, "versus", versus.Seed)
+		t.Board.ClickStats = gs.ClickStats // Клики считаются за весь матч
+	} else if result.cleared {
+		t.Cleared = true
+		t.ClearTime = time.Since(startTime).Seconds()
+		versus.WinnerTeam = team
+		for i, other := range versus.Teams {
+			other.Board.Mu.Lock()
+			if i == team-1 {
+				other.Board.GameWon = true
+			} else {
+				other.Board.GameOver = true
+			}
+			other.Board.Mu.Unlock()
+		}
+	}
+	board := t.Board
+	versus.Mu.Unlock()
+
+	switch {
+	case result.exploded:
+		log.Printf("Матч в комнате %s: игрок %s (команда %d) подорвался на (%d, %d), поле команды начинается заново", room.ID, playerID, team, click.Row, click.Col)
+		s.sendTeamBoard(room, team, board)
+		if nickname != "" {
+			s.BroadcastToAll(room, Message{
+				Type:     "chat",
+				PlayerID: playerID,
+				Nickname: nickname,
+				Color:    playerColor,
+				Chat: &ChatMessage{
+					Text:     fmt.Sprintf("%s подорвался, команда %d начинает заново 💣", nickname, team),
+					IsSystem: true,
+					Action:   "versus",
+					Row:      click.Row,
+					Col:      click.Col,
+				},
+			})
+		}
+	case result.cleared:
+		s.finishVersus(room, versus, team)
+	case result.flagDelta != 0:
+		s.sendTeamBoard(room, team, board)
+	case len(result.changedCells) > 0:
+		s.sendTeamUpdates(room, team, board, result.changedCells)
+	}
+
+	s.BroadcastVersusStatus(room)
+	return nil
+}
+
+// finishVersus объявляет победу команды и записывает результаты матча
+func (s *Service) finishVersus(room *Room, versus *VersusState, winnerTeam int) {
+	versus.Mu.Lock()
+	clearTime := versus.Teams[winnerTeam-1].ClearTime
+	versus.Mu.Unlock()
+
+	log.Printf("Матч в комнате %s завершен: победила команда %d за %.2f сек", room.ID, winnerTeam, clearTime)
+	s.BroadcastGameState(room)
+	s.BroadcastToAll(room, Message{
+		Type: "chat",
+		Chat: &ChatMessage{
+			Text:     fmt.Sprintf("🏁 Команда %d победила за %.2f сек", winnerTeam, clearTime),
+			IsSystem: true,
+			Action:   "versus",
+		},
+	})
+
+	s.recordVersusResults(room, versus)
+}
+
+// recordVersusResults записывает результат матча каждому игроку команд
+// Матчи с ботами в рейтинг не идут
+func (s *Service) recordVersusResults(room *Room, versus *VersusState) {
+	if s.profileHandler == nil {
+		return
+	}
+
+	type member struct {
+		playerID string
+		userID   int
+		nickname string
+		color    string
+		team     int
+	}
+	room.Mu.RLock()
+	botGame := room.hasBots()
+	cols, rows, mines := room.Cols, room.Rows, room.Mines
+	chording := room.Chording
+	roomID := room.ID
+	creatorID := room.CreatorID
+	hasCustomSeed := room.HasCustomSeed
+	members := make([]member, 0)
+	participants := make([]GameParticipant, 0)
+	players := 0
+	for id, p := range room.Players {
+		if p.Team != 0 && !p.IsBot {
+			players++
+		}
+		if p.Team == 0 || p.UserID == 0 {
+			continue
+		}
+		members = append(members, member{playerID: id, userID: p.UserID, nickname: p.Nickname, color: p.Color, team: p.Team})
+		participants = append(participants, GameParticipant{
+			UserID:   p.UserID,
+			Nickname: p.Nickname,
+			Color:    p.Color,
+		})
+	}
+	room.Mu.RUnlock()
+
+	if botGame {
+		log.Printf("Матч в комнате %s сыгран с ботами, результат не записывается", roomID)
+		return
+	}
+
+	versus.Mu.Lock()
+	winnerTeam := versus.WinnerTeam
+	gameTime := versus.Teams[winnerTeam-1].ClearTime
+	seed := versus.Seed
+	details := make([]GameResultDetails, len(members))
+	for i, m := range members {
+		board := versus.Teams[m.team-1].Board
+		board.Mu.RLock()
+		replay := board.BuildReplay()
+		// Время - общее время команды, а не личное: режим versus и число игроков
+		// исключают запись из таблиц рекордов по времени
+		details[i] = GameResultDetails{
+			Replay:      replay,
+			GameMode:    "versus",
+			Players:     players,
+			FlagsPlaced: replay.FlagsPlacedBy(m.playerID),
+			ThreeBV:     board.Calculate3BV(),
+			Clicks:      board.PlayerClickStats(m.playerID),
+			Placement:   1,
+		}
+		board.Mu.RUnlock()
+		if m.team != winnerTeam {
+			details[i].Placement = 2
+		}
+	}
+	versus.Mu.Unlock()
+
+	go func() {
+		for i, m := range members {
+			// Стартовая область открыта для обеих команд, поэтому матч записывается как игра с QuickStart
+			unlocked, err := s.profileHandler.RecordGameResult(m.userID, cols, rows, mines, gameTime, m.team == winnerTeam, chording, true, roomID, seed, hasCustomSeed, creatorID, participants, details[i])
+			if err != nil {
+				log.Printf("Ошибка записи результата матча: %v", err)
+				continue
+			}
+			s.announceAchievements(room, m.playerID, m.nickname, m.color, unlocked)
+		}
+	}()
+}
+
+// teamAudience возвращает игроков, которым видно поле команды: сама команда и зрители
+func (s *Service) teamAudience(room *Room, team int) []string {
+	room.Mu.RLock()
+	defer room.Mu.RUnlock()
+	playerIDs := make([]string, 0, len(room.Players))
+	for id, player := range room.Players {
+		if !player.IsBot && (player.Team == team || player.Team == 0) {
+			playerIDs = append(playerIDs, id)
+		}
+	}
+	return playerIDs
+}
+
+// sendTeamBoard отправляет поле команды ее игрокам и зрителям
+func (s *Service) sendTeamBoard(room *Room, team int, gs *GameState) {
+	binaryData, err := EncodeTeamGameStateProtobuf(gs, team)
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка кодирования gameState команды %d: %v", team, err)
+		return
+	}
+	for _, id := range s.teamAudience(room, team) {
+		s.sendToPlayer(id, binaryData, "gameState")
+	}
+}
+
+// sendTeamUpdates отправляет измененные клетки поля команды ее игрокам и зрителям
+func (s *Service) sendTeamUpdates(room *Room, team int, gs *GameState, changedCells map[[2]int]bool) {
+	updates := CollectBoardUpdates(gs, room.GameMode, changedCells)
+	gs.Mu.RLock()
+	revealed := gs.Revealed
+	gs.Mu.RUnlock()
+	binaryData, err := EncodeTeamCellUpdateProtobuf(updates, revealed, team)
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка кодирования обновлений клеток команды %d: %v", team, err)
+		return
+	}
+	for _, id := range s.teamAudience(room, team) {
+		s.sendToPlayer(id, binaryData, "cellUpdate")
+	}
+}
+
+// broadcastVersusBoards отправляет поля обеих команд: каждой команде свое, зрителям оба
+func (s *Service) broadcastVersusBoards(room *Room) {
+	room.Mu.RLock()
+	versus := room.Versus
+	room.Mu.RUnlock()
+	if versus == nil {
+		return
+	}
+
+	versus.Mu.Lock()
+	boards := make([]*GameState, versusTeams)
+	for i, t := range versus.Teams {
+		boards[i] = t.Board
+	}
+	versus.Mu.Unlock()
+
+	for i, board := range boards {
+		s.sendTeamBoard(room, i+1, board)
+	}
+	s.BroadcastVersusStatus(room)
+}
+
+// sendVersusView отправляет подключившемуся игроку поле его команды (зрителю - оба поля) и счет матча
+func (s *Service) sendVersusView(room *Room, player WSPlayer) {
+	room.Mu.RLock()
+	versus := room.Versus
+	team := 0
+	if roomPlayer := room.Players[player.GetID()]; roomPlayer != nil {
+		team = roomPlayer.Team
+	}
+	teamPlayers := room.teamSizes()
+	room.Mu.RUnlock()
+	if versus == nil {
+		return
+	}
+
+	versus.Mu.Lock()
+	boards := make([]*GameState, versusTeams)
+	for i, t := range versus.Teams {
+		boards[i] = t.Board
+	}
+	versus.Mu.Unlock()
+
+	for i, board := range boards {
+		if team != 0 && team != i+1 {
+			continue
+		}
+		binaryData, err := EncodeTeamGameStateProtobuf(board, i+1)
+		if err != nil {
+			log.Printf("[WS OUT] Ошибка кодирования gameState команды %d: %v", i+1, err)
+			return
+		}
+		if err := writeBinary(player, binaryData); err != nil {
+			log.Printf("[WS OUT] Ошибка отправки gameState игроку %s: %v", player.GetID(), err)
+			return
+		}
+	}
+
+	binaryData, err := EncodeVersusStatusProtobuf(versus, teamPlayers)
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка кодирования счета матча: %v", err)
+		return
+	}
+	if err := writeBinary(player, binaryData); err != nil {
+		log.Printf("[WS OUT] Ошибка отправки versusStatus игроку %s: %v", player.GetID(), err)
+	}
+}
+
+// BroadcastVersusStatus отправляет счет матча всем игрокам комнаты
+func (s *Service) BroadcastVersusStatus(room *Room) {
+	room.Mu.RLock()
+	versus := room.Versus
+	teamPlayers := room.teamSizes()
+	room.Mu.RUnlock()
+	if versus == nil {
+		return
+	}
+
+	binaryData, err := EncodeVersusStatusProtobuf(versus, teamPlayers)
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка кодирования счета матча: %v", err)
+		return
+	}
+	s.broadcastBinary(room, binaryData, "versusStatus")
+}
+
+// teamSizes возвращает число игроков в каждой команде
+// ВАЖНО: вызывающий код должен удерживать r.Mu
+func (r *Room) teamSizes() [versusTeams]int {
+	var sizes [versusTeams]int
+	for _, player := range r.Players {
+		if player.Team > 0 && player.Team <= versusTeams {
+			sizes[player.Team-1]++
+		}
+	}
+	return sizes
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"minesweeperonline/internal/achievements"
+	"minesweeperonline/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// winStreak возвращает количество побед подряд в последних играх пользователя (включая только что записанную)
+// Игры без победы и поражения (игра на счет) серию не прерывают
+func (h *ProfileHandler) winStreak(tx *gorm.DB, userID int, limit int) (int, error) {
+	var results []bool
+	if err := tx.Model(&models.UserGameHistory{}).
+		Where("user_id = ? AND completed = ?", userID, false).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Pluck("won", &results).Error; err != nil {
+		return 0, err
+	}
+
+	streak := 0
+	for _, won := range results {
+		if !won {
+			break
+		}
+		streak++
+	}
+	return streak, nil
+}
+
+// evaluateAchievements проверяет правила достижений для записанной игры и сохраняет новые
+// ВАЖНО: вызывается внутри транзакции записи результата игры
+func (h *ProfileHandler) evaluateAchievements(tx *gorm.DB, gameHistory *models.UserGameHistory, result achievements.GameResult) ([]achievements.Achievement, error) {
+	var unlockedIDs []string
+	if err := tx.Model(&models.UserAchievement{}).
+		Where("user_id = ?", gameHistory.UserID).
+		Pluck("achievement_id", &unlockedIDs).Error; err != nil {
+		return nil, err
+	}
+	unlocked := make(map[string]bool, len(unlockedIDs))
+	for _, id := range unlockedIDs {
+		unlocked[id] = true
+	}
+
+	if result.Won {
+		streak, err := h.winStreak(tx, gameHistory.UserID, 10)
+		if err != nil {
+			return nil, err
+		}
+		result.WinStreak = streak
+	}
+
+	earned := achievements.Evaluate(result, unlocked)
+	now := time.Now()
+	for _, achievement := range earned {
+		record := models.UserAchievement{
+			UserID:        gameHistory.UserID,
+			AchievementID: achievement.ID,
+			GameHistoryID: gameHistory.ID,
+			UnlockedAt:    now,
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return nil, err
+		}
+		log.Printf("Достижение получено: userID=%d, achievement=%s, gameHistoryID=%d", gameHistory.UserID, achievement.ID, gameHistory.ID)
+	}
+
+	return earned, nil
+}
+
+// getUserAchievements возвращает полученные пользователем достижения (новые первыми)
+func (h *ProfileHandler) getUserAchievements(userID int) ([]models.AchievementResponse, error) {
+	var records []models.UserAchievement
+	if err := h.db.Where("user_id = ?", userID).
+		Order("unlocked_at DESC").
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]models.AchievementResponse, 0, len(records))
+	for _, record := range records {
+		achievement, ok := achievements.Get(record.AchievementID)
+		if !ok {
+			// Достижение могло быть удалено из правил - не показываем его
+			continue
+		}
+		result = append(result, models.AchievementResponse{
+			ID:            achievement.ID,
+			Title:         achievement.Title,
+			Description:   achievement.Description,
+			GameHistoryID: record.GameHistoryID,
+			UnlockedAt:    record.UnlockedAt,
+		})
+	}
+	return result, nil
+}
@@ -10,23 +10,29 @@ import (
 	"minesweeperonline/internal/auth"
 	"minesweeperonline/internal/config"
 	"minesweeperonline/internal/database"
+	"minesweeperonline/internal/mailer"
 	"minesweeperonline/internal/models"
 	"minesweeperonline/internal/utils"
 
 	"gorm.io/gorm"
 )
 
+// errResetTokenUsed токен сброса пароля уже использован или не выдавался
+var errResetTokenUsed = errors.New("reset token already used")
+
 type AuthHandler struct {
 	db             *database.DB
 	profileHandler *ProfileHandler
 	config         *config.Config
+	mailer         mailer.Mailer
 }
 
-func NewAuthHandler(db *database.DB, profileHandler *ProfileHandler, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(db *database.DB, profileHandler *ProfileHandler, cfg *config.Config, mailer mailer.Mailer) *AuthHandler {
 	return &AuthHandler{
 		db:             db,
 		profileHandler: profileHandler,
 		config:         cfg,
+		mailer:         mailer,
 	}
 }
 
@@ -61,18 +67,23 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Генерация токена
-	token, err := auth.GenerateToken(user.ID, user.Username)
+	// Письмо с подтверждением email не задерживает ответ
+	go func() {
+		if err := h.sendVerificationEmail(user); err != nil {
+			log.Printf("Error sending verification email to user %d: %v", user.ID, err)
+		}
+	}()
+
+	// Новая сессия: короткоживущий access токен и refresh токен для его продления
+	response, err := issueSession(h.db.DB, user, r)
 	if err != nil {
-		log.Printf("Error generating token: %v", err)
+		log.Printf("Error creating session: %v", err)
 		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
+	response.User = h.userToMap(user)
 
-	utils.JSONResponse(w, http.StatusOK, models.AuthResponse{
-		Token: token,
-		User:  h.userToMap(user),
-	})
+	utils.JSONResponse(w, http.StatusOK, response)
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -104,18 +115,16 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Генерация токена
-	token, err := auth.GenerateToken(user.ID, user.Username)
+	// Новая сессия: короткоживущий access токен и refresh токен для его продления
+	response, err := issueSession(h.db.DB, user, r)
 	if err != nil {
-		log.Printf("Error generating token: %v", err)
+		log.Printf("Error creating session: %v", err)
 		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
+	response.User = h.userToMap(user)
 
-	utils.JSONResponse(w, http.StatusOK, models.AuthResponse{
-		Token: token,
-		User:  h.userToMap(user),
-	})
+	utils.JSONResponse(w, http.StatusOK, response)
 }
 
 func (h *AuthHandler) GetMe(w http.ResponseWriter, r *http.Request) {
@@ -127,21 +136,25 @@ func (h *AuthHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Рассчитываем рейтинг динамически
+	// Берем рейтинг из user_ratings
 	if h.profileHandler != nil {
-		userRating := h.profileHandler.calculateUserRating(userID, 100)
-		user.Rating = userRating
+		user.Rating = h.profileHandler.getUserRating(userID)
 	}
 
-	// Проверяем, является ли пользователь администратором
+	// Роли и права пользователя
 	type UserResponse struct {
 		models.User
-		IsAdmin bool `json:"isAdmin"`
+		IsAdmin     bool              `json:"isAdmin"`
+		Roles       []string          `json:"roles"`
+		Permissions []auth.Permission `json:"permissions"`
 	}
 
+	roles := h.userRoles(userID)
 	response := UserResponse{
-		User:    user,
-		IsAdmin: h.config.AdminEmail != "" && user.Email == h.config.AdminEmail,
+		User:        user,
+		IsAdmin:     containsRole(roles, auth.RoleAdmin),
+		Roles:       roles,
+		Permissions: auth.PermissionsOf(roles),
 	}
 
 	utils.JSONResponse(w, http.StatusOK, response)
@@ -188,6 +201,11 @@ func (h *AuthHandler) createUser(username, email, password string) (models.User,
 		return models.User{}, err
 	}
 
+	// Создаем строку рейтинга, чтобы пользователь сразу попал в лидерборд
+	if err := h.db.Create(&models.UserRating{UserID: user.ID, UpdatedAt: time.Now()}).Error; err != nil {
+		log.Printf("Error creating user rating: %v", err)
+	}
+
 	return user, nil
 }
 
@@ -219,16 +237,19 @@ func (h *AuthHandler) findUserByEmail(email string) (models.User, error) {
 	return user, err
 }
 
-// userToMap преобразует User в map с добавлением isAdmin
+// userToMap преобразует User в map с добавлением ролей и прав
 func (h *AuthHandler) userToMap(user models.User) map[string]interface{} {
-	isAdmin := h.config.AdminEmail != "" && user.Email == h.config.AdminEmail
+	roles := h.userRoles(user.ID)
 	userMap := map[string]interface{}{
-		"id":        user.ID,
-		"username":  user.Username,
-		"email":     user.Email,
-		"rating":    user.Rating,
-		"createdAt": user.CreatedAt,
-		"isAdmin":   isAdmin,
+		"id":          user.ID,
+		"username":    user.Username,
+		"email":       user.Email,
+		"rating":      user.Rating,
+		"createdAt":   user.CreatedAt,
+		"isAdmin":     containsRole(roles, auth.RoleAdmin),
+		"roles":       roles,
+		"permissions": auth.PermissionsOf(roles),
+		"verified":    user.Verified,
 	}
 	if user.Color != nil {
 		userMap["color"] = *user.Color
@@ -236,14 +257,24 @@ func (h *AuthHandler) userToMap(user models.User) map[string]interface{} {
 	return userMap
 }
 
-// ResetPasswordByAdmin позволяет администратору сбросить пароль пользователя
+// containsRole проверяет, есть ли роль в списке
+func containsRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ResetPasswordByAdmin позволяет пользователю с правом сброса паролей сбросить пароль другого пользователя
 func (h *AuthHandler) ResetPasswordByAdmin(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		utils.JSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	// Проверяем, что запрос от администратора
+	// Право на сброс пароля проверяет middleware.RequirePermission
 	userID := r.Context().Value("userID").(int)
 	adminUser, err := h.findUserByID(userID)
 	if err != nil {
@@ -251,12 +282,6 @@ func (h *AuthHandler) ResetPasswordByAdmin(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Проверяем, что пользователь - администратор
-	if h.config.AdminEmail == "" || adminUser.Email != h.config.AdminEmail {
-		utils.JSONError(w, http.StatusForbidden, "Only admin can reset passwords")
-		return
-	}
-
 	var req struct {
 		Username    string `json:"username"`
 		Email       string `json:"email"`
@@ -303,10 +328,15 @@ func (h *AuthHandler) ResetPasswordByAdmin(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Обновляем пароль
-	err = h.db.Model(&models.User{}).
-		Where("id = ?", targetUser.ID).
-		Update("password_hash", newPasswordHash).Error
+	// Обновляем пароль и отзываем все сессии пользователя
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", targetUser.ID).
+			Update("password_hash", newPasswordHash).Error; err != nil {
+			return err
+		}
+		return revokeAllSessions(tx, targetUser.ID)
+	})
 	if err != nil {
 		log.Printf("Error updating password: %v", err)
 		utils.JSONError(w, http.StatusInternalServerError, "Failed to update password")
@@ -321,8 +351,7 @@ func (h *AuthHandler) ResetPasswordByAdmin(w http.ResponseWriter, r *http.Reques
 	})
 }
 
-// RequestPasswordReset генерирует токен для сброса пароля (без отправки email)
-// В реальном приложении здесь должна быть отправка email с токеном
+// RequestPasswordReset отправляет на email ссылку для сброса пароля
 func (h *AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		utils.JSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
@@ -343,21 +372,20 @@ func (h *AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Проверяем, существует ли пользователь с таким email
-	_, err := h.findUserByEmail(req.Email)
-	if err != nil {
-		// Для безопасности не сообщаем, существует ли пользователь
-		utils.JSONResponse(w, http.StatusOK, map[string]string{
-			"status":  "ok",
-			"message": "Если указанный email зарегистрирован, следуйте инструкциям ниже",
-		})
-		return
+	// Для безопасности ответ не зависит от того, существует ли пользователь:
+	// письмо отправляется в фоне, чтобы и время ответа не выдавало существование аккаунта
+	user, err := h.findUserByEmail(req.Email)
+	if err == nil {
+		go func() {
+			if err := h.sendPasswordResetEmail(user); err != nil {
+				log.Printf("Error sending password reset email to user %d: %v", user.ID, err)
+			}
+		}()
 	}
 
-	// Пользователь существует - возвращаем сообщение с инструкциями
 	utils.JSONResponse(w, http.StatusOK, map[string]string{
 		"status":  "ok",
-		"message": "Для восстановления пароля отправьте письмо на guhbap@gmail.com с email-адреса, на который зарегистрирован ваш аккаунт",
+		"message": "Если указанный email зарегистрирован, на него отправлено письмо со ссылкой для восстановления пароля",
 	})
 }
 
@@ -401,6 +429,11 @@ func (h *AuthHandler) ResetPasswordByToken(w http.ResponseWriter, r *http.Reques
 		utils.JSONError(w, http.StatusNotFound, "User not found")
 		return
 	}
+	// Токен выдан на прежний email - ссылка недействительна
+	if user.Email != claims.Email {
+		utils.JSONError(w, http.StatusUnauthorized, "Invalid or expired reset token")
+		return
+	}
 
 	// Хешируем новый пароль
 	newPasswordHash, err := auth.HashPassword(req.NewPassword)
@@ -410,10 +443,32 @@ func (h *AuthHandler) ResetPasswordByToken(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Обновляем пароль
-	err = h.db.Model(&models.User{}).
-		Where("id = ?", user.ID).
-		Update("password_hash", newPasswordHash).Error
+	// Гасим токен, обновляем пароль и отзываем все сессии пользователя
+	// Письмо пришло на email пользователя, поэтому email считается подтвержденным
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND user_id = ? AND used_at IS NULL AND expires_at > ?", claims.ID, user.ID, time.Now()).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errResetTokenUsed
+		}
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", user.ID).
+			Updates(map[string]interface{}{
+				"password_hash": newPasswordHash,
+				"verified":      true,
+			}).Error; err != nil {
+			return err
+		}
+		return revokeAllSessions(tx, user.ID)
+	})
+	if errors.Is(err, errResetTokenUsed) {
+		utils.JSONError(w, http.StatusUnauthorized, "Invalid or expired reset token")
+		return
+	}
 	if err != nil {
 		log.Printf("Error updating password: %v", err)
 		utils.JSONError(w, http.StatusInternalServerError, "Failed to update password")
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"minesweeperonline/internal/auth"
+	"minesweeperonline/internal/mailer"
+	"minesweeperonline/internal/models"
+	"minesweeperonline/internal/utils"
+)
+
+// sendPasswordResetEmail выдает одноразовый токен сброса пароля и отправляет ссылку с ним на email пользователя
+func (h *AuthHandler) sendPasswordResetEmail(user models.User) error {
+	tokenID := utils.GenerateUUID()
+	token, err := auth.GeneratePasswordResetToken(user.ID, user.Email, tokenID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	record := models.PasswordResetToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(auth.PasswordResetTTL),
+		CreatedAt: now,
+	}
+	if err := h.db.Create(&record).Error; err != nil {
+		return err
+	}
+
+	link := h.appLink("/reset-password", token)
+	return h.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Восстановление пароля",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\n"+
+			"Чтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %d мин. и может быть использована один раз.\n"+
+			"Если вы не запрашивали восстановление пароля, просто проигнорируйте это письмо.\n",
+			user.Username, link, int(auth.PasswordResetTTL.Minutes())),
+	})
+}
+
+// sendVerificationEmail отправляет ссылку подтверждения email
+func (h *AuthHandler) sendVerificationEmail(user models.User) error {
+	token, err := auth.GenerateEmailVerificationToken(user.ID, user.Email)
+	if err != nil {
+		return err
+	}
+
+	link := h.appLink("/api/auth/verify-email", token)
+	return h.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Подтверждение email",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\n"+
+			"Чтобы подтвердить email, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %d ч.\n"+
+			"Если вы не регистрировались, просто проигнорируйте это письмо.\n",
+			user.Username, link, int(auth.EmailVerificationTTL.Hours())),
+	})
+}
+
+// appLink собирает ссылку на сайт с токеном в query параметре token
+func (h *AuthHandler) appLink(path, token string) string {
+	return strings.TrimRight(h.config.AppURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// VerifyEmail подтверждает email по ссылке из письма и перенаправляет на сайт
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	redirect := strings.TrimRight(h.config.AppURL, "/") + "/?emailVerified="
+
+	claims, err := auth.ValidateEmailVerificationToken(r.URL.Query().Get("token"))
+	if err != nil {
+		http.Redirect(w, r, redirect+"0", http.StatusFound)
+		return
+	}
+
+	// Email мог смениться после отправки письма: подтверждается только адрес из токена
+	result := h.db.Model(&models.User{}).
+		Where("id = ? AND email = ?", claims.UserID, claims.Email).
+		Update("verified", true)
+	if result.Error != nil {
+		log.Printf("Error verifying email for user %d: %v", claims.UserID, result.Error)
+		http.Redirect(w, r, redirect+"0", http.StatusFound)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Redirect(w, r, redirect+"0", http.StatusFound)
+		return
+	}
+
+	// Инвалидируем кеш пользователя и профиля
+	if h.profileHandler != nil {
+		h.profileHandler.cache.Delete(fmt.Sprintf("user:id:%d", claims.UserID))
+		h.profileHandler.cache.Delete(fmt.Sprintf("profile:%d", claims.UserID))
+		if user, err := h.findUserByID(claims.UserID); err == nil {
+			h.profileHandler.cache.Delete(fmt.Sprintf("user:username:%s", user.Username))
+		}
+	}
+	log.Printf("Email verified for user ID %d", claims.UserID)
+
+	http.Redirect(w, r, redirect+"1", http.StatusFound)
+}
+
+// ResendVerification повторно отправляет письмо подтверждения email
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		utils.JSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	user, err := h.findUserByID(userID)
+	if err != nil {
+		utils.JSONError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if user.Verified {
+		utils.JSONError(w, http.StatusBadRequest, "Email already verified")
+		return
+	}
+
+	if err := h.sendVerificationEmail(user); err != nil {
+		log.Printf("Error sending verification email to user %d: %v", userID, err)
+		utils.JSONError(w, http.StatusInternalServerError, "Failed to send email")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
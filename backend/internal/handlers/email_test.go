@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"mime/quotedprintable"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"minesweeperonline/internal/auth"
+	"minesweeperonline/internal/config"
+	"minesweeperonline/internal/database"
+	"minesweeperonline/internal/mailer"
+	"minesweeperonline/internal/mailer/mailertest"
+	"minesweeperonline/internal/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeStore минимальная база для тестов сброса пароля: один пользователь и токены сброса
+// Гашение токена повторяет условие UPDATE ... WHERE used_at IS NULL: второй раз запись не обновляется
+type fakeStore struct {
+	mu            sync.Mutex
+	user          models.User
+	tokens        map[string]bool // ID токена -> погашен
+	passwordSaves int
+}
+
+func (s *fakeStore) Connect(context.Context) (driver.Conn, error) { return &fakeConn{store: s}, nil }
+func (s *fakeStore) Driver() driver.Driver                        { return nil }
+
+// exec выполняет запрос и возвращает число измененных строк
+func (s *fakeStore) exec(query string, args []driver.NamedValue) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case strings.HasPrefix(query, `INSERT INTO "password_reset_tokens"`):
+		for _, arg := range args {
+			if id, ok := arg.Value.(string); ok {
+				s.tokens[id] = false
+			}
+		}
+		return 1
+	case strings.HasPrefix(query, `UPDATE "password_reset_tokens"`):
+		for _, arg := range args {
+			if id, ok := arg.Value.(string); ok {
+				if used, known := s.tokens[id]; known && !used {
+					s.tokens[id] = true
+					return 1
+				}
+			}
+		}
+		return 0
+	case strings.HasPrefix(query, `UPDATE "users"`) && strings.Contains(query, `"password_hash"`):
+		s.passwordSaves++
+		return 1
+	}
+	return 1
+}
+
+// query возвращает пользователя на SELECT из users и пустой результат на остальные запросы
+func (s *fakeStore) query(query string, args []driver.NamedValue) driver.Rows {
+	if strings.HasPrefix(query, `INSERT`) || strings.HasPrefix(query, `UPDATE`) {
+		s.exec(query, args)
+		return &fakeRows{}
+	}
+	if !strings.Contains(query, `FROM "users"`) {
+		return &fakeRows{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &fakeRows{
+		columns: []string{"id", "username", "email", "verified"},
+		rows:    [][]driver.Value{{int64(s.user.ID), s.user.Username, s.user.Email, s.user.Verified}},
+	}
+}
+
+type fakeConn struct{ store *fakeStore }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(c.store.exec(query, args)), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.store.query(query, args), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// newTestAuthHandler создает AuthHandler с fakeStore и отправкой писем на тестовый SMTP сервер
+func newTestAuthHandler(t *testing.T, user models.User) (*AuthHandler, *fakeStore, *mailertest.Server) {
+	t.Helper()
+	store := &fakeStore{user: user, tokens: make(map[string]bool)}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(store)}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+
+	server := mailertest.NewServer()
+	t.Cleanup(server.Close)
+	h := NewAuthHandler(&database.DB{DB: db}, nil, &config.Config{AppURL: "https://minesweeper.example/"},
+		&mailer.SMTPMailer{Host: server.Host, Port: server.Port, From: "noreply@minesweeper.example"})
+	return h, store, server
+}
+
+var resetLinkPattern = regexp.MustCompile(`https://minesweeper\.example/reset-password\?token=\S+`)
+
+// resetToken достает токен из ссылки в письме
+func resetToken(t *testing.T, data []byte) string {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("письмо не разбирается: %v", err)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatalf("тело не декодируется: %v", err)
+	}
+	link := resetLinkPattern.Find(body)
+	if link == nil {
+		t.Fatalf("в письме нет ссылки сброса пароля: %s", body)
+	}
+	parsed, err := url.Parse(string(link))
+	if err != nil {
+		t.Fatalf("ссылка не разбирается: %v", err)
+	}
+	return parsed.Query().Get("token")
+}
+
+func resetPassword(h *AuthHandler, token string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"token": token, "newPassword": "new-secret"})
+	w := httptest.NewRecorder()
+	h.ResetPasswordByToken(w, httptest.NewRequest("POST", "/api/auth/reset-password", bytes.NewReader(body)))
+	return w
+}
+
+func TestSendPasswordResetEmail(t *testing.T) {
+	user := models.User{ID: 7, Username: "alice", Email: "alice@example.com"}
+	h, store, server := newTestAuthHandler(t, user)
+
+	if err := h.sendPasswordResetEmail(user); err != nil {
+		t.Fatalf("sendPasswordResetEmail: %v", err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 || len(messages[0].To) != 1 || messages[0].To[0] != user.Email {
+		t.Fatalf("письмо не ушло на %s: %+v", user.Email, messages)
+	}
+	claims, err := auth.ValidatePasswordResetToken(resetToken(t, messages[0].Data))
+	if err != nil {
+		t.Fatalf("токен из письма не проходит проверку: %v", err)
+	}
+	if claims.UserID != user.ID || claims.Email != user.Email {
+		t.Errorf("токен выдан на %d/%s", claims.UserID, claims.Email)
+	}
+	if used, ok := store.tokens[claims.ID]; !ok || used {
+		t.Errorf("токен %q не сохранен как неиспользованный", claims.ID)
+	}
+}
+
+func TestResetPasswordByTokenSingleUse(t *testing.T) {
+	user := models.User{ID: 7, Username: "alice", Email: "alice@example.com"}
+	h, store, server := newTestAuthHandler(t, user)
+
+	if err := h.sendPasswordResetEmail(user); err != nil {
+		t.Fatalf("sendPasswordResetEmail: %v", err)
+	}
+	token := resetToken(t, server.Messages()[0].Data)
+
+	if w := resetPassword(h, token); w.Code != http.StatusOK {
+		t.Fatalf("первый сброс: код %d, %s", w.Code, w.Body)
+	}
+	if w := resetPassword(h, token); w.Code != http.StatusUnauthorized {
+		t.Fatalf("повторный сброс той же ссылкой: код %d, ожидался %d", w.Code, http.StatusUnauthorized)
+	}
+	if store.passwordSaves != 1 {
+		t.Errorf("пароль сохранен %d раз, ожидался 1", store.passwordSaves)
+	}
+}
+
+func TestRequestPasswordResetSendsInBackground(t *testing.T) {
+	user := models.User{ID: 7, Username: "alice", Email: "alice@example.com"}
+	h, _, server := newTestAuthHandler(t, user)
+
+	body, _ := json.Marshal(map[string]string{"email": user.Email})
+	w := httptest.NewRecorder()
+	h.RequestPasswordReset(w, httptest.NewRequest("POST", "/api/auth/forgot-password", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("код %d, %s", w.Code, w.Body)
+	}
+
+	// Письмо уходит после ответа
+	deadline := time.Now().Add(5 * time.Second)
+	for len(server.Messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if messages := server.Messages(); len(messages) != 1 || messages[0].To[0] != user.Email {
+		t.Fatalf("письмо сброса пароля не отправлено: %+v", messages)
+	}
+}
@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"minesweeperonline/internal/rating"
+	"minesweeperonline/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// LeaderboardEntry представляет запись в лидерборде
+type LeaderboardEntry struct {
+	Rank        int      `json:"rank"`
+	ID          int      `json:"id"`
+	Username    string   `json:"username"`
+	Color       string   `json:"color,omitempty"`
+	Rating      float64  `json:"rating"`
+	EloRating   float64  `json:"eloRating"`
+	GamesPlayed int      `json:"gamesPlayed"`
+	GamesWon    int      `json:"gamesWon"`
+	GamesLost   int      `json:"gamesLost"`
+	BestTime    *float64 `json:"bestTime,omitempty"`   // Лучшее время на выбранном поле (только для пресетов)
+	BestGameID  int      `json:"bestGameId,omitempty"` // Игра с лучшим временем (только для пресетов)
+}
+
+// BoardPreset размеры поля для таблицы рекордов
+type BoardPreset struct {
+	Width  int
+	Height int
+	Mines  int
+}
+
+// boardPresets классические уровни сложности
+var boardPresets = map[string]BoardPreset{
+	"beginner":     {Width: 9, Height: 9, Mines: 10},
+	"intermediate": {Width: 16, Height: 16, Mines: 40},
+	"expert":       {Width: 30, Height: 16, Mines: 99},
+}
+
+const (
+	defaultLeaderboardLimit = 100
+	maxLeaderboardLimit     = 500
+)
+
+// LeaderboardFilter параметры запроса лидерборда
+type LeaderboardFilter struct {
+	Page       int
+	Limit      int
+	Period     string       // "all", "month", "week"
+	Preset     *BoardPreset // nil - общий рейтинг, иначе таблица рекордов по времени
+	Chording   *bool
+	QuickStart *bool
+	NoGuess    *bool
+}
+
+// parseBoardPreset разбирает пресет: beginner, intermediate, expert или WxH/M (например, 16x16/40)
+func parseBoardPreset(value string) (*BoardPreset, error) {
+	if preset, ok := boardPresets[strings.ToLower(value)]; ok {
+		return &preset, nil
+	}
+
+	var preset BoardPreset
+	if _, err := fmt.Sscanf(strings.ToLower(value), "%dx%d/%d", &preset.Width, &preset.Height, &preset.Mines); err != nil {
+		return nil, fmt.Errorf("invalid board preset: %s", value)
+	}
+	if err := utils.ValidateRoomParams("preset", preset.Height, preset.Width, preset.Mines); err != nil {
+		return nil, err
+	}
+	return &preset, nil
+}
+
+// parseOptionalBool разбирает необязательный булев параметр запроса
+func parseOptionalBool(value string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// parseLeaderboardFilter разбирает параметры page, limit, period, preset, chording, quickStart и noGuess
+func parseLeaderboardFilter(r *http.Request) (LeaderboardFilter, error) {
+	query := r.URL.Query()
+	filter := LeaderboardFilter{
+		Page:   1,
+		Limit:  defaultLeaderboardLimit,
+		Period: "all",
+	}
+
+	if value := query.Get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return filter, fmt.Errorf("invalid page")
+		}
+		filter.Page = page
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLeaderboardLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxLeaderboardLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("period"); value != "" {
+		if value != "all" && value != "month" && value != "week" {
+			return filter, fmt.Errorf("period must be one of: all, month, week")
+		}
+		filter.Period = value
+	}
+
+	if value := query.Get("preset"); value != "" {
+		preset, err := parseBoardPreset(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.Preset = preset
+	}
+
+	var err error
+	if filter.Chording, err = parseOptionalBool(query.Get("chording")); err != nil {
+		return filter, fmt.Errorf("invalid chording filter")
+	}
+	if filter.QuickStart, err = parseOptionalBool(query.Get("quickStart")); err != nil {
+		return filter, fmt.Errorf("invalid quickStart filter")
+	}
+	if filter.NoGuess, err = parseOptionalBool(query.Get("noGuess")); err != nil {
+		return filter, fmt.Errorf("invalid noGuess filter")
+	}
+
+	return filter, nil
+}
+
+// cacheKey ключ кеша для выбранных фильтров
+func (f LeaderboardFilter) cacheKey() string {
+	key := fmt.Sprintf("leaderboard:%d:%d:%s", f.Page, f.Limit, f.Period)
+	if f.Preset != nil {
+		key += fmt.Sprintf(":%dx%d/%d", f.Preset.Width, f.Preset.Height, f.Preset.Mines)
+	}
+	if f.Chording != nil {
+		key += fmt.Sprintf(":c=%v", *f.Chording)
+	}
+	if f.QuickStart != nil {
+		key += fmt.Sprintf(":q=%v", *f.QuickStart)
+	}
+	if f.NoGuess != nil {
+		key += fmt.Sprintf(":ng=%v", *f.NoGuess)
+	}
+	return key
+}
+
+// since начало временного окна (nil для all)
+func (f LeaderboardFilter) since() *time.Time {
+	var t time.Time
+	switch f.Period {
+	case "week":
+		t = time.Now().AddDate(0, 0, -7)
+	case "month":
+		t = time.Now().AddDate(0, -1, 0)
+	default:
+		return nil
+	}
+	return &t
+}
+
+// applyGameFilters добавляет к запросу по user_game_history фильтры окна и модификаторов
+func (f LeaderboardFilter) applyGameFilters(query *gorm.DB) *gorm.DB {
+	if since := f.since(); since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+	if f.Chording != nil {
+		query = query.Where("chording = ?", *f.Chording)
+	}
+	if f.QuickStart != nil {
+		query = query.Where("quick_start = ?", *f.QuickStart)
+	}
+	if f.NoGuess != nil {
+		query = query.Where("no_guess = ?", *f.NoGuess)
+	}
+	return query
+}
+
+// leaderboardRow строка результата запросов лидерборда
+type leaderboardRow struct {
+	ID          int
+	Username    string
+	Color       *string
+	Rating      float64
+	EloRating   *float64
+	GamesPlayed int
+	GamesWon    int
+	GamesLost   int
+	BestTime    *float64
+	BestGameID  *int
+}
+
+// GetLeaderboard возвращает страницу лидерборда
+// Параметры: page, limit, period (all/month/week), preset (beginner/intermediate/expert или WxH/M), chording, quickStart, noGuess
+// Без пресета игроки ранжируются по рейтингу, с пресетом - по лучшему времени на поле
+// Общее количество записей возвращается в заголовке X-Total-Count и считается отдельным запросом,
+// поэтому не зависит от того, попала ли страница в диапазон
+func (h *ProfileHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseLeaderboardFilter(r)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	type cachedLeaderboard struct {
+		Entries []LeaderboardEntry
+		Total   int64
+	}
+
+	// Проверяем кеш (лидерборд кешируем на 1 минуту)
+	cacheKey := filter.cacheKey()
+	if cached, found := h.cache.Get(cacheKey); found {
+		if leaderboard, ok := cached.(cachedLeaderboard); ok {
+			w.Header().Set("X-Total-Count", strconv.FormatInt(leaderboard.Total, 10))
+			utils.JSONResponse(w, http.StatusOK, leaderboard.Entries)
+			return
+		}
+	}
+
+	var rows []leaderboardRow
+	var total int64
+	switch {
+	case filter.Preset != nil:
+		rows, total, err = h.queryBoardLadder(filter)
+	case filter.Period == "all" && filter.Chording == nil && filter.QuickStart == nil && filter.NoGuess == nil:
+		rows, total, err = h.queryRatingLeaderboard(filter)
+	default:
+		rows, total, err = h.queryWindowedRatingLeaderboard(filter)
+	}
+	if err != nil {
+		log.Printf("Error getting leaderboard: %v", err)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	leaderboard := make([]LeaderboardEntry, 0, len(rows))
+	for i, row := range rows {
+		entry := LeaderboardEntry{
+			Rank:        (filter.Page-1)*filter.Limit + i + 1,
+			ID:          row.ID,
+			Username:    row.Username,
+			Rating:      row.Rating,
+			EloRating:   rating.Rref,
+			GamesPlayed: row.GamesPlayed,
+			GamesWon:    row.GamesWon,
+			GamesLost:   row.GamesLost,
+			BestTime:    row.BestTime,
+		}
+		if row.Color != nil {
+			entry.Color = *row.Color
+		}
+		if row.EloRating != nil {
+			entry.EloRating = *row.EloRating
+		}
+		if row.BestGameID != nil {
+			entry.BestGameID = *row.BestGameID
+		}
+		leaderboard = append(leaderboard, entry)
+	}
+
+	// Сохраняем в кеш (используем основной кеш, но лидерборд обновляется часто)
+	h.cache.Set(cacheKey, cachedLeaderboard{Entries: leaderboard, Total: total})
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	utils.JSONResponse(w, http.StatusOK, leaderboard)
+}
+
+// queryRatingLeaderboard общий рейтинг за все время: один запрос по индексу idx_user_ratings_rating
+func (h *ProfileHandler) queryRatingLeaderboard(filter LeaderboardFilter) ([]leaderboardRow, int64, error) {
+	base := h.db.Table("user_ratings AS r").
+		Joins("JOIN users AS u ON u.id = r.user_id")
+
+	rows, err := pageLeaderboard(base.Session(&gorm.Session{}).
+		Select("u.id, u.username, u.color, r.rating, s.elo_rating, COALESCE(s.games_played, 0) AS games_played, COALESCE(s.games_won, 0) AS games_won, COALESCE(s.games_lost, 0) AS games_lost").
+		Joins("LEFT JOIN user_stats AS s ON s.user_id = r.user_id").
+		Order("r.rating DESC, u.username ASC"), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int64
+	err = base.Count(&total).Error
+	return rows, total, err
+}
+
+// pageLeaderboard выбирает страницу лидерборда из упорядоченного запроса
+func pageLeaderboard(query *gorm.DB, filter LeaderboardFilter) ([]leaderboardRow, error) {
+	var rows []leaderboardRow
+	err := query.
+		Limit(filter.Limit).
+		Offset((filter.Page - 1) * filter.Limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// gameCounts количество игр, побед и поражений по тем же фильтрам, что и строка лидерборда
+func gameCounts(games *gorm.DB) *gorm.DB {
+	return games.Session(&gorm.Session{}).
+		Select("user_id, COUNT(*) AS games_played, COUNT(*) FILTER (WHERE won) AS games_won, COUNT(*) FILTER (WHERE NOT won AND NOT completed) AS games_lost").
+		Group("user_id")
+}
+
+// queryWindowedRatingLeaderboard рейтинг по играм из временного окна и/или с выбранными модификаторами
+// Считается так же, как основной рейтинг: сумма топ-100 игр с коэффициентом 0.95^i
+// Игры, победы и поражения считаются по тем же играм окна, а не за все время
+func (h *ProfileHandler) queryWindowedRatingLeaderboard(filter LeaderboardFilter) ([]leaderboardRow, int64, error) {
+	games := filter.applyGameFilters(h.db.Table("user_game_history"))
+
+	ranked := games.Session(&gorm.Session{}).
+		Select("user_id, rating, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY rating DESC) AS rn").
+		Where("rating > 0")
+
+	totals := h.db.Table("(?) AS ranked", ranked).
+		Select("user_id, SUM(rating * POWER(0.95, rn - 1)) AS rating").
+		Where("rn <= ?", topRatedGamesCount).
+		Group("user_id")
+
+	base := h.db.Table("(?) AS t", totals).
+		Joins("JOIN (?) AS c ON c.user_id = t.user_id", gameCounts(games)).
+		Joins("JOIN users AS u ON u.id = t.user_id")
+
+	rows, err := pageLeaderboard(base.Session(&gorm.Session{}).
+		Select("u.id, u.username, u.color, t.rating, s.elo_rating, c.games_played, c.games_won, c.games_lost").
+		Joins("LEFT JOIN user_stats AS s ON s.user_id = t.user_id").
+		Order("t.rating DESC, u.username ASC"), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int64
+	err = base.Count(&total).Error
+	return rows, total, err
+}
+
+// queryBoardLadder таблица рекордов для поля: игроки ранжируются по лучшему времени победы
+// Учитываются только одиночные классические победы без подсказок и потерянных жизней;
+// игры с явно указанным seed не учитываются
+// Игры, победы и поражения считаются по таким же играм на этом поле
+func (h *ProfileHandler) queryBoardLadder(filter LeaderboardFilter) ([]leaderboardRow, int64, error) {
+	preset := filter.Preset
+	games := filter.applyGameFilters(h.db.Table("user_game_history").
+		Where("has_custom_seed = ? AND width = ? AND height = ? AND mines = ?",
+			false, preset.Width, preset.Height, preset.Mines).
+		Where("game_mode = ? AND hints_used = 0 AND placement = 0 AND players <= 1", "classic").
+		// Гости не попадают в участники, поэтому для старых записей без players это лишь нижняя граница
+		Where("(SELECT COUNT(*) FROM game_participants AS gp WHERE gp.game_history_id = user_game_history.id) <= 1"))
+
+	best := games.Session(&gorm.Session{}).
+		Select("DISTINCT ON (user_id) user_id, id AS best_game_id, game_time AS best_time").
+		Where("won = ? AND lives_lost = 0", true).
+		Order("user_id, game_time ASC")
+
+	base := h.db.Table("(?) AS b", best).
+		Joins("JOIN (?) AS c ON c.user_id = b.user_id", gameCounts(games)).
+		Joins("JOIN users AS u ON u.id = b.user_id")
+
+	rows, err := pageLeaderboard(base.Session(&gorm.Session{}).
+		Select("u.id, u.username, u.color, COALESCE(r.rating, 0) AS rating, s.elo_rating, c.games_played, c.games_won, c.games_lost, b.best_time, b.best_game_id").
+		Joins("LEFT JOIN user_ratings AS r ON r.user_id = b.user_id").
+		Joins("LEFT JOIN user_stats AS s ON s.user_id = b.user_id").
+		Order("b.best_time ASC, u.username ASC"), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int64
+	err = base.Count(&total).Error
+	return rows, total, err
+}
@@ -9,6 +9,7 @@ import (
 	"sort"
 	"time"
 
+	"minesweeperonline/internal/achievements"
 	"minesweeperonline/internal/auth"
 	"minesweeperonline/internal/cache"
 	"minesweeperonline/internal/database"
@@ -35,7 +36,7 @@ func NewProfileHandler(db *database.DB) *ProfileHandler {
 }
 
 // calculateGameRating рассчитывает рейтинг для одной игры с учетом модификаторов
-func (h *ProfileHandler) calculateGameRating(width, height, mines int, gameTime float64, chording, quickStart bool) float64 {
+func (h *ProfileHandler) calculateGameRating(width, height, mines int, gameTime float64, chording, quickStart, noGuess bool, livesLost int) float64 {
 	if !rating.IsRatingEligible(float64(width), float64(height), float64(mines), gameTime) {
 		return 0.0
 	}
@@ -49,76 +50,50 @@ func (h *ProfileHandler) calculateGameRating(width, height, mines int, gameTime
 	if quickStart {
 		gameRating = gameRating * 0.9
 	}
-
-	return gameRating
-}
-
-// calculateUserRating рассчитывает рейтинг пользователя как сумму рейтинга лучших игр
-// topGamesCount - количество лучших игр для суммирования (по умолчанию 10)
-func (h *ProfileHandler) calculateUserRating(userID int, topGamesCount int) float64 {
-	if topGamesCount <= 0 {
-		topGamesCount = 100 // По умолчанию топ-100
-	}
-
-	var historyRecords []models.UserGameHistory
-	err := h.db.Where("user_id = ?", userID).
-		Find(&historyRecords).Error
-
-	if err != nil {
-		log.Printf("Error querying games for rating calculation: %v", err)
-		return 0.0
+	// На поле без угадываний нет риска проиграть на догадке
+	if noGuess {
+		gameRating = gameRating * 0.85
 	}
-
-	// Рассчитываем рейтинг для каждой игры
-	type GameRating struct {
-		Rating float64
+	// Каждая потерянная жизнь - ошибка, которая в обычной игре стоила бы победы
+	if livesLost > 0 {
+		gameRating = gameRating * math.Pow(0.75, float64(livesLost))
 	}
-	var gameRatings []GameRating
 
-	for _, record := range historyRecords {
-		// Учитываем только выигранные игры
-		if !record.Won {
-			continue
-		}
-
-		// Пропускаем игры с явно указанным пользователем seed (нерейтинговые)
-		if record.HasCustomSeed {
-			continue
-		}
-
-		// Рассчитываем рейтинг для игры
-		gameRating := h.calculateGameRating(record.Width, record.Height, record.Mines, record.GameTime, record.Chording, record.QuickStart)
-
-		if gameRating > 0 {
-			gameRatings = append(gameRatings, GameRating{Rating: gameRating})
-		}
-	}
-
-	// Сортируем по рейтингу (по убыванию)
-	sort.Slice(gameRatings, func(i, j int) bool {
-		return gameRatings[i].Rating > gameRatings[j].Rating
-	})
+	return gameRating
+}
 
-	// Суммируем топ-N игр с весовыми коэффициентами
-	// Первая игра (лучшая) дает 100% рейтинга (коэффициент 1.0)
-	// Вторая - 95% (0.95)
-	// Третья - 90.25% (0.95^2)
-	// N-я игра дает 0.95^(n-1) процентов
-	totalRating := 0.0
-	count := topGamesCount
-	if len(gameRatings) < count {
-		count = len(gameRatings)
-	}
-	for i := 0; i < count; i++ {
-		// Коэффициент для i-й игры: 0.95^i
-		// i=0 (первая игра) -> коэффициент = 1.0 (100%)
-		// i=1 (вторая игра) -> коэффициент = 0.95 (95%)
-		// i=2 (третья игра) -> коэффициент = 0.9025 (90.25%)
-		coefficient := math.Pow(0.95, float64(i))
-		totalRating += gameRatings[i].Rating * coefficient
-	}
+// GameClickStats статистика 3BV и кликов игры для ответов API
+type GameClickStats struct {
+	ThreeBV          int     `json:"threeBV"`
+	ThreeBVPerSecond float64 `json:"threeBVPerSecond"`
+	Clicks           int     `json:"clicks"`
+	LeftClicks       int     `json:"leftClicks"`
+	RightClicks      int     `json:"rightClicks"`
+	ChordClicks      int     `json:"chordClicks"`
+	UsefulClicks     int     `json:"usefulClicks"`
+	WastedClicks     int     `json:"wastedClicks"`
+	Efficiency       float64 `json:"efficiency"` // 3BV / все клики игрока, в процентах
+}
 
-	return totalRating
+// newGameClickStats вычисляет 3BV/s и эффективность по сохраненной игре
+func newGameClickStats(record models.UserGameHistory) GameClickStats {
+	clicks := record.LeftClicks + record.RightClicks + record.ChordClicks
+	stats := GameClickStats{
+		ThreeBV:      record.ThreeBV,
+		Clicks:       clicks,
+		LeftClicks:   record.LeftClicks,
+		RightClicks:  record.RightClicks,
+		ChordClicks:  record.ChordClicks,
+		UsefulClicks: clicks - record.WastedClicks,
+		WastedClicks: record.WastedClicks,
+	}
+	if record.GameTime > 0 {
+		stats.ThreeBVPerSecond = float64(record.ThreeBV) / record.GameTime
+	}
+	if clicks > 0 {
+		stats.Efficiency = float64(record.ThreeBV) / float64(clicks) * 100.0
+	}
+	return stats
 }
 
 // buildUserProfile создает профиль пользователя с расчетом рейтинга и статистики
@@ -151,13 +126,19 @@ func (h *ProfileHandler) buildUserProfile(userID int) (models.UserProfile, error
 	// Проверяем онлайн статус (последний раз был онлайн менее 5 минут назад)
 	stats.IsOnline = time.Since(stats.LastSeen) < 5*time.Minute
 
-	// Рассчитываем рейтинг динамически
-	userRating := h.calculateUserRating(userID, 100)
-	user.Rating = userRating
+	// Берем рейтинг из user_ratings
+	user.Rating = h.getUserRating(userID)
+
+	userAchievements, err := h.getUserAchievements(userID)
+	if err != nil {
+		log.Printf("Error getting achievements: %v", err)
+		userAchievements = []models.AchievementResponse{}
+	}
 
 	profile := models.UserProfile{
-		User:  user,
-		Stats: stats,
+		User:         user,
+		Stats:        stats,
+		Achievements: userAchievements,
 	}
 
 	// Сохраняем в кеш
@@ -348,10 +329,15 @@ func (h *ProfileHandler) ChangePassword(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Обновляем пароль в БД
-	err = h.db.Model(&models.User{}).
-		Where("id = ?", userID).
-		Update("password_hash", newPasswordHash).Error
+	// Обновляем пароль в БД и отзываем все сессии: старые токены перестают действовать
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			Update("password_hash", newPasswordHash).Error; err != nil {
+			return err
+		}
+		return revokeAllSessions(tx, userID)
+	})
 	if err != nil {
 		log.Printf("Error updating password for user %d: %v", userID, err)
 		utils.JSONError(w, http.StatusInternalServerError, "Failed to update password")
@@ -364,7 +350,27 @@ func (h *ProfileHandler) ChangePassword(w http.ResponseWriter, r *http.Request)
 		h.cache.Delete(fmt.Sprintf("user:username:%s", user.Username))
 	}
 
-	utils.JSONResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+	// Текущее устройство остается в системе с токенами нового поколения
+	// (пользователь читается из БД заново: поколение токенов только что изменилось)
+	var updated models.User
+	if err := h.db.First(&updated, userID).Error; err != nil {
+		log.Printf("Error reloading user %d after password change: %v", userID, err)
+		utils.JSONResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+	response, err := issueSession(h.db.DB, updated, r)
+	if err != nil {
+		log.Printf("Error creating session for user %d: %v", userID, err)
+		utils.JSONResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"status":       "ok",
+		"token":        response.Token,
+		"refreshToken": response.RefreshToken,
+		"expiresIn":    response.ExpiresIn,
+	})
 }
 
 func (h *ProfileHandler) FindUserColor(id int) (string, error) {
@@ -415,13 +421,47 @@ func isValidHexColor(color string) bool {
 // Rating is NOT given for:
 // - Playing less complex fields than previously played (prevents farming easy fields)
 // This prevents farming rating on easy fields and penalizes worse performance
-func (h *ProfileHandler) RecordGameResult(userID int, width, height, mines int, gameTime float64, won bool, chording bool, quickStart bool, roomID string, seed string, hasCustomSeed bool, creatorID int, participants []game.GameParticipant) error {
+//
+// История игры, участники, запись ходов, статистика, рейтинг Эло, user_ratings,
+// снимок рейтинга и новые достижения сохраняются в одной транзакции
+// Возвращает достижения, полученные за эту игру
+func (h *ProfileHandler) RecordGameResult(userID int, width, height, mines int, gameTime float64, won bool, chording bool, quickStart bool, roomID string, seed string, hasCustomSeed bool, creatorID int, participants []game.GameParticipant, details game.GameResultDetails) ([]achievements.Achievement, error) {
 	// Если participants не передан, используем пустой слайс
 	if participants == nil {
 		participants = []game.GameParticipant{}
 	}
 
-	var err error
+	var gameRating float64
+	if won {
+		// Проверяем, может ли игра дать рейтинг
+		// Если seed был указан пользователем явно, игра нерейтинговая
+		if hasCustomSeed {
+			log.Printf("Игра не дает рейтинг: указан seed=%s (игра нерейтинговая)", seed)
+		} else if !rating.IsRatingEligible(float64(width), float64(height), float64(mines), gameTime) {
+			log.Printf("Игра не дает рейтинг: плотность=%.2f%% (мин. 10%%)",
+				float64(mines)/(float64(width)*float64(height))*100)
+		} else {
+			// Вычисляем рейтинг за игру по формуле: R = K * d / ln(t + 1)
+			// с модификаторами Chording (0.8), QuickStart (0.9), NoGuess (0.85) и 0.75 за каждую потерянную жизнь
+			gameRating = h.calculateGameRating(width, height, mines, gameTime, chording, quickStart, details.NoGuess, details.LivesLost)
+			log.Printf("Field %dx%d with %d mines, time=%.2f, chording=%v, quickStart=%v, noGuess=%v, livesLost=%d: gameRating=%.2f",
+				width, height, mines, gameTime, chording, quickStart, details.NoGuess, details.LivesLost, gameRating)
+			// В совместной игре рейтинг делится по вкладу игрока
+			if details.ContributionShare != nil {
+				gameRating = gameRating * *details.ContributionShare
+				log.Printf("Вклад игрока в совместную игру: %.2f, gameRating=%.2f", *details.ContributionShare, gameRating)
+			}
+		}
+	} else {
+		// For lost games, don't update rating
+		log.Printf("Game lost - no rating update")
+	}
+
+	gameMode := details.GameMode
+	if gameMode == "" {
+		gameMode = "classic"
+	}
+
 	// Сохраняем игру в историю (для побед и поражений)
 	log.Printf("Сохранение игры в историю: userID=%d, roomID=%s, размер=%dx%d, мины=%d, время=%.2f сек, seed=%s (len=%d), creatorID=%d, won=%v",
 		userID, roomID, width, height, mines, gameTime, seed, len(seed), creatorID, won)
@@ -438,93 +478,177 @@ func (h *ProfileHandler) RecordGameResult(userID int, width, height, mines int,
 		Won:           won,
 		Chording:      chording,
 		QuickStart:    quickStart,
+		NoGuess:       details.NoGuess,
+		GameMode:      gameMode,
+		HintsUsed:     details.HintsUsed,
+		Players:       details.Players,
+		Completed:     details.Completed,
+		Placement:     details.Placement,
+		LivesLost:     details.LivesLost,
+		Score:         details.Score,
+		Rating:        gameRating,
+		ThreeBV:       details.ThreeBV,
+		LeftClicks:    details.Clicks.Left(),
+		RightClicks:   details.Clicks.Right(),
+		ChordClicks:   details.Clicks.Chord(),
+		WastedClicks:  details.Clicks.Wasted(),
 		CreatedAt:     time.Now(),
 	}
-	log.Printf("GameHistory перед сохранением: Seed=%s (len=%d), тип=%T", gameHistory.Seed, len(gameHistory.Seed), gameHistory.Seed)
-	// Сохраняем через GORM, но проверяем тип колонки перед сохранением
-	err = h.db.Create(&gameHistory).Error
-	if err != nil {
-		log.Printf("Error saving game to history: %v", err)
-	} else {
-		// Проверяем, что сохранилось
-		var savedRecord models.UserGameHistory
-		if err := h.db.First(&savedRecord, gameHistory.ID).Error; err == nil {
-			log.Printf("Проверка сохраненного seed: ID=%d, Seed=%s (len=%d)", savedRecord.ID, savedRecord.Seed, len(savedRecord.Seed))
-			if savedRecord.Seed != gameHistory.Seed {
-				log.Printf("ОШИБКА: seed не совпадает! Ожидалось: %s (len=%d), получено: %s (len=%d)",
-					gameHistory.Seed, len(gameHistory.Seed), savedRecord.Seed, len(savedRecord.Seed))
-				// Пытаемся обновить через Raw SQL
-				if err := h.db.Exec(`UPDATE user_game_history SET seed = ? WHERE id = ?`, gameHistory.Seed, gameHistory.ID).Error; err != nil {
-					log.Printf("Ошибка обновления seed через Raw SQL: %v", err)
-				} else {
-					log.Printf("Seed обновлен через Raw SQL: %s", gameHistory.Seed)
-				}
+
+	var unlocked []achievements.Achievement
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&gameHistory).Error; err != nil {
+			return fmt.Errorf("save game history: %w", err)
+		}
+
+		// Проверяем, что seed сохранился без искажений
+		var savedSeed string
+		if err := tx.Model(&models.UserGameHistory{}).Where("id = ?", gameHistory.ID).Pluck("seed", &savedSeed).Error; err == nil && savedSeed != gameHistory.Seed {
+			log.Printf("ОШИБКА: seed не совпадает! Ожидалось: %s (len=%d), получено: %s (len=%d)",
+				gameHistory.Seed, len(gameHistory.Seed), savedSeed, len(savedSeed))
+			if err := tx.Exec(`UPDATE user_game_history SET seed = ? WHERE id = ?`, gameHistory.Seed, gameHistory.ID).Error; err != nil {
+				return fmt.Errorf("fix game seed: %w", err)
 			}
 		}
 
-		if len(participants) > 0 {
-			// Сохраняем участников игры
-			for _, participant := range participants {
-				var colorPtr *string
-				if participant.Color != "" {
-					colorPtr = &participant.Color
-				}
-				gameParticipant := models.GameParticipant{
-					GameHistoryID: gameHistory.ID,
-					UserID:        participant.UserID,
-					Nickname:      participant.Nickname,
-					Color:         colorPtr,
-				}
-				err = h.db.Where("game_history_id = ? AND user_id = ?", gameHistory.ID, participant.UserID).
-					FirstOrCreate(&gameParticipant).Error
-				if err != nil {
-					log.Printf("Error saving game participant: %v", err)
-				}
+		// Сохраняем участников игры
+		for _, participant := range participants {
+			var colorPtr *string
+			if participant.Color != "" {
+				color := participant.Color
+				colorPtr = &color
+			}
+			gameParticipant := models.GameParticipant{
+				GameHistoryID: gameHistory.ID,
+				UserID:        participant.UserID,
+				Nickname:      participant.Nickname,
+				Color:         colorPtr,
+				Score:         participant.Score,
+			}
+			if c := participant.Contribution; c != nil {
+				gameParticipant.CellsRevealed = &c.CellsRevealed
+				gameParticipant.FlagsCorrect = &c.FlagsCorrect
+				gameParticipant.FlagsIncorrect = &c.FlagsIncorrect
+				gameParticipant.Chords = &c.Chords
+				gameParticipant.HintsUsed = &c.HintsUsed
+				gameParticipant.MinesHit = &c.MinesHit
+			}
+			if err := tx.Where("game_history_id = ? AND user_id = ?", gameHistory.ID, participant.UserID).
+				FirstOrCreate(&gameParticipant).Error; err != nil {
+				return fmt.Errorf("save game participant: %w", err)
 			}
 		}
-	}
 
-	if won {
-		// Проверяем, может ли игра дать рейтинг
-		// Если seed был указан пользователем явно, игра нерейтинговая
-		if hasCustomSeed {
-			log.Printf("Игра не дает рейтинг: указан seed=%s (игра нерейтинговая)", seed)
-		} else if !rating.IsRatingEligible(float64(width), float64(height), float64(mines), gameTime) {
-			log.Printf("Игра не дает рейтинг: плотность=%.2f%% (мин. 10%%)",
-				float64(mines)/(float64(width)*float64(height))*100)
-		} else {
-			// Вычисляем рейтинг за игру по формуле: R = K * d / ln(t + 1)
-			gameRating := rating.CalculateGameRating(float64(width), float64(height), float64(mines), gameTime)
+		// Сохраняем запись ходов для воспроизведения
+		if details.Replay != nil {
+			if err := h.saveGameReplay(tx, gameHistory.ID, details.Replay); err != nil {
+				return fmt.Errorf("save game replay: %w", err)
+			}
+		}
 
-			// Если используется Chording, рейтинг умножается на 0.8
-			if chording {
-				gameRating = gameRating * 0.8
-				log.Printf("Chording enabled: рейтинг умножен на 0.8")
+		if err := h.updateGameStats(tx, userID, won, details.Completed); err != nil {
+			return fmt.Errorf("update game stats: %w", err)
+		}
+
+		// Рейтинг Эло обновляется параллельно со старой системой после каждой рейтинговой победы
+		// Победы с потерянными жизнями не сравниваются по времени с обычными играми
+		eloChanged := false
+		if won && !hasCustomSeed && details.LivesLost == 0 {
+			changed, err := h.updateEloRating(tx, userID, &gameHistory)
+			if err != nil {
+				return fmt.Errorf("update elo rating: %w", err)
 			}
+			eloChanged = changed
+		}
 
-			// Если используется QuickStart, рейтинг умножается на 0.9
-			if quickStart {
-				gameRating = gameRating * 0.9
-				log.Printf("QuickStart enabled: рейтинг умножен на 0.9")
+		if gameRating > 0 || eloChanged {
+			if err := h.refreshUserRating(tx, userID, gameHistory.ID); err != nil {
+				return fmt.Errorf("update user rating: %w", err)
 			}
+		}
 
-			log.Printf("Field %dx%d with %d mines, time=%.2f, chording=%v, quickStart=%v: gameRating=%.2f",
-				width, height, mines, gameTime, chording, quickStart, gameRating)
-			// Рейтинг теперь рассчитывается динамически как сумма лучших игр, не сохраняем в БД
+		earned, err := h.evaluateAchievements(tx, &gameHistory, achievements.GameResult{
+			Width:         width,
+			Height:        height,
+			Mines:         mines,
+			GameTime:      gameTime,
+			Won:           won,
+			HasCustomSeed: hasCustomSeed,
+			GameMode:      gameMode,
+			HintsUsed:     details.HintsUsed,
+			FlagsPlaced:   details.FlagsPlaced,
+			Participants:  len(participants),
+		})
+		if err != nil {
+			return fmt.Errorf("evaluate achievements: %w", err)
 		}
-	} else {
-		// For lost games, don't update rating
-		log.Printf("Game lost - no rating update")
+		unlocked = earned
+
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error recording game result: %v", err)
+		return nil, err
 	}
 
-	// Update game statistics
-	return h.updateGameStats(userID, won)
+	// Инвалидируем кеш статистики, профиля и лидерборда
+	h.cache.Delete(fmt.Sprintf("stats:%d", userID))
+	h.cache.Delete(fmt.Sprintf("profile:%d", userID))
+	h.cache.Invalidate("leaderboard")
+
+	return unlocked, nil
+}
+
+// updateEloRating пересчитывает рейтинг Эло игрока после победы и сохраняет его вместе с игрой
+// Rpl' = Rpl + K * DF * (S - E), где Rp - рейтинг поля, S - оценка по времени, E - ожидаемый результат
+// Возвращает true, если рейтинг изменился
+func (h *ProfileHandler) updateEloRating(tx *gorm.DB, userID int, gameHistory *models.UserGameHistory) (bool, error) {
+	var stats models.UserStats
+	if err := tx.Where("user_id = ?", userID).First(&stats).Error; err != nil {
+		return false, err
+	}
+
+	currentRating := stats.EloRating
+	if currentRating == 0 {
+		currentRating = rating.Rref
+	}
+
+	result, ok := rating.CalculateEloUpdate(currentRating,
+		float64(gameHistory.Width), float64(gameHistory.Height), float64(gameHistory.Mines), gameHistory.GameTime)
+	if !ok {
+		log.Printf("Игра не меняет рейтинг Эло: сложность поля %dx%d/%d недостаточна",
+			gameHistory.Width, gameHistory.Height, gameHistory.Mines)
+		return false, nil
+	}
+
+	if err := tx.Model(&models.UserStats{}).
+		Where("user_id = ?", userID).
+		Update("elo_rating", result.NewRating).Error; err != nil {
+		return false, err
+	}
+
+	gameHistory.EloRating = &result.NewRating
+	gameHistory.EloDelta = result.Delta
+	if err := tx.Model(&models.UserGameHistory{}).
+		Where("id = ?", gameHistory.ID).
+		Updates(map[string]interface{}{
+			"elo_rating": result.NewRating,
+			"elo_delta":  result.Delta,
+		}).Error; err != nil {
+		return false, err
+	}
+
+	log.Printf("Рейтинг Эло обновлен: userID=%d, Rp=%.1f, Texp=%.1f, S=%.3f, E=%.3f, DF=%.3f, %.1f -> %.1f (%+.1f)",
+		userID, result.PuzzleRating, result.ExpectedTime, result.Score, result.Expected, result.DifficultyFactor,
+		currentRating, result.NewRating, result.Delta)
+	return true, nil
 }
 
 // updateGameStats обновляет статистику игр пользователя
-func (h *ProfileHandler) updateGameStats(userID int, won bool) error {
+// completed - игра окончена без победы и поражения: считается сыгранной, но не проигранной
+func (h *ProfileHandler) updateGameStats(tx *gorm.DB, userID int, won, completed bool) error {
 	stats := models.UserStats{UserID: userID}
-	if err := h.db.Where("user_id = ?", userID).FirstOrCreate(&stats).Error; err != nil {
+	if err := tx.Where("user_id = ?", userID).FirstOrCreate(&stats).Error; err != nil {
 		return err
 	}
 
@@ -536,22 +660,13 @@ func (h *ProfileHandler) updateGameStats(userID int, won bool) error {
 
 	if won {
 		updates["games_won"] = gorm.Expr("games_won + ?", 1)
-	} else {
+	} else if !completed {
 		updates["games_lost"] = gorm.Expr("games_lost + ?", 1)
 	}
 
-	err := h.db.Model(&models.UserStats{}).
+	return tx.Model(&models.UserStats{}).
 		Where("user_id = ?", userID).
 		Updates(updates).Error
-
-	// Инвалидируем кеш статистики, профиля и лидерборда
-	if err == nil {
-		h.cache.Delete(fmt.Sprintf("stats:%d", userID))
-		h.cache.Delete(fmt.Sprintf("profile:%d", userID))
-		h.cache.Delete("leaderboard") // Лидерборд зависит от статистики
-	}
-
-	return err
 }
 
 // FindUserByID находит пользователя по ID
@@ -647,75 +762,6 @@ func (h *ProfileHandler) createUserStats(userID int) (models.UserStats, error) {
 	return stats, nil
 }
 
-// LeaderboardEntry представляет запись в лидерборде
-type LeaderboardEntry struct {
-	ID          int     `json:"id"`
-	Username    string  `json:"username"`
-	Color       string  `json:"color,omitempty"`
-	Rating      float64 `json:"rating"`
-	GamesPlayed int     `json:"gamesPlayed"`
-	GamesWon    int     `json:"gamesWon"`
-	GamesLost   int     `json:"gamesLost"`
-}
-
-// GetLeaderboard возвращает список всех игроков, отсортированных по рейтингу
-func (h *ProfileHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
-	// Проверяем кеш (лидерборд кешируем на 1 минуту)
-	cacheKey := "leaderboard"
-	if cached, found := h.cache.Get(cacheKey); found {
-		if leaderboard, ok := cached.([]LeaderboardEntry); ok {
-			utils.JSONResponse(w, http.StatusOK, leaderboard)
-			return
-		}
-	}
-
-	var users []models.User
-	err := h.db.Order("username ASC").Find(&users).Error
-	if err != nil {
-		log.Printf("Error getting leaderboard: %v", err)
-		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
-		return
-	}
-
-	var leaderboard []LeaderboardEntry
-	for _, user := range users {
-		// Рассчитываем рейтинг динамически для каждого пользователя
-		userRating := h.calculateUserRating(user.ID, 100)
-
-		entry := LeaderboardEntry{
-			ID:       user.ID,
-			Username: user.Username,
-			Rating:   userRating,
-		}
-		if user.Color != nil {
-			entry.Color = *user.Color
-		}
-
-		// Получаем статистику пользователя (используем кешированный метод)
-		stats, err := h.getUserStats(user.ID)
-		if err == nil {
-			entry.GamesPlayed = stats.GamesPlayed
-			entry.GamesWon = stats.GamesWon
-			entry.GamesLost = stats.GamesLost
-		}
-
-		leaderboard = append(leaderboard, entry)
-	}
-
-	// Сортируем по рейтингу (по убыванию), затем по username
-	sort.Slice(leaderboard, func(i, j int) bool {
-		if leaderboard[i].Rating != leaderboard[j].Rating {
-			return leaderboard[i].Rating > leaderboard[j].Rating
-		}
-		return leaderboard[i].Username < leaderboard[j].Username
-	})
-
-	// Сохраняем в кеш (используем основной кеш, но лидерборд обновляется часто)
-	h.cache.Set(cacheKey, leaderboard)
-
-	utils.JSONResponse(w, http.StatusOK, leaderboard)
-}
-
 // getUserIDFromRequest получает userID из запроса (из username параметра или контекста)
 func (h *ProfileHandler) getUserIDFromRequest(r *http.Request) (int, error) {
 	username := r.URL.Query().Get("username")
@@ -756,12 +802,17 @@ func (h *ProfileHandler) GetTopGames(w http.ResponseWriter, r *http.Request) {
 		Rating            float64 `json:"rating"`            // Рейтинг игры (до применения коэффициента)
 		RatingPercent     float64 `json:"ratingPercent"`     // Процент засчитанного рейтинга (0.95^позиция * 100)
 		RatingContributed float64 `json:"ratingContributed"` // Конкретно полученный рейтинг (рейтинг * коэффициент)
+		EloRating         float64 `json:"eloRating,omitempty"` // Рейтинг Эло после игры
+		EloDelta          float64 `json:"eloDelta"`            // Изменение рейтинга Эло за игру
 		Won               bool    `json:"won"`
 		CreatedAt         string  `json:"createdAt"`
+		GameClickStats
 	}
 
 	var historyRecords []models.UserGameHistory
-	err = h.db.Where("user_id = ?", userID).
+	err = h.db.Where("user_id = ? AND rating > 0", userID).
+		Order("rating DESC").
+		Limit(topRatedGamesCount).
 		Find(&historyRecords).Error
 
 	if err != nil {
@@ -772,22 +823,25 @@ func (h *ProfileHandler) GetTopGames(w http.ResponseWriter, r *http.Request) {
 
 	var games []GameHistory
 	for _, record := range historyRecords {
-		// Рассчитываем рейтинг только для выигранных игр без явно указанного seed
-		var gameRating float64
-		if record.Won && !record.HasCustomSeed {
-			gameRating = h.calculateGameRating(record.Width, record.Height, record.Mines, record.GameTime, record.Chording, record.QuickStart)
-		}
+		// Рейтинг игры сохраняется при записи результата (0 для нерейтинговых игр)
+		gameRating := record.Rating
 
-		games = append(games, GameHistory{
+		game := GameHistory{
 			ID:        record.ID,
 			Width:     record.Width,
 			Height:    record.Height,
 			Mines:     record.Mines,
 			GameTime:  record.GameTime,
 			Rating:    gameRating,
+			EloDelta:  record.EloDelta,
 			Won:       record.Won,
 			CreatedAt: record.CreatedAt.Format(time.RFC3339),
-		})
+			GameClickStats: newGameClickStats(record),
+		}
+		if record.EloRating != nil {
+			game.EloRating = *record.EloRating
+		}
+		games = append(games, game)
 	}
 
 	// Фильтруем только выигранные игры с рейтингом > 0
@@ -837,6 +891,7 @@ func (h *ProfileHandler) GetRecentGames(w http.ResponseWriter, r *http.Request)
 		UserID   int    `json:"userId"`
 		Nickname string `json:"nickname"`
 		Color    string `json:"color,omitempty"`
+		Score    *int   `json:"score,omitempty"`
 	}
 
 	type RecentGame struct {
@@ -846,9 +901,13 @@ func (h *ProfileHandler) GetRecentGames(w http.ResponseWriter, r *http.Request)
 		Mines        int                   `json:"mines"`
 		GameTime     float64               `json:"gameTime"`
 		Rating       float64               `json:"rating"`
+		EloRating    float64               `json:"eloRating,omitempty"`
+		EloDelta     float64               `json:"eloDelta"`
 		Won          bool                  `json:"won"`
+		Completed    bool                  `json:"completed,omitempty"` // Игра окончена без победы и поражения
 		CreatedAt    string                `json:"createdAt"`
 		Participants []GameParticipantInfo `json:"participants"`
+		GameClickStats
 	}
 
 	var historyRecords []models.UserGameHistory
@@ -865,11 +924,8 @@ func (h *ProfileHandler) GetRecentGames(w http.ResponseWriter, r *http.Request)
 
 	var games []RecentGame
 	for _, record := range historyRecords {
-		// Рассчитываем рейтинг только для выигранных игр без явно указанного seed
-		var gameRating float64
-		if record.Won && !record.HasCustomSeed {
-			gameRating = h.calculateGameRating(record.Width, record.Height, record.Mines, record.GameTime, record.Chording, record.QuickStart)
-		}
+		// Рейтинг игры сохраняется при записи результата (0 для нерейтинговых игр)
+		gameRating := record.Rating
 
 		game := RecentGame{
 			ID:           record.ID,
@@ -878,9 +934,15 @@ func (h *ProfileHandler) GetRecentGames(w http.ResponseWriter, r *http.Request)
 			Mines:        record.Mines,
 			GameTime:     record.GameTime,
 			Rating:       gameRating,
+			EloDelta:     record.EloDelta,
 			Won:          record.Won,
+			Completed:    record.Completed,
 			CreatedAt:    record.CreatedAt.Format(time.RFC3339),
 			Participants: []GameParticipantInfo{},
+			GameClickStats: newGameClickStats(record),
+		}
+		if record.EloRating != nil {
+			game.EloRating = *record.EloRating
 		}
 
 		// Получаем участников игры
@@ -891,6 +953,7 @@ func (h *ProfileHandler) GetRecentGames(w http.ResponseWriter, r *http.Request)
 				participant := GameParticipantInfo{
 					UserID:   p.UserID,
 					Nickname: p.Nickname,
+					Score:    p.Score,
 				}
 				if p.Color != nil {
 					participant.Color = *p.Color
@@ -937,11 +1000,29 @@ func (h *ProfileHandler) GetGameDetails(w http.ResponseWriter, r *http.Request)
 	h.db.Where("game_history_id = ?", gameHistory.ID).Find(&participants)
 
 	// Получаем информацию о пользователях-участниках
+	type ParticipantContribution struct {
+		CellsRevealed  int `json:"cellsRevealed"`
+		FlagsCorrect   int `json:"flagsCorrect"`
+		FlagsIncorrect int `json:"flagsIncorrect"`
+		Chords         int `json:"chords"`
+		HintsUsed      int `json:"hintsUsed"`
+		MinesHit       int `json:"minesHit"`
+	}
+
 	type ParticipantInfo struct {
-		UserID   int    `json:"userId"`
-		Username string `json:"username"`
-		Nickname string `json:"nickname"`
-		Color    string `json:"color,omitempty"`
+		UserID       int                      `json:"userId"`
+		Username     string                   `json:"username"`
+		Nickname     string                   `json:"nickname"`
+		Color        string                   `json:"color,omitempty"`
+		Score        *int                     `json:"score,omitempty"`
+		Contribution *ParticipantContribution `json:"contribution,omitempty"` // Вклад в совместную игру
+	}
+
+	intValue := func(v *int) int {
+		if v == nil {
+			return 0
+		}
+		return *v
 	}
 
 	participantInfos := make([]ParticipantInfo, 0, len(participants))
@@ -952,26 +1033,27 @@ func (h *ProfileHandler) GetGameDetails(w http.ResponseWriter, r *http.Request)
 				UserID:   p.UserID,
 				Username: user.Username,
 				Nickname: p.Nickname,
+				Score:    p.Score,
 			}
 			if p.Color != nil {
 				participantInfo.Color = *p.Color
 			}
+			if p.CellsRevealed != nil {
+				participantInfo.Contribution = &ParticipantContribution{
+					CellsRevealed:  *p.CellsRevealed,
+					FlagsCorrect:   intValue(p.FlagsCorrect),
+					FlagsIncorrect: intValue(p.FlagsIncorrect),
+					Chords:         intValue(p.Chords),
+					HintsUsed:      intValue(p.HintsUsed),
+					MinesHit:       intValue(p.MinesHit),
+				}
+			}
 			participantInfos = append(participantInfos, participantInfo)
 		}
 	}
 
-	// Рассчитываем рейтинг игры
-	var gameRating float64
-	if gameHistory.Won && !gameHistory.HasCustomSeed {
-		gameRating = h.calculateGameRating(
-			gameHistory.Width,
-			gameHistory.Height,
-			gameHistory.Mines,
-			gameHistory.GameTime,
-			gameHistory.Chording,
-			gameHistory.QuickStart,
-		)
-	}
+	// Рейтинг игры сохраняется при записи результата
+	gameRating := gameHistory.Rating
 
 	// Формируем ответ
 	type GameDetailsResponse struct {
@@ -985,12 +1067,20 @@ func (h *ProfileHandler) GetGameDetails(w http.ResponseWriter, r *http.Request)
 		CreatorID     int               `json:"creatorId"`
 		CreatorName   string            `json:"creatorName"`
 		Won           bool              `json:"won"`
+		Completed     bool              `json:"completed,omitempty"` // Игра окончена без победы и поражения
 		Chording      bool              `json:"chording"`
 		QuickStart    bool              `json:"quickStart"`
+		NoGuess       bool              `json:"noGuess"`
+		Placement     int               `json:"placement,omitempty"`
+		LivesLost     int               `json:"livesLost,omitempty"`
+		Score         int               `json:"score,omitempty"`
 		StartTime     string            `json:"startTime"`
 		Duration      float64           `json:"duration"`
 		Rating        float64           `json:"rating"`
+		EloRating     float64           `json:"eloRating,omitempty"`
+		EloDelta      float64           `json:"eloDelta"`
 		Participants  []ParticipantInfo `json:"participants"`
+		GameClickStats
 	}
 
 	response := GameDetailsResponse{
@@ -1004,12 +1094,22 @@ func (h *ProfileHandler) GetGameDetails(w http.ResponseWriter, r *http.Request)
 		CreatorID:     gameHistory.CreatorID,
 		CreatorName:   creator.Username,
 		Won:           gameHistory.Won,
+		Completed:     gameHistory.Completed,
 		Chording:      gameHistory.Chording,
 		QuickStart:    gameHistory.QuickStart,
+		NoGuess:       gameHistory.NoGuess,
+		Placement:     gameHistory.Placement,
+		LivesLost:     gameHistory.LivesLost,
+		Score:         gameHistory.Score,
 		StartTime:     gameHistory.CreatedAt.Format(time.RFC3339),
 		Duration:      gameHistory.GameTime,
 		Rating:        gameRating,
+		EloDelta:      gameHistory.EloDelta,
 		Participants:  participantInfos,
+		GameClickStats: newGameClickStats(gameHistory),
+	}
+	if gameHistory.EloRating != nil {
+		response.EloRating = *gameHistory.EloRating
 	}
 
 	utils.JSONResponse(w, http.StatusOK, response)
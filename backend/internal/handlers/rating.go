@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"minesweeperonline/internal/models"
+	"minesweeperonline/internal/rating"
+	"minesweeperonline/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// topRatedGamesCount количество лучших игр, учитываемых в рейтинге игрока
+const topRatedGamesCount = 100
+
+// weightedRatingSum суммирует рейтинги игр (отсортированные по убыванию) с весовыми коэффициентами
+// Первая игра (лучшая) дает 100% рейтинга (коэффициент 1.0)
+// Вторая - 95% (0.95)
+// Третья - 90.25% (0.95^2)
+// N-я игра дает 0.95^(n-1) процентов
+func weightedRatingSum(ratings []float64) float64 {
+	total := 0.0
+	for i, r := range ratings {
+		total += r * math.Pow(0.95, float64(i))
+	}
+	return total
+}
+
+// calculateUserRating рассчитывает рейтинг пользователя как сумму рейтинга лучших игр
+// Использует сохраненный рейтинг каждой игры и индекс (user_id, rating)
+func (h *ProfileHandler) calculateUserRating(tx *gorm.DB, userID int) (float64, int, error) {
+	var ratings []float64
+	if err := tx.Model(&models.UserGameHistory{}).
+		Where("user_id = ? AND rating > 0", userID).
+		Order("rating DESC").
+		Limit(topRatedGamesCount).
+		Pluck("rating", &ratings).Error; err != nil {
+		return 0, 0, err
+	}
+
+	var ratedGames int64
+	if err := tx.Model(&models.UserGameHistory{}).
+		Where("user_id = ? AND rating > 0", userID).
+		Count(&ratedGames).Error; err != nil {
+		return 0, 0, err
+	}
+
+	return weightedRatingSum(ratings), int(ratedGames), nil
+}
+
+// refreshUserRating пересчитывает материализованный рейтинг игрока и сохраняет снимок после рейтинговой игры
+// ВАЖНО: вызывается внутри транзакции записи результата игры
+func (h *ProfileHandler) refreshUserRating(tx *gorm.DB, userID int, gameHistoryID int) error {
+	userRating, ratedGames, err := h.calculateUserRating(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	record := models.UserRating{
+		UserID:     userID,
+		Rating:     userRating,
+		RatedGames: ratedGames,
+		UpdatedAt:  now,
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rating", "rated_games", "updated_at"}),
+	}).Create(&record).Error; err != nil {
+		return err
+	}
+
+	var eloRating float64
+	if err := tx.Model(&models.UserStats{}).Where("user_id = ?", userID).Pluck("elo_rating", &eloRating).Error; err != nil {
+		return err
+	}
+
+	snapshot := models.RatingSnapshot{
+		UserID:        userID,
+		GameHistoryID: gameHistoryID,
+		Rating:        userRating,
+		EloRating:     eloRating,
+		CreatedAt:     now,
+	}
+	if err := tx.Create(&snapshot).Error; err != nil {
+		return err
+	}
+
+	log.Printf("Рейтинг пользователя обновлен: userID=%d, rating=%.2f, eloRating=%.1f, ratedGames=%d", userID, userRating, eloRating, ratedGames)
+	return nil
+}
+
+// getUserRating возвращает материализованный рейтинг пользователя
+func (h *ProfileHandler) getUserRating(userID int) float64 {
+	var record models.UserRating
+	err := h.db.Where("user_id = ?", userID).First(&record).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Error getting user rating: %v", err)
+		}
+		return 0.0
+	}
+	return record.Rating
+}
+
+// BackfillRatings заполняет рейтинг игр, user_ratings и rating_snapshots по существующей истории
+// Выполняется один раз, пока таблица user_ratings пуста
+func (h *ProfileHandler) BackfillRatings() error {
+	var existing int64
+	if err := h.db.Model(&models.UserRating{}).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	log.Println("Заполнение user_ratings и rating_snapshots по истории игр")
+
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		var users []models.User
+		if err := tx.Select("id").Find(&users).Error; err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			var records []models.UserGameHistory
+			if err := tx.Where("user_id = ? AND won = ? AND has_custom_seed = ?", user.ID, true, false).
+				Order("created_at ASC").
+				Find(&records).Error; err != nil {
+				return err
+			}
+
+			// Восстанавливаем кривую рейтинга в хронологическом порядке
+			var ratings []float64
+			for _, record := range records {
+				gameRating := record.Rating
+				if gameRating == 0 {
+					gameRating = h.calculateGameRating(record.Width, record.Height, record.Mines, record.GameTime, record.Chording, record.QuickStart, record.NoGuess, record.LivesLost)
+					if gameRating > 0 {
+						if err := tx.Model(&models.UserGameHistory{}).Where("id = ?", record.ID).Update("rating", gameRating).Error; err != nil {
+							return err
+						}
+					}
+				}
+				if gameRating <= 0 && record.EloRating == nil {
+					continue
+				}
+
+				if gameRating > 0 {
+					ratings = insertSortedDesc(ratings, gameRating)
+				}
+				top := ratings
+				if len(top) > topRatedGamesCount {
+					top = top[:topRatedGamesCount]
+				}
+
+				snapshot := models.RatingSnapshot{
+					UserID:        user.ID,
+					GameHistoryID: record.ID,
+					Rating:        weightedRatingSum(top),
+					EloRating:     rating.Rref,
+					CreatedAt:     record.CreatedAt,
+				}
+				if record.EloRating != nil {
+					snapshot.EloRating = *record.EloRating
+				}
+				if err := tx.Create(&snapshot).Error; err != nil {
+					return err
+				}
+			}
+
+			userRating, ratedGames, err := h.calculateUserRating(tx, user.ID)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&models.UserRating{
+				UserID:     user.ID,
+				Rating:     userRating,
+				RatedGames: ratedGames,
+				UpdatedAt:  time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		log.Printf("user_ratings заполнена для %d пользователей", len(users))
+		return nil
+	})
+}
+
+// insertSortedDesc вставляет значение в срез, отсортированный по убыванию
+func insertSortedDesc(values []float64, value float64) []float64 {
+	i := 0
+	for i < len(values) && values[i] >= value {
+		i++
+	}
+	values = append(values, 0)
+	copy(values[i+1:], values[i:])
+	values[i] = value
+	return values
+}
+
+// RatingHistoryPoint точка на графике рейтинга игрока
+type RatingHistoryPoint struct {
+	GameID    int     `json:"gameId"`
+	Rating    float64 `json:"rating"`
+	EloRating float64 `json:"eloRating"`
+	CreatedAt string  `json:"createdAt"`
+}
+
+// GetRatingHistory возвращает изменение рейтинга игрока после каждой рейтинговой игры
+func (h *ProfileHandler) GetRatingHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromRequest(r)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.JSONError(w, http.StatusNotFound, "User not found")
+		} else {
+			utils.JSONError(w, http.StatusUnauthorized, "Unauthorized")
+		}
+		return
+	}
+
+	var snapshots []models.RatingSnapshot
+	if err := h.db.Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&snapshots).Error; err != nil {
+		log.Printf("Error querying rating history: %v", err)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	points := make([]RatingHistoryPoint, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		points = append(points, RatingHistoryPoint{
+			GameID:    snapshot.GameHistoryID,
+			Rating:    snapshot.Rating,
+			EloRating: snapshot.EloRating,
+			CreatedAt: snapshot.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	utils.JSONResponse(w, http.StatusOK, points)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"minesweeperonline/internal/game"
+	"minesweeperonline/internal/models"
+	"minesweeperonline/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// saveGameReplay сохраняет запись ходов игры рядом с записью истории
+func (h *ProfileHandler) saveGameReplay(tx *gorm.DB, gameHistoryID int, replay *game.GameReplay) error {
+	data, err := json.Marshal(replay)
+	if err != nil {
+		return err
+	}
+
+	gameReplay := models.GameReplay{
+		GameHistoryID: gameHistoryID,
+		Data:          data,
+		CreatedAt:     time.Now(),
+	}
+	if err := tx.Create(&gameReplay).Error; err != nil {
+		return err
+	}
+
+	log.Printf("Запись игры сохранена: gameHistoryID=%d, ходов=%d, размер=%d байт", gameHistoryID, len(replay.Moves), len(data))
+	return nil
+}
+
+// GetGameReplay возвращает запись ходов игры для пошагового воспроизведения
+func (h *ProfileHandler) GetGameReplay(w http.ResponseWriter, r *http.Request) {
+	gameID := r.URL.Query().Get("id")
+	if gameID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "Game ID parameter is required")
+		return
+	}
+
+	var gameHistory models.UserGameHistory
+	if err := h.db.First(&gameHistory, gameID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.JSONError(w, http.StatusNotFound, "Game not found")
+		} else {
+			log.Printf("Error getting game for replay: %v", err)
+			utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	var gameReplay models.GameReplay
+	if err := h.db.Where("game_history_id = ?", gameHistory.ID).First(&gameReplay).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.JSONError(w, http.StatusNotFound, "Replay not found")
+		} else {
+			log.Printf("Error getting game replay: %v", err)
+			utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	var replay game.GameReplay
+	if err := json.Unmarshal(gameReplay.Data, &replay); err != nil {
+		log.Printf("Error decoding game replay %d: %v", gameHistory.ID, err)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	type GameReplayResponse struct {
+		ID         int         `json:"id"`
+		Width      int         `json:"width"`
+		Height     int         `json:"height"`
+		Mines      int         `json:"mines"`
+		Seed       string      `json:"seed"`
+		Won        bool        `json:"won"`
+		NoGuess    bool        `json:"noGuess"` // Поле без угадываний: восстанавливается по seed и первому ходу
+		Duration   float64     `json:"duration"`
+		StartTime  string      `json:"startTime"`
+		Moves      []game.Move `json:"moves"`
+		MineLayout [][2]int    `json:"mineLayout"`
+	}
+
+	utils.JSONResponse(w, http.StatusOK, GameReplayResponse{
+		ID:         gameHistory.ID,
+		Width:      gameHistory.Width,
+		Height:     gameHistory.Height,
+		Mines:      gameHistory.Mines,
+		Seed:       gameHistory.Seed,
+		Won:        gameHistory.Won,
+		NoGuess:    gameHistory.NoGuess,
+		Duration:   gameHistory.GameTime,
+		StartTime:  gameHistory.CreatedAt.Format(time.RFC3339),
+		Moves:      replay.Moves,
+		MineLayout: replay.MineLayout,
+	})
+}
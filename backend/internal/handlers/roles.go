@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"minesweeperonline/internal/auth"
+	"minesweeperonline/internal/database"
+	"minesweeperonline/internal/models"
+	"minesweeperonline/internal/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewRoleLoader возвращает загрузку ролей пользователя для auth.SetRoleLoader
+// Роли читаются при каждой проверке, поэтому выдача и отзыв роли действуют сразу
+func NewRoleLoader(db *database.DB) func(userID int) ([]string, error) {
+	return func(userID int) ([]string, error) {
+		var roles []string
+		err := db.Model(&models.UserRole{}).
+			Where("user_id = ?", userID).
+			Pluck("role_name", &roles).Error
+		if err != nil {
+			return nil, err
+		}
+		return append(roles, auth.RoleUser), nil
+	}
+}
+
+// GrantAdminByEmail выдает роль admin пользователю с указанным email (ADMIN_EMAIL при запуске)
+func GrantAdminByEmail(db *database.DB, email string) error {
+	if email == "" {
+		return nil
+	}
+	var user models.User
+	err := db.Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Admin user with email %s not found, admin role not granted", email)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserRole{
+		UserID:    user.ID,
+		RoleName:  auth.RoleAdmin,
+		GrantedAt: time.Now(),
+	}).Error
+}
+
+// userRoles возвращает роли пользователя; при ошибке - только базовую роль
+func (h *AuthHandler) userRoles(userID int) []string {
+	roles, err := auth.UserRoles(userID)
+	if err != nil {
+		log.Printf("Error loading roles for user %d: %v", userID, err)
+		return []string{auth.RoleUser}
+	}
+	return roles
+}
+
+// GetRoles возвращает пользователей с выданными ролями
+func (h *AuthHandler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	var rows []struct {
+		UserID    int
+		Username  string
+		Email     string
+		RoleName  string
+		GrantedAt time.Time
+	}
+	err := h.db.Table("user_roles").
+		Select("user_roles.user_id, users.username, users.email, user_roles.role_name, user_roles.granted_at").
+		Joins("JOIN users ON users.id = user_roles.user_id").
+		Order("users.username, user_roles.role_name").
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("Error loading user roles: %v", err)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	type UserRolesResponse struct {
+		UserID   int      `json:"userId"`
+		Username string   `json:"username"`
+		Email    string   `json:"email"`
+		Roles    []string `json:"roles"`
+	}
+	response := make([]UserRolesResponse, 0)
+	for _, row := range rows {
+		if n := len(response); n > 0 && response[n-1].UserID == row.UserID {
+			response[n-1].Roles = append(response[n-1].Roles, row.RoleName)
+			continue
+		}
+		response = append(response, UserRolesResponse{
+			UserID:   row.UserID,
+			Username: row.Username,
+			Email:    row.Email,
+			Roles:    []string{row.RoleName},
+		})
+	}
+
+	utils.JSONResponse(w, http.StatusOK, response)
+}
+
+// GrantRole выдает пользователю роль
+func (h *AuthHandler) GrantRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" {
+		utils.JSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	adminID := r.Context().Value("userID").(int)
+	target, role, ok := h.roleRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserRole{
+		UserID:    target.ID,
+		RoleName:  role,
+		GrantedBy: &adminID,
+		GrantedAt: time.Now(),
+	}).Error
+	if err != nil {
+		log.Printf("Error granting role %s to user %d: %v", role, target.ID, err)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	log.Printf("User %d granted role %s to user %s (ID: %d)", adminID, role, target.Username, target.ID)
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"userId": target.ID,
+		"roles":  h.userRoles(target.ID),
+	})
+}
+
+// RevokeRole отзывает у пользователя роль
+func (h *AuthHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "DELETE" {
+		utils.JSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	adminID := r.Context().Value("userID").(int)
+	target, role, ok := h.roleRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Администратор не может лишить прав сам себя, чтобы не остаться без администраторов
+	if target.ID == adminID && role == auth.RoleAdmin {
+		utils.JSONError(w, http.StatusBadRequest, "Cannot revoke your own admin role")
+		return
+	}
+
+	result := h.db.Where("user_id = ? AND role_name = ?", target.ID, role).Delete(&models.UserRole{})
+	if result.Error != nil {
+		log.Printf("Error revoking role %s from user %d: %v", role, target.ID, result.Error)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.JSONError(w, http.StatusNotFound, "User does not have this role")
+		return
+	}
+
+	log.Printf("User %d revoked role %s from user %s (ID: %d)", adminID, role, target.Username, target.ID)
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"userId": target.ID,
+		"roles":  h.userRoles(target.ID),
+	})
+}
+
+// roleRequest разбирает /admin/users/{username}/roles/{role}
+// При ошибке отправляет ответ и возвращает false
+func (h *AuthHandler) roleRequest(w http.ResponseWriter, r *http.Request) (models.User, string, bool) {
+	vars := mux.Vars(r)
+	role := vars["role"]
+	if !auth.ValidRole(role) {
+		utils.JSONError(w, http.StatusBadRequest, "Unknown role")
+		return models.User{}, "", false
+	}
+	if role == auth.RoleUser {
+		utils.JSONError(w, http.StatusBadRequest, "Role user cannot be granted or revoked")
+		return models.User{}, "", false
+	}
+
+	target, err := h.findUserByUsername(vars["username"])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.JSONError(w, http.StatusNotFound, "User not found")
+		} else {
+			log.Printf("Error finding user: %v", err)
+			utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return models.User{}, "", false
+	}
+	return target, role, true
+}
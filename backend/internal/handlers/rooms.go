@@ -1,23 +1,32 @@
 package handlers
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/gorilla/mux"
 
+	"minesweeperonline/internal/auth"
 	"minesweeperonline/internal/game"
 	"minesweeperonline/internal/utils"
 )
 
 type RoomHandler struct {
 	roomManager *game.RoomManager
+	closeRoom   func(roomID string) // Отключает игроков удаляемой комнаты
 }
 
 func NewRoomHandler(roomManager *game.RoomManager) *RoomHandler {
 	return &RoomHandler{roomManager: roomManager}
 }
 
+// SetRoomCloser задает отключение игроков комнаты при ее удалении
+// Задается после создания WebSocket менеджера, который создается позже обработчика
+func (h *RoomHandler) SetRoomCloser(closeRoom func(roomID string)) {
+	h.closeRoom = closeRoom
+}
+
 func (h *RoomHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		utils.JSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
@@ -33,6 +42,14 @@ func (h *RoomHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 		GameMode   string `json:"gameMode"`
 		QuickStart bool   `json:"quickStart"`
 		Chording   bool   `json:"chording"`
+		NoGuess    bool   `json:"noGuess"`           // Без угадываний (только для classic)
+		Lives      int    `json:"lives"`             // Жизни вместо конца игры на первой мине (только для classic)
+		PerPlayerLives bool `json:"perPlayerLives"` // Жизни у каждого игрока свои
+		ClockMode  string `json:"clockMode"`         // Игра на время: "deadline" или "score" (не для race, versus и turns)
+		TimeLimit  int    `json:"timeLimit"`         // Ограничение времени в секундах
+		TurnTimeout int   `json:"turnTimeout"`       // Время на ход в секундах (только для turns)
+		NoSpectators bool `json:"noSpectators"`      // Запретить зрителей
+		MaxSpectators int `json:"maxSpectators"`     // Наибольшее число зрителей (0 - без ограничения)
 		Seed       *string `json:"seed,omitempty"` // Опциональный seed (UUID)
 	}
 
@@ -56,10 +73,46 @@ func (h *RoomHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 
 	// Валидация gameMode
 	gameMode := req.GameMode
-	if gameMode != "classic" && gameMode != "training" && gameMode != "fair" {
+	if gameMode != "classic" && gameMode != "training" && gameMode != "fair" && gameMode != "race" && gameMode != "versus" && gameMode != "turns" {
 		gameMode = "classic" // По умолчанию
 	}
 
+	// Режим без угадываний имеет смысл только для classic: в training и fair мины расставляются динамически
+	noGuess := req.NoGuess && gameMode == "classic"
+	if noGuess {
+		if err := game.ValidateNoGuess(req.Rows, req.Cols, req.Mines); err != nil {
+			utils.JSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	// Жизни тоже только для classic: в training и fair подорванная мина не осталась бы на месте
+	if req.Lives < 0 || req.Lives > game.MaxLives {
+		utils.JSONError(w, http.StatusBadRequest, fmt.Sprintf("Lives must be between 0 and %d", game.MaxLives))
+		return
+	}
+	lives, perPlayerLives := 0, false
+	if gameMode == "classic" && req.Lives > 1 {
+		lives, perPlayerLives = req.Lives, req.PerPlayerLives
+	}
+
+	clockMode, timeLimit, err := parseClockMode(req.ClockMode, req.TimeLimit, gameMode)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	turnTimeout, err := parseTurnTimeout(req.TurnTimeout, gameMode)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.MaxSpectators < 0 || req.MaxSpectators > game.MaxSpectatorsLimit {
+		utils.JSONError(w, http.StatusBadRequest, fmt.Sprintf("Max spectators must be between 0 and %d", game.MaxSpectatorsLimit))
+		return
+	}
+
 	var seed string = ""
 	if req.Seed != nil && *req.Seed != "" {
 		seed = *req.Seed
@@ -67,9 +120,9 @@ func (h *RoomHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	} else {
 		log.Printf("CreateRoom: seed не указан, будет сгенерирован автоматически")
 	}
-	room := h.roomManager.CreateRoom(req.Name, req.Password, req.Rows, req.Cols, req.Mines, creatorID, gameMode, req.QuickStart, req.Chording, seed)
+	room := h.roomManager.CreateRoom(req.Name, req.Password, req.Rows, req.Cols, req.Mines, creatorID, gameMode, req.QuickStart, req.Chording, noGuess, lives, perPlayerLives, clockMode, timeLimit, turnTimeout, req.NoSpectators, req.MaxSpectators, seed)
 	log.Printf("CreateRoom: после создания комнаты GameState.Seed=%s (len=%d)", room.GameState.Seed, len(room.GameState.Seed))
-	log.Printf("Создана комната: %s (ID: %s, CreatorID: %d, GameMode: %s, QuickStart: %v, Chording: %v, Seed: %s, HasCustomSeed: %v)", req.Name, room.ID, creatorID, gameMode, req.QuickStart, req.Chording, room.GameState.Seed, room.HasCustomSeed)
+	log.Printf("Создана комната: %s (ID: %s, CreatorID: %d, GameMode: %s, QuickStart: %v, Chording: %v, NoGuess: %v, Lives: %d, Seed: %s, HasCustomSeed: %v)", req.Name, room.ID, creatorID, gameMode, req.QuickStart, req.Chording, noGuess, lives, room.GameState.Seed, room.HasCustomSeed)
 	utils.JSONResponse(w, http.StatusOK, room.ToResponse())
 }
 
@@ -147,7 +200,7 @@ func (h *RoomHandler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
 	gameMode := "classic"
 	if gameModeVal, exists := reqMap["gameMode"]; exists {
 		if gameModeStr, ok := gameModeVal.(string); ok {
-			if gameModeStr == "classic" || gameModeStr == "training" || gameModeStr == "fair" {
+			if gameModeStr == "classic" || gameModeStr == "training" || gameModeStr == "fair" || gameModeStr == "race" || gameModeStr == "versus" || gameModeStr == "turns" {
 				gameMode = gameModeStr
 			}
 		}
@@ -169,6 +222,61 @@ func (h *RoomHandler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Извлекаем noGuess (по умолчанию false, только для classic)
+	noGuess := false
+	if noGuessVal, exists := reqMap["noGuess"]; exists {
+		if noGuessBool, ok := noGuessVal.(bool); ok {
+			noGuess = noGuessBool && gameMode == "classic"
+		}
+	}
+
+	// Извлекаем lives и perPlayerLives (только для classic)
+	lives := 0
+	if livesVal, exists := reqMap["lives"]; exists {
+		if livesFloat, ok := livesVal.(float64); ok {
+			lives = int(livesFloat)
+		}
+	}
+	if lives < 0 || lives > game.MaxLives {
+		utils.JSONError(w, http.StatusBadRequest, fmt.Sprintf("Lives must be between 0 and %d", game.MaxLives))
+		return
+	}
+	perPlayerLives := false
+	if perPlayerVal, exists := reqMap["perPlayerLives"]; exists {
+		if perPlayerBool, ok := perPlayerVal.(bool); ok {
+			perPlayerLives = perPlayerBool
+		}
+	}
+	if gameMode != "classic" || lives <= 1 {
+		lives, perPlayerLives = 0, false
+	}
+
+	// Извлекаем clockMode и timeLimit (игра на время)
+	clockModeReq, _ := reqMap["clockMode"].(string)
+	timeLimitFloat, _ := reqMap["timeLimit"].(float64)
+	clockMode, timeLimit, err := parseClockMode(clockModeReq, int(timeLimitFloat), gameMode)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Извлекаем turnTimeout (время на ход в режиме turns)
+	turnTimeoutFloat, _ := reqMap["turnTimeout"].(float64)
+	turnTimeout, err := parseTurnTimeout(int(turnTimeoutFloat), gameMode)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Извлекаем noSpectators и maxSpectators (зрители)
+	noSpectators, _ := reqMap["noSpectators"].(bool)
+	maxSpectatorsFloat, _ := reqMap["maxSpectators"].(float64)
+	maxSpectators := int(maxSpectatorsFloat)
+	if maxSpectators < 0 || maxSpectators > game.MaxSpectatorsLimit {
+		utils.JSONError(w, http.StatusBadRequest, fmt.Sprintf("Max spectators must be between 0 and %d", game.MaxSpectatorsLimit))
+		return
+	}
+
 	// Проверяем, было ли передано поле password
 	passwordProvided := false
 	password := ""
@@ -183,6 +291,12 @@ func (h *RoomHandler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
 		utils.JSONError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if noGuess {
+		if err := game.ValidateNoGuess(rows, cols, mines); err != nil {
+			utils.JSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
 	// Проверяем, что комната существует и пользователь является создателем
 	room := h.roomManager.GetRoom(roomID)
@@ -205,7 +319,7 @@ func (h *RoomHandler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Обновляем комнату
-	if err := h.roomManager.UpdateRoom(roomID, name, password, rows, cols, mines, gameMode, quickStart, chording); err != nil {
+	if err := h.roomManager.UpdateRoom(roomID, name, password, rows, cols, mines, gameMode, quickStart, chording, noGuess, lives, perPlayerLives, clockMode, timeLimit, turnTimeout, noSpectators, maxSpectators); err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -215,3 +329,72 @@ func (h *RoomHandler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
 	utils.JSONResponse(w, http.StatusOK, room.ToResponse())
 }
 
+// DeleteRoom удаляет комнату и отключает ее игроков
+// Удалить комнату может ее создатель или пользователь с правом удаления комнат
+func (h *RoomHandler) DeleteRoom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "DELETE" {
+		utils.JSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		utils.JSONError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	roomID := mux.Vars(r)["id"]
+	room := h.roomManager.GetRoom(roomID)
+	if room == nil {
+		utils.JSONError(w, http.StatusNotFound, "Room not found")
+		return
+	}
+
+	if !room.IsCreator(userID) {
+		allowed, err := auth.HasPermission(userID, auth.PermissionDeleteRooms)
+		if err != nil {
+			log.Printf("Error checking permission for user %d: %v", userID, err)
+			utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		if !allowed {
+			utils.JSONError(w, http.StatusForbidden, "Only room creator or moderator can delete room")
+			return
+		}
+	}
+
+	if h.closeRoom != nil {
+		h.closeRoom(roomID)
+	}
+	h.roomManager.DeleteRoom(roomID)
+	log.Printf("Комната %s удалена пользователем %d", roomID, userID)
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// parseClockMode проверяет настройки игры на время
+// В гонке, в матче команд и в пошаговом режиме ограничения времени нет, без режима ограничение сбрасывается
+func parseClockMode(clockMode string, timeLimit int, gameMode string) (string, int, error) {
+	if clockMode == "" || gameMode == "race" || gameMode == "versus" || gameMode == "turns" {
+		return "", 0, nil
+	}
+	if clockMode != game.ClockDeadline && clockMode != game.ClockScore {
+		return "", 0, fmt.Errorf("Unknown clock mode: %s", clockMode)
+	}
+	if timeLimit <= 0 || timeLimit > game.MaxTimeLimit {
+		return "", 0, fmt.Errorf("Time limit must be between 1 and %d seconds", game.MaxTimeLimit)
+	}
+	return clockMode, timeLimit, nil
+}
+
+// parseTurnTimeout проверяет время на ход
+// Имеет смысл только в пошаговом режиме, 0 - без ограничения
+func parseTurnTimeout(turnTimeout int, gameMode string) (int, error) {
+	if gameMode != "turns" {
+		return 0, nil
+	}
+	if turnTimeout < 0 || turnTimeout > game.MaxTurnTimeout {
+		return 0, fmt.Errorf("Turn timeout must be between 0 and %d seconds", game.MaxTurnTimeout)
+	}
+	return turnTimeout, nil
+}
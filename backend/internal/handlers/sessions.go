@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"minesweeperonline/internal/auth"
+	"minesweeperonline/internal/database"
+	"minesweeperonline/internal/models"
+	"minesweeperonline/internal/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// errSessionRevoked сессия отозвана, истекла или токены пользователя сменили поколение
+var errSessionRevoked = errors.New("session revoked")
+
+// NewSessionChecker возвращает проверку сессии access токена для auth.SetSessionChecker
+// Токен действителен, пока его сессия не отозвана и поколение токенов пользователя не сменилось
+func NewSessionChecker(db *database.DB) func(claims *auth.Claims) error {
+	return func(claims *auth.Claims) error {
+		var count int64
+		err := db.Table("auth_sessions").
+			Joins("JOIN users ON users.id = auth_sessions.user_id").
+			Where("auth_sessions.id = ? AND auth_sessions.user_id = ?", claims.SessionID, claims.UserID).
+			Where("auth_sessions.revoked_at IS NULL AND auth_sessions.expires_at > ?", time.Now()).
+			Where("users.token_generation = ?", claims.Generation).
+			Count(&count).Error
+		if err != nil {
+			log.Printf("Error checking session %d: %v", claims.SessionID, err)
+			return err
+		}
+		if count == 0 {
+			return errSessionRevoked
+		}
+		return nil
+	}
+}
+
+// issueSession создает сессию входа и выдает для нее access и refresh токены
+func issueSession(db *gorm.DB, user models.User, r *http.Request) (models.AuthResponse, error) {
+	refreshToken, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	now := time.Now()
+	session := models.AuthSession{
+		UserID:           user.ID,
+		RefreshTokenHash: auth.HashRefreshToken(refreshToken),
+		UserAgent:        truncateString(r.UserAgent(), 255),
+		IP:               clientIP(r),
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(auth.RefreshTokenTTL),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	token, err := auth.GenerateToken(user.ID, user.Username, session.ID, user.TokenGeneration)
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	return models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(auth.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// revokeAllSessions отзывает все сессии пользователя и меняет поколение его токенов
+// Уже выданные access токены перестают приниматься сразу, refresh токены - тоже
+func revokeAllSessions(tx *gorm.DB, userID int) error {
+	err := tx.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("token_generation", gorm.Expr("token_generation + 1")).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.AuthSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Refresh обменивает refresh токен на новую пару токенов
+// Refresh токен одноразовый: повторное предъявление уже замененного токена отзывает сессию
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		utils.JSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := utils.DecodeJSON(r, &req); err != nil || req.RefreshToken == "" {
+		utils.JSONError(w, http.StatusBadRequest, "Refresh token is required")
+		return
+	}
+	hash := auth.HashRefreshToken(req.RefreshToken)
+
+	var session models.AuthSession
+	err := h.db.Where("refresh_token_hash = ?", hash).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Предъявлен уже замененный токен: кто-то другой воспользовался им раньше
+		result := h.db.Model(&models.AuthSession{}).
+			Where("previous_token_hash = ? AND revoked_at IS NULL", hash).
+			Update("revoked_at", time.Now())
+		if result.Error == nil && result.RowsAffected > 0 {
+			log.Printf("Refresh token reuse detected, session revoked")
+		}
+		utils.JSONError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding session: %v", err)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		utils.JSONError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+
+	user, err := h.findUserByID(session.UserID)
+	if err != nil {
+		utils.JSONError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken()
+	if err != nil {
+		log.Printf("Error generating refresh token: %v", err)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	// Замена условна: из двух одновременных обновлений одним токеном проходит только одно
+	now := time.Now()
+	result := h.db.Model(&models.AuthSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  auth.HashRefreshToken(refreshToken),
+			"previous_token_hash": hash,
+			"last_used_at":        now,
+			"expires_at":          now.Add(auth.RefreshTokenTTL),
+		})
+	if result.Error != nil {
+		log.Printf("Error rotating refresh token: %v", result.Error)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.JSONError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+
+	token, err := auth.GenerateToken(user.ID, user.Username, session.ID, user.TokenGeneration)
+	if err != nil {
+		log.Printf("Error generating token: %v", err)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(auth.AccessTokenTTL.Seconds()),
+		User:         h.userToMap(user),
+	})
+}
+
+// Logout отзывает текущую сессию
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		utils.JSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	sessionID := r.Context().Value("sessionID").(int)
+	if _, err := h.revokeSession(userID, sessionID); err != nil {
+		log.Printf("Error revoking session %d: %v", sessionID, err)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// GetSessions возвращает активные сессии пользователя
+func (h *AuthHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(int)
+	sessionID := r.Context().Value("sessionID").(int)
+
+	var sessions []models.AuthSession
+	err := h.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		log.Printf("Error loading sessions for user %d: %v", userID, err)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	type SessionResponse struct {
+		models.AuthSession
+		Current bool `json:"current"`
+	}
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			AuthSession: session,
+			Current:     session.ID == sessionID,
+		})
+	}
+
+	utils.JSONResponse(w, http.StatusOK, response)
+}
+
+// RevokeSession отзывает сессию пользователя по ID
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "DELETE" {
+		utils.JSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	sessionID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	revoked, err := h.revokeSession(userID, sessionID)
+	if err != nil {
+		log.Printf("Error revoking session %d: %v", sessionID, err)
+		utils.JSONError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !revoked {
+		utils.JSONError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// revokeSession отзывает одну сессию пользователя; false - активной сессии с таким ID нет
+func (h *AuthHandler) revokeSession(userID, sessionID int) (bool, error) {
+	result := h.db.Model(&models.AuthSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// trustedProxies прокси, от которых принимается заголовок X-Forwarded-For
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies задает доверенные прокси: IP или подсети (CIDR) через запятую
+// Без доверенных прокси адрес клиента берется только из RemoteAddr
+func SetTrustedProxies(list string) error {
+	var proxies []*net.IPNet
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %q", item)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+		}
+		proxies = append(proxies, network)
+	}
+	trustedProxies = proxies
+	return nil
+}
+
+// isTrustedProxy проверяет, что адрес принадлежит доверенному прокси
+func isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP возвращает адрес клиента
+// X-Forwarded-For учитывается, только если запрос пришел от доверенного прокси. Заголовок
+// разбирается справа налево до первого недоверенного адреса: левее него значения мог подставить сам клиент
+func clientIP(r *http.Request) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	if !isTrustedProxy(ip) {
+		return truncateString(ip, 64)
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" {
+			continue
+		}
+		ip = addr
+		if !isTrustedProxy(addr) {
+			break
+		}
+	}
+	return truncateString(ip, 64)
+}
+
+// truncateString обрезает строку до max байт
+func truncateString(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
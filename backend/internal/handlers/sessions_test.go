@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	if err := SetTrustedProxies("10.0.0.1, 172.16.0.0/12, ::1"); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	t.Cleanup(func() { trustedProxies = nil })
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"без прокси", "203.0.113.5:4000", nil, "203.0.113.5"},
+		{"заголовок от недоверенного адреса игнорируется", "203.0.113.5:4000", []string{"1.2.3.4"}, "203.0.113.5"},
+		{"доверенный прокси", "10.0.0.1:4000", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"подставленный клиентом адрес левее", "10.0.0.1:4000", []string{"1.2.3.4, 198.51.100.7"}, "198.51.100.7"},
+		{"цепочка доверенных прокси", "10.0.0.1:4000", []string{"198.51.100.7, 172.20.1.1"}, "198.51.100.7"},
+		{"несколько заголовков", "10.0.0.1:4000", []string{"1.2.3.4", "198.51.100.7"}, "198.51.100.7"},
+		{"доверенный прокси без заголовка", "10.0.0.1:4000", nil, "10.0.0.1"},
+		{"все адреса доверенные", "[::1]:4000", []string{"172.16.0.2"}, "172.16.0.2"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/api/auth/login", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for _, value := range tt.forwarded {
+			r.Header.Add("X-Forwarded-For", value)
+		}
+		if got := clientIP(r); got != tt.want {
+			t.Errorf("%s: clientIP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetTrustedProxiesInvalid(t *testing.T) {
+	t.Cleanup(func() { trustedProxies = nil })
+	for _, list := range []string{"proxy.local", "10.0.0.0/33"} {
+		if err := SetTrustedProxies(list); err == nil {
+			t.Errorf("SetTrustedProxies(%q): expected error", list)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer не отправляет письма, а сохраняет их для локальной разработки:
+// в каталог Dir файлами .eml или, если Dir не задан, в лог
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// Send сохраняет письмо
+func (m *FileMailer) Send(msg Message) error {
+	if err := validAddress(msg.To); err != nil {
+		return err
+	}
+	now := time.Now()
+	data, err := buildMessage(m.From, msg, now)
+	if err != nil {
+		return err
+	}
+
+	if m.Dir == "" {
+		log.Printf("[MAIL] Письмо для %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	name := filepath.Join(m.Dir, fmt.Sprintf("%d.eml", now.UnixNano()))
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		return err
+	}
+	log.Printf("[MAIL] Письмо для %s сохранено в %s", msg.To, name)
+	return nil
+}
@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"minesweeperonline/internal/config"
+)
+
+// Message письмо с текстовым телом
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New выбирает реализацию по конфигурации:
+// SMTP, если задан SMTP_HOST, иначе письма пишутся в MAIL_DIR или в лог (для локальной разработки).
+// Без SMTP письма вместе со ссылками сброса пароля никому не уходят, поэтому при запуске об этом пишется предупреждение
+func New(cfg *config.Config) Mailer {
+	if cfg.SMTPHost != "" {
+		return &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUser,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}
+	}
+	target := "в лог"
+	if cfg.MailDir != "" {
+		target = "в каталог " + cfg.MailDir
+	}
+	log.Printf("ВНИМАНИЕ: SMTP_HOST не задан - письма НЕ отправляются, а пишутся %s вместе со ссылками сброса пароля и подтверждения email!", target)
+	log.Printf("ВНИМАНИЕ: такой режим допустим только для локальной разработки, в продакшене задайте SMTP_HOST")
+	return &FileMailer{Dir: cfg.MailDir, From: cfg.MailFrom}
+}
+
+// buildMessage собирает письмо в формате RFC 5322 (UTF-8, quoted-printable)
+func buildMessage(from string, msg Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", sanitizeHeader(from))
+	fmt.Fprintf(&buf, "To: %s\r\n", sanitizeHeader(msg.To))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", sanitizeHeader(msg.Subject)))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sanitizeHeader убирает переводы строк, чтобы значение не могло добавить свои заголовки
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// validAddress проверяет, что адрес можно передать SMTP серверу
+func validAddress(addr string) error {
+	if strings.ContainsAny(addr, "\r\n") {
+		return fmt.Errorf("invalid email address")
+	}
+	if _, err := mail.ParseAddress(addr); err != nil {
+		return fmt.Errorf("invalid email address: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package mailer
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"minesweeperonline/internal/mailer/mailertest"
+)
+
+// parseMessage разбирает письмо и декодирует тему и тело
+func parseMessage(t *testing.T, data []byte) (*mail.Message, string, string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("письмо не разбирается: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("тема не декодируется: %v", err)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatalf("тело не декодируется: %v", err)
+	}
+	return msg, subject, string(body)
+}
+
+func TestBuildMessageSanitizesHeaders(t *testing.T) {
+	data, err := buildMessage("noreply@example.com\r\nBcc: victim@example.com", Message{
+		To:      "user@example.com\nBcc: victim@example.com",
+		Subject: "Сброс пароля\r\nX-Injected: 1",
+		Body:    "Строка 1\nBcc: в теле можно\n",
+	}, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("buildMessage: %v", err)
+	}
+
+	msg, subject, body := parseMessage(t, data)
+	if bcc := msg.Header.Get("Bcc"); bcc != "" {
+		t.Errorf("переводы строк добавили заголовок Bcc: %q", bcc)
+	}
+	if injected := msg.Header.Get("X-Injected"); injected != "" {
+		t.Errorf("переводы строк добавили заголовок X-Injected: %q", injected)
+	}
+	if got := msg.Header.Get("To"); got != "user@example.comBcc: victim@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	if subject != "Сброс пароляX-Injected: 1" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if body != "Строка 1\r\nBcc: в теле можно\r\n" {
+		t.Errorf("тело = %q", body)
+	}
+	if got := msg.Header.Get("Date"); got != "Fri, 02 Jan 2026 03:04:05 +0000" {
+		t.Errorf("Date = %q", got)
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	server := mailertest.NewServer()
+	defer server.Close()
+
+	m := &SMTPMailer{
+		Host:     server.Host,
+		Port:     server.Port,
+		Username: "smtp-user",
+		Password: "smtp-password",
+		From:     "noreply@example.com",
+	}
+	err := m.Send(Message{
+		To:      "user@example.com",
+		Subject: "Подтверждение email",
+		Body:    "Здравствуйте!\nСсылка: https://example.com/?token=a.b.c\n",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("сервер принял %d писем, ожидалось 1", len(messages))
+	}
+	got := messages[0]
+	if got.From != "noreply@example.com" || len(got.To) != 1 || got.To[0] != "user@example.com" {
+		t.Errorf("конверт: from=%q to=%v", got.From, got.To)
+	}
+	if auth := server.Auth(); len(auth) != 1 || auth[0] != "smtp-user:smtp-password" {
+		t.Errorf("AUTH = %v", auth)
+	}
+
+	msg, subject, body := parseMessage(t, got.Data)
+	if msg.Header.Get("To") != "user@example.com" || subject != "Подтверждение email" {
+		t.Errorf("заголовки: To=%q Subject=%q", msg.Header.Get("To"), subject)
+	}
+	if !strings.Contains(body, "https://example.com/?token=a.b.c") {
+		t.Errorf("в теле нет ссылки: %q", body)
+	}
+}
+
+func TestSMTPMailerRejectsInvalidAddress(t *testing.T) {
+	server := mailertest.NewServer()
+	defer server.Close()
+
+	m := &SMTPMailer{Host: server.Host, Port: server.Port, From: "noreply@example.com"}
+	for _, to := range []string{"", "not an address", "user@example.com\r\nRCPT TO:<victim@example.com>"} {
+		if err := m.Send(Message{To: to, Subject: "s", Body: "b"}); err == nil {
+			t.Errorf("Send(%q) без ошибки", to)
+		}
+	}
+	if messages := server.Messages(); len(messages) != 0 {
+		t.Errorf("сервер принял %d писем на недопустимые адреса", len(messages))
+	}
+}
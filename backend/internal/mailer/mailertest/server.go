@@ -0,0 +1,154 @@
+// Package mailertest запускает локальный SMTP сервер для тестов отправки писем
+package mailertest
+
+import (
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Message письмо, принятое сервером
+type Message struct {
+	From string
+	To   []string
+	Data []byte // Письмо целиком: заголовки и тело
+}
+
+// Server SMTP сервер на 127.0.0.1 со случайным портом
+// Поддерживает EHLO, AUTH PLAIN, MAIL, RCPT, DATA, RSET, NOOP и QUIT; STARTTLS не объявляет
+type Server struct {
+	Host string
+	Port string
+
+	listener net.Listener
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	messages []Message
+	auth     []string
+}
+
+// NewServer запускает сервер, его нужно остановить через Close
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("mailertest: failed to listen: " + err.Error())
+	}
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	s := &Server{Host: host, Port: port, listener: listener}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Close останавливает сервер и дожидается завершения соединений
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// Messages возвращает принятые письма
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Auth возвращает учетные данные AUTH PLAIN в виде "username:password"
+func (s *Server) Auth() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.auth...)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(textproto.NewConn(conn))
+		}()
+	}
+}
+
+// handle ведет SMTP диалог с одним клиентом
+func (s *Server) handle(conn *textproto.Conn) {
+	reply := func(line string) bool {
+		return conn.PrintfLine("%s", line) == nil
+	}
+	if !reply("220 mailertest ESMTP") {
+		return
+	}
+
+	var msg Message
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			if !reply("250-mailertest") || !reply("250-8BITMIME") || !reply("250 AUTH PLAIN") {
+				return
+			}
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			decoded, err := base64.StdEncoding.DecodeString(initial)
+			parts := strings.Split(string(decoded), "\x00")
+			if !strings.EqualFold(mechanism, "PLAIN") || err != nil || len(parts) != 3 {
+				reply("504 unsupported authentication")
+				continue
+			}
+			s.mu.Lock()
+			s.auth = append(s.auth, parts[1]+":"+parts[2])
+			s.mu.Unlock()
+			reply("235 authenticated")
+		case "MAIL":
+			msg = Message{From: addressArg(arg)}
+			reply("250 ok")
+		case "RCPT":
+			msg.To = append(msg.To, addressArg(arg))
+			reply("250 ok")
+		case "DATA":
+			if !reply("354 end data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = Message{}
+			reply("250 ok")
+		case "RSET", "NOOP":
+			msg = Message{}
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// addressArg достает адрес из "FROM:<addr>" или "TO:<addr> ..."
+func addressArg(arg string) string {
+	start := strings.Index(arg, "<")
+	end := strings.Index(arg, ">")
+	if start == -1 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer отправляет письма через SMTP сервер
+// STARTTLS используется, если сервер его поддерживает; без логина подходит локальный стенд (MailHog, smtp4dev)
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send отправляет письмо
+func (m *SMTPMailer) Send(msg Message) error {
+	if err := validAddress(msg.To); err != nil {
+		return err
+	}
+	data, err := buildMessage(m.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, []string{msg.To}, data)
+}
@@ -33,6 +33,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
 		ctx = context.WithValue(ctx, "username", claims.Username)
+		ctx = context.WithValue(ctx, "sessionID", claims.SessionID)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
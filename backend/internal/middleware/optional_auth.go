@@ -22,6 +22,7 @@ func OptionalAuthMiddleware(next http.Handler) http.Handler {
 					// Токен валиден, добавляем userID в контекст
 					ctx := context.WithValue(r.Context(), "userID", claims.UserID)
 					ctx = context.WithValue(ctx, "username", claims.Username)
+					ctx = context.WithValue(ctx, "sessionID", claims.SessionID)
 					r = r.WithContext(ctx)
 				}
 				// Если токен невалиден, просто игнорируем и продолжаем без userID
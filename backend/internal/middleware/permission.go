@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"minesweeperonline/internal/auth"
+)
+
+// RequirePermission пропускает запрос, только если у пользователя есть право permission
+// Используется после AuthMiddleware: userID берется из контекста
+func RequirePermission(permission auth.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := r.Context().Value("userID").(int)
+			if !ok {
+				http.Error(w, "Authorization required", http.StatusUnauthorized)
+				return
+			}
+
+			allowed, err := auth.HasPermission(userID, permission)
+			if err != nil {
+				log.Printf("Permission check error for user %d: %v", userID, err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -11,9 +11,17 @@ type Room struct {
 	Rows      int        `gorm:"not null" json:"rows"`
 	Cols      int        `gorm:"not null" json:"cols"`
 	Mines     int        `gorm:"not null" json:"mines"`
-	GameMode  string     `gorm:"type:varchar(50);default:'classic'" json:"gameMode"` // "classic", "training", "fair"
+	GameMode  string     `gorm:"type:varchar(50);default:'classic'" json:"gameMode"` // "classic", "training", "fair", "race", "versus", "turns"
 	QuickStart bool      `gorm:"default:false" json:"quickStart"` // Быстрый старт
 	Chording   bool      `gorm:"default:false" json:"chording"`  // Chording
+	NoGuess    bool      `gorm:"default:false" json:"noGuess"`   // Без угадываний
+	Lives      int       `gorm:"default:0" json:"lives"`         // Жизни (0 или 1 - первая мина заканчивает игру)
+	PerPlayerLives bool  `gorm:"default:false" json:"perPlayerLives"` // Жизни у каждого игрока свои
+	ClockMode  string    `gorm:"type:varchar(20);default:''" json:"clockMode"` // Игра на время: "", "deadline", "score"
+	TimeLimit  int       `gorm:"default:0" json:"timeLimit"`     // Ограничение времени в секундах
+	TurnTimeout int      `gorm:"default:0" json:"turnTimeout"`   // Время на ход в режиме turns в секундах
+	NoSpectators bool    `gorm:"default:false" json:"noSpectators"` // Зрители запрещены
+	MaxSpectators int    `gorm:"default:0" json:"maxSpectators"` // Наибольшее число зрителей (0 - без ограничения)
 	CreatorID int        `gorm:"default:0" json:"creatorId"`
 	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
 	UpdatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
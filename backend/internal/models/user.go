@@ -12,6 +12,10 @@ type User struct {
 	Color        *string   `gorm:"type:varchar(7)" json:"color,omitempty"`
 	Rating       float64   `gorm:"-" json:"rating"` // Вычисляемое поле, не хранится в БД
 	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+	// Поколение токенов: увеличивается при смене пароля, токены прошлых поколений недействительны
+	TokenGeneration int `gorm:"not null;default:0;column:token_generation" json:"-"`
+	// Email подтвержден по ссылке из письма
+	Verified bool `gorm:"not null;default:false" json:"verified"`
 }
 
 func (User) TableName() string {
@@ -30,8 +34,67 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Token string                 `json:"token"`
-	User  map[string]interface{} `json:"user"` // Используем map для поддержки isAdmin
+	Token        string                 `json:"token"`
+	RefreshToken string                 `json:"refreshToken"`
+	ExpiresIn    int                    `json:"expiresIn"` // Время жизни access токена в секундах
+	User         map[string]interface{} `json:"user"` // Используем map для поддержки isAdmin
+}
+
+// AuthSession сессия входа: хранит хеш текущего refresh токена
+// Refresh токен меняется при каждом обновлении; предъявление предыдущего означает утечку и отзывает сессию
+type AuthSession struct {
+	ID                int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID            int        `gorm:"not null;column:user_id;index:idx_auth_sessions_user_id" json:"-"`
+	RefreshTokenHash  string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_auth_sessions_refresh_token_hash;column:refresh_token_hash" json:"-"`
+	PreviousTokenHash *string    `gorm:"type:varchar(64);index:idx_auth_sessions_previous_token_hash;column:previous_token_hash" json:"-"`
+	UserAgent         string     `gorm:"type:varchar(255);column:user_agent" json:"userAgent"`
+	IP                string     `gorm:"type:varchar(64);column:ip" json:"ip"`
+	CreatedAt         time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+	LastUsedAt        time.Time  `gorm:"default:CURRENT_TIMESTAMP;column:last_used_at" json:"lastUsedAt"`
+	ExpiresAt         time.Time  `gorm:"not null;column:expires_at" json:"expiresAt"`
+	RevokedAt         *time.Time `gorm:"column:revoked_at" json:"-"`
+}
+
+func (AuthSession) TableName() string {
+	return "auth_sessions"
+}
+
+// PasswordResetToken выданный токен сброса пароля
+// ID совпадает с jti токена; токен принимается, пока не использован и не истек
+type PasswordResetToken struct {
+	ID        string     `gorm:"primaryKey;type:varchar(36)" json:"-"`
+	UserID    int        `gorm:"not null;column:user_id;index:idx_password_reset_tokens_user_id" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null;column:expires_at" json:"-"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"-"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"-"`
+}
+
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// Role роль пользователя: admin, moderator или user
+// Права ролей заданы в коде (auth.rolePermissions), таблица - справочник ролей
+type Role struct {
+	Name        string `gorm:"primaryKey;type:varchar(32)" json:"name"`
+	Description string `gorm:"type:varchar(255)" json:"description"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+// UserRole выданная пользователю роль
+// Роль user не выдается: она есть у всех пользователей
+type UserRole struct {
+	UserID    int       `gorm:"primaryKey;column:user_id" json:"userId"`
+	RoleName  string    `gorm:"primaryKey;type:varchar(32);column:role_name;index:idx_user_roles_role_name" json:"role"`
+	GrantedBy *int      `gorm:"column:granted_by" json:"grantedBy,omitempty"` // Администратор, выдавший роль (nil - выдана при запуске по ADMIN_EMAIL)
+	GrantedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;column:granted_at" json:"grantedAt"`
+}
+
+func (UserRole) TableName() string {
+	return "user_roles"
 }
 
 type UserStats struct {
@@ -39,6 +102,7 @@ type UserStats struct {
 	GamesPlayed int       `gorm:"default:0" json:"gamesPlayed"`
 	GamesWon    int       `gorm:"default:0" json:"gamesWon"`
 	GamesLost   int       `gorm:"default:0" json:"gamesLost"`
+	EloRating   float64   `gorm:"type:double precision;default:1500;column:elo_rating" json:"eloRating"` // Рейтинг Эло, обновляется после каждой рейтинговой победы
 	LastSeen    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"lastSeen"`
 	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"-"`
 	IsOnline    bool      `gorm:"-" json:"isOnline"` // Вычисляемое поле, не хранится в БД
@@ -49,24 +113,62 @@ func (UserStats) TableName() string {
 }
 
 type UserProfile struct {
-	User  User      `json:"user"`
-	Stats UserStats `json:"stats"`
+	User         User                  `json:"user"`
+	Stats        UserStats             `json:"stats"`
+	Achievements []AchievementResponse `json:"achievements"`
+}
+
+// UserAchievement полученное игроком достижение
+type UserAchievement struct {
+	UserID        int       `gorm:"primaryKey;column:user_id" json:"userId"`
+	AchievementID string    `gorm:"primaryKey;type:varchar(64);column:achievement_id" json:"achievementId"`
+	GameHistoryID int       `gorm:"column:game_history_id" json:"gameHistoryId"` // Игра, в которой получено достижение
+	UnlockedAt    time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;column:unlocked_at" json:"unlockedAt"`
+}
+
+func (UserAchievement) TableName() string {
+	return "user_achievements"
+}
+
+// AchievementResponse достижение в профиле игрока
+type AchievementResponse struct {
+	ID            string    `json:"id"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	GameHistoryID int       `json:"gameHistoryId,omitempty"`
+	UnlockedAt    time.Time `json:"unlockedAt"`
 }
 
 type UserGameHistory struct {
 	ID            int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID        int       `gorm:"not null;column:user_id" json:"userId"`
+	UserID        int       `gorm:"not null;column:user_id;index:idx_user_game_history_user_rating,priority:1" json:"userId"`
 	RoomID        string    `gorm:"type:varchar(255);column:room_id" json:"roomId"`
-	Width         int       `gorm:"not null" json:"width"`
-	Height        int       `gorm:"not null" json:"height"`
-	Mines         int       `gorm:"not null" json:"mines"`
-	GameTime      float64   `gorm:"type:double precision;not null;column:game_time" json:"gameTime"`
+	Width         int       `gorm:"not null;index:idx_user_game_history_board,priority:1" json:"width"`
+	Height        int       `gorm:"not null;index:idx_user_game_history_board,priority:2" json:"height"`
+	Mines         int       `gorm:"not null;index:idx_user_game_history_board,priority:3" json:"mines"`
+	GameTime      float64   `gorm:"type:double precision;not null;column:game_time;index:idx_user_game_history_board,priority:4" json:"gameTime"`
 	Seed          string    `gorm:"type:varchar(36);not null;column:seed" json:"seed"`
 	HasCustomSeed bool      `gorm:"default:false;column:has_custom_seed" json:"hasCustomSeed"`
 	CreatorID     int       `gorm:"not null;column:creator_id" json:"creatorId"`
 	Won           bool      `gorm:"default:false" json:"won"`
 	Chording      bool      `gorm:"default:false" json:"chording"`
 	QuickStart    bool      `gorm:"default:false;column:quick_start" json:"quickStart"`
+	NoGuess       bool      `gorm:"default:false;column:no_guess" json:"noGuess"` // Поле без угадываний
+	GameMode      string    `gorm:"type:varchar(20);not null;default:'classic';column:game_mode" json:"gameMode"` // Режим игры: classic, training, fair, race, versus, turns
+	HintsUsed     int       `gorm:"default:0;column:hints_used" json:"hintsUsed,omitempty"` // Подсказки, использованные в игре
+	Players       int       `gorm:"default:0;column:players" json:"players,omitempty"`       // Люди, игравшие в игре, включая гостей (0 - не записано)
+	Completed     bool      `gorm:"default:false;column:completed" json:"completed,omitempty"` // Игра окончена без победы и поражения (время вышло в игре на счет)
+	Placement     int       `gorm:"default:0;column:placement" json:"placement,omitempty"` // Место в гонке (0 - не гонка)
+	LivesLost     int       `gorm:"default:0;column:lives_lost" json:"livesLost,omitempty"` // Жизни, потерянные за игру (режим жизней)
+	Score         int       `gorm:"default:0;column:score" json:"score,omitempty"`           // Счет игры на время (режим score)
+	Rating        float64   `gorm:"type:double precision;default:0;index:idx_user_game_history_user_rating,priority:2,sort:desc" json:"rating"` // Рейтинг за игру на момент записи (0 для нерейтинговых игр)
+	EloRating     *float64  `gorm:"type:double precision;column:elo_rating" json:"eloRating,omitempty"` // Рейтинг Эло игрока после игры (nil, если игра не изменила рейтинг)
+	EloDelta      float64   `gorm:"type:double precision;default:0;column:elo_delta" json:"eloDelta"`   // Изменение рейтинга Эло за игру
+	ThreeBV       int       `gorm:"default:0;column:three_bv" json:"threeBV"`                           // 3BV поля
+	LeftClicks    int       `gorm:"default:0;column:left_clicks" json:"leftClicks"`                     // Клики игрока по закрытым ячейкам
+	RightClicks   int       `gorm:"default:0;column:right_clicks" json:"rightClicks"`                   // Клики игрока по флагам
+	ChordClicks   int       `gorm:"default:0;column:chord_clicks" json:"chordClicks"`                   // Chording клики игрока
+	WastedClicks  int       `gorm:"default:0;column:wasted_clicks" json:"wastedClicks"`                 // Клики, не изменившие поле
 	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
 }
 
@@ -79,8 +181,54 @@ type GameParticipant struct {
 	UserID        int     `gorm:"primaryKey;column:user_id" json:"userId"`
 	Nickname      string  `gorm:"type:varchar(100);not null" json:"nickname"`
 	Color         *string `gorm:"type:varchar(7)" json:"color,omitempty"`
+	Score         *int    `gorm:"column:score" json:"score,omitempty"` // Итоговый счет (пошаговый режим)
+	// Вклад в совместную игру (NULL - не отслеживался)
+	CellsRevealed  *int `gorm:"column:cells_revealed" json:"cellsRevealed,omitempty"`
+	FlagsCorrect   *int `gorm:"column:flags_correct" json:"flagsCorrect,omitempty"`
+	FlagsIncorrect *int `gorm:"column:flags_incorrect" json:"flagsIncorrect,omitempty"`
+	Chords         *int `gorm:"column:chords" json:"chords,omitempty"`
+	HintsUsed      *int `gorm:"column:hints_used" json:"hintsUsed,omitempty"`
+	MinesHit       *int `gorm:"column:mines_hit" json:"minesHit,omitempty"`
 }
 
 func (GameParticipant) TableName() string {
 	return "game_participants"
 }
+
+// GameReplay хранит запись ходов завершенной игры рядом с записью истории
+type GameReplay struct {
+	GameHistoryID int       `gorm:"primaryKey;column:game_history_id" json:"gameHistoryId"`
+	Data          []byte    `gorm:"type:bytea;not null" json:"-"` // JSON с ходами и расположением мин
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+func (GameReplay) TableName() string {
+	return "game_replays"
+}
+
+// UserRating материализованный рейтинг игрока для лидерборда
+// Обновляется в той же транзакции, что и запись результата игры
+type UserRating struct {
+	UserID     int       `gorm:"primaryKey;column:user_id" json:"userId"`
+	Rating     float64   `gorm:"type:double precision;not null;default:0;index:idx_user_ratings_rating,sort:desc" json:"rating"`
+	RatedGames int       `gorm:"not null;default:0;column:rated_games" json:"ratedGames"` // Количество рейтинговых побед
+	UpdatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (UserRating) TableName() string {
+	return "user_ratings"
+}
+
+// RatingSnapshot значение рейтинга игрока после очередной рейтинговой игры
+type RatingSnapshot struct {
+	ID            int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        int       `gorm:"not null;column:user_id;index:idx_rating_snapshots_user_created,priority:1" json:"userId"`
+	GameHistoryID int       `gorm:"not null;column:game_history_id" json:"gameHistoryId"`
+	Rating        float64   `gorm:"type:double precision;not null" json:"rating"`
+	EloRating     float64   `gorm:"type:double precision;not null;column:elo_rating" json:"eloRating"`
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP;index:idx_rating_snapshots_user_created,priority:2" json:"createdAt"`
+}
+
+func (RatingSnapshot) TableName() string {
+	return "rating_snapshots"
+}
@@ -181,3 +181,41 @@ func ComputeDifficultyFactor(w, h, m, Dref float64) float64 {
 	}
 	return df
 }
+
+// EloResult результат пересчета рейтинга Эло игрока по одной игре
+type EloResult struct {
+	PuzzleRating     float64 // Rp - рейтинг поля
+	ExpectedTime     float64 // Texp - ожидаемое время прохождения
+	Score            float64 // S - оценка результата по времени
+	Expected         float64 // E - ожидаемый результат по формуле Эло
+	DifficultyFactor float64 // DF - коэффициент сложности поля
+	Delta            float64 // Изменение рейтинга игрока
+	NewRating        float64 // Рейтинг игрока после игры
+}
+
+// CalculateEloUpdate пересчитывает рейтинг игрока Rpl после победы на поле w x h с m минами за время T
+// Rpl' = Rpl + K * DF * (S - E)
+// Возвращает false, если сложность поля недостаточна для получения рейтинга
+func CalculateEloUpdate(playerRating, w, h, m, T float64) (EloResult, bool) {
+	Dref := ComputeDref()
+	if !IsComplexitySufficient(w, h, m, Dref) {
+		return EloResult{}, false
+	}
+
+	Rp := computeRp(w, h, m, Dref)
+	Texp := expectedTime(w, h, m)
+	S := performanceScore(T, Texp)
+	E := expectedResult(Rp, playerRating)
+	DF := ComputeDifficultyFactor(w, h, m, Dref)
+	delta := K * DF * (S - E)
+
+	return EloResult{
+		PuzzleRating:     Rp,
+		ExpectedTime:     Texp,
+		Score:            S,
+		Expected:         E,
+		DifficultyFactor: DF,
+		Delta:            delta,
+		NewRating:        playerRating + delta,
+	}, true
+}
@@ -0,0 +1,88 @@
+package websocket
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+	"minesweeperonline/internal/auth"
+	pb "minesweeperonline/proto"
+)
+
+// authSubprotocol подпротокол, за которым в Sec-WebSocket-Protocol передается JWT: "bearer, <token>"
+const authSubprotocol = "bearer"
+
+// sessionSubprotocol подпротокол, за которым передается токен возобновления сессии: "session, <token>"
+// Токен не передается в URL, чтобы не попадать в логи прокси и историю
+const sessionSubprotocol = "session"
+
+// authMessageTimeout сколько ждать первое сообщение с токеном при подключении с ?auth=message
+const authMessageTimeout = 10 * time.Second
+
+// tokenFromRequest возвращает JWT из query параметра token или из подпротокола
+func tokenFromRequest(r *http.Request) string {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token
+	}
+	return subprotocolValue(r, authSubprotocol)
+}
+
+// sessionFromRequest возвращает токен возобновления сессии из подпротокола
+func sessionFromRequest(r *http.Request) string {
+	return subprotocolValue(r, sessionSubprotocol)
+}
+
+// subprotocolValue возвращает значение, переданное в Sec-WebSocket-Protocol следом за name
+func subprotocolValue(r *http.Request, name string) string {
+	protocols := websocket.Subprotocols(r)
+	for i := 0; i+1 < len(protocols); i++ {
+		if protocols[i] == name {
+			return protocols[i+1]
+		}
+	}
+	return ""
+}
+
+// readAuthMessage читает JWT из первого сообщения клиента (AuthMessage)
+func readAuthMessage(conn *websocket.Conn) (string, error) {
+	conn.SetReadDeadline(time.Now().Add(authMessageTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	messageType, data, err := conn.ReadMessage()
+	if err != nil {
+		return "", fmt.Errorf("auth message not received: %w", err)
+	}
+	if messageType != websocket.BinaryMessage {
+		return "", fmt.Errorf("auth message must be binary")
+	}
+	var clientMsg pb.ClientMessage
+	if err := proto.Unmarshal(data, &clientMsg); err != nil {
+		return "", fmt.Errorf("invalid auth message: %w", err)
+	}
+	if clientMsg.GetAuth() == nil {
+		return "", fmt.Errorf("first message must be auth")
+	}
+	return clientMsg.GetAuth().Token, nil
+}
+
+// authenticate определяет пользователя соединения по JWT
+// Возвращает nil без ошибки для гостя (токен не передан) и ошибку для недействительного токена
+func authenticate(r *http.Request, conn *websocket.Conn) (*auth.Claims, error) {
+	token := tokenFromRequest(r)
+	if token == "" && r.URL.Query().Get("auth") == "message" {
+		var err error
+		if token, err = readAuthMessage(conn); err != nil {
+			return nil, err
+		}
+	}
+	if token == "" {
+		return nil, nil
+	}
+	claims, err := auth.ValidateToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -17,6 +18,7 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Разрешаем все источники для разработки
 	},
+	Subprotocols: []string{authSubprotocol, sessionSubprotocol},
 }
 
 var colors = []string{
@@ -30,6 +32,7 @@ type Manager struct {
 	profileHandler *handlers.ProfileHandler
 	gameService    GameService
 	wsPlayers      map[string]*Player
+	sessions       map[string]*Player // Токен сессии -> игрок
 	wsPlayersMu    sync.RWMutex
 }
 
@@ -46,6 +49,12 @@ type GameService interface {
 	BroadcastPlayerList(room interface{})
 	SendGameStateToPlayer(room interface{}, player *Player)
 	SendPlayerListToPlayer(room interface{}, player *Player)
+	AddBot(room interface{}, skill game.BotSkill) error
+	RemoveBot(room interface{}, botID string) error
+	RemoveBots(room interface{})
+	StartRace(room interface{})
+	SetTeam(room interface{}, playerID string, team int) error
+	AckState(room interface{}, playerID string, version uint64, resync bool)
 }
 
 // NewManager создает новый менеджер WebSocket соединений
@@ -55,6 +64,7 @@ func NewManager(roomManager *game.RoomManager, profileHandler *handlers.ProfileH
 		profileHandler: profileHandler,
 		gameService:    gameService,
 		wsPlayers:      make(map[string]*Player),
+		sessions:       make(map[string]*Player),
 	}
 }
 
@@ -83,57 +93,142 @@ func (m *Manager) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Отменяем удаление комнаты, если кто-то подключается
 	room.CancelDeletion()
 
+	// Пользователь определяется только по JWT (query token, подпротокол или первое сообщение), без токена - гость
+	// Токен проверяется до возобновления сессии: отозванная сессия входа не возобновляет и игру
+	claims, err := authenticate(r, conn)
+	if err != nil {
+		log.Printf("Отказ в подключении к комнате %s: %v", roomID, err)
+		errorMsg, _ := EncodeErrorProtobuf(err.Error())
+		conn.WriteMessage(websocket.BinaryMessage, errorMsg)
+		conn.Close()
+		if room.GetPlayerCount() == 0 {
+			m.roomManager.ScheduleRoomDeletion(roomID, 5*time.Minute)
+		}
+		return
+	}
+
+	// Подпротокол "session, <token>" и ?lastSeq=<seq> - возобновление сессии после разрыва соединения
+	if token := sessionFromRequest(r); token != "" {
+		lastSeq, _ := strconv.ParseUint(r.URL.Query().Get("lastSeq"), 10, 64)
+		player := m.getSession(token)
+		if player != nil && player.RoomID == roomID && room.GetPlayer(player.ID) != nil && player.canResume(claims) {
+			if m.resumeSession(conn, room, player, lastSeq) {
+				m.serveConnection(conn, room, player)
+				return
+			}
+		}
+		log.Printf("Сессия для комнаты %s не найдена, истекла или выдана другому входу, подключаем как нового игрока", roomID)
+	}
+
 	playerID := utils.GenerateID()
 	color := colors[utils.RandInt(len(colors))]
 
-	// Пытаемся получить userID из query параметра (если пользователь авторизован)
-	userIDStr := r.URL.Query().Get("userId")
-	var userID int
+	var userID, authSessionID int
 	var initialNickname string
-	if userIDStr != "" {
-		// Парсим userID, игнорируем ошибку если не число
-		if id, err := strconv.Atoi(userIDStr); err == nil {
-			userID = id
-			// Обновляем last_seen для пользователя
-			if m.profileHandler != nil {
-				m.profileHandler.UpdateLastSeen(userID)
-				// Получаем сохраненный цвет пользователя, если есть
-				if userColor, err := m.profileHandler.FindUserColor(userID); err == nil && userColor != "" {
-					color = userColor
-				}
-				// Получаем username из базы данных для авторизованного пользователя
-				if user, err := m.profileHandler.FindUserByID(userID); err == nil {
-					initialNickname = user.Username
-				}
+	if claims != nil {
+		userID = claims.UserID
+		authSessionID = claims.SessionID
+		initialNickname = claims.Username
+		// Обновляем last_seen для пользователя
+		if m.profileHandler != nil {
+			m.profileHandler.UpdateLastSeen(userID)
+			// Получаем сохраненный цвет пользователя, если есть
+			if userColor, err := m.profileHandler.FindUserColor(userID); err == nil && userColor != "" {
+				color = userColor
+			}
+			// Получаем username из базы данных для авторизованного пользователя
+			if user, err := m.profileHandler.FindUserByID(userID); err == nil {
+				initialNickname = user.Username
 			}
 		}
 	}
 
 	player := &Player{
+		ID:           playerID,
+		UserID:       userID,
+		Nickname:     initialNickname,
+		Color:        color,
+		Conn:         conn,
+		SessionToken:  utils.GenerateUUID(),
+		RoomID:        roomID,
+		AuthSessionID: authSessionID,
+	}
+
+	// Добавляем игрока в комнату (game.Player без WebSocket соединения)
+	roomPlayer := &game.Player{
 		ID:       playerID,
 		UserID:   userID,
 		Nickname: initialNickname,
 		Color:    color,
-		Conn:     conn,
+	}
+
+	// spectate=1 - подключение зрителем: зритель видит игру, но не ходит
+	if r.URL.Query().Get("spectate") == "1" {
+		if err := room.AddSpectator(playerID, roomPlayer); err != nil {
+			log.Printf("Зритель не допущен в комнату %s: %v", roomID, err)
+			errorMsg, _ := EncodeErrorProtobuf(err.Error())
+			conn.WriteMessage(websocket.BinaryMessage, errorMsg)
+			conn.Close()
+			if room.GetPlayerCount() == 0 {
+				m.roomManager.ScheduleRoomDeletion(roomID, 5*time.Minute)
+			}
+			return
+		}
+	} else {
+		room.AddPlayer(playerID, roomPlayer)
 	}
 
 	// Сохраняем WebSocket Player в Manager
 	m.wsPlayersMu.Lock()
 	m.wsPlayers[playerID] = player
+	m.sessions[player.SessionToken] = player
 	m.wsPlayersMu.Unlock()
 
-	// Добавляем игрока в комнату (game.Player без WebSocket соединения)
-	roomPlayer := &game.Player{
-		ID:       playerID,
-		UserID:   userID,
-		Nickname: initialNickname,
-		Color:    color,
+	log.Printf("Игрок %s подключен к комнате %s", playerID, roomID)
+
+	// Токен сессии нужен клиенту для переподключения
+	if sessionMsg, err := EncodeSessionProtobuf(player.SessionToken, playerID, false, 0); err == nil {
+		if err := player.Send(sessionMsg, false); err != nil {
+			log.Printf("[WS OUT] Ошибка отправки session игроку %s: %v", playerID, err)
+		}
 	}
 
-	// Добавляем игрока в комнату
-	room.AddPlayer(playerID, roomPlayer)
+	// Отправка начального состояния игры
+	m.gameService.SendGameStateToPlayer(room, player)
 
-	log.Printf("Игрок %s подключен к комнате %s", playerID, roomID)
+	// Отправка списка игроков новому игроку
+	m.gameService.SendPlayerListToPlayer(room, player)
+
+	m.serveConnection(conn, room, player)
+}
+
+// resumeSession привязывает новое соединение к сессии игрока
+// Пропущенные сообщения повторяются, а если их уже нет в буфере - отправляется полное состояние
+// Возвращает false, если сессия успела истечь
+func (m *Manager) resumeSession(conn *websocket.Conn, room *game.Room, player *Player, lastSeq uint64) bool {
+	oldConn, replayed, err := player.resume(conn, lastSeq)
+	if oldConn != nil {
+		oldConn.Close()
+	}
+	if err == errSessionExpired {
+		return false
+	}
+	if err != nil {
+		log.Printf("[WS OUT] Ошибка повтора сообщений игроку %s: %v", player.ID, err)
+	}
+
+	log.Printf("Игрок %s возобновил сессию в комнате %s (lastSeq=%d, повтор=%v)", player.ID, player.RoomID, lastSeq, replayed)
+	if !replayed {
+		m.gameService.SendGameStateToPlayer(room, player)
+		m.gameService.SendPlayerListToPlayer(room, player)
+	}
+	return true
+}
+
+// serveConnection обслуживает соединение игрока до его разрыва
+// После разрыва игрок остается в комнате на grace-период и может возобновить сессию
+func (m *Manager) serveConnection(conn *websocket.Conn, room *game.Room, player *Player) {
+	playerID := player.ID
 
 	// Настройка ping-pong для поддержания соединения
 	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
@@ -149,7 +244,7 @@ func (m *Manager) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		go func() {
 			for range pingTicker.C {
 				player.Mu.Lock()
-				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(sessionWriteTimeout)); err != nil {
 					log.Printf("Ошибка отправки ping игроку %s: %v", playerID, err)
 					player.Mu.Unlock()
 					return
@@ -158,23 +253,36 @@ func (m *Manager) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 			}
 		}()
 
-	// Отправка начального состояния игры
-	m.gameService.SendGameStateToPlayer(room, player)
+	// Обработка сообщений
+	m.handleMessages(conn, room, player, playerID, player.RoomID)
+	conn.Close()
 
-	// Отправка списка игроков новому игроку
-	m.gameService.SendPlayerListToPlayer(room, player)
+	if !player.detach(conn, func() { m.leaveRoom(room, player) }) {
+		log.Printf("Соединение игрока %s заменено новым", playerID)
+		return
+	}
+	log.Printf("Игрок %s потерял соединение, ожидание переподключения %v", playerID, sessionGracePeriod)
+}
 
-	// Обработка сообщений
-	m.handleMessages(conn, room, player, playerID, roomID)
+// leaveRoom удаляет игрока из комнаты, когда его сессия истекла
+func (m *Manager) leaveRoom(room *game.Room, player *Player) {
+	playerID := player.ID
+	roomID := player.RoomID
 
-	// Отключение игрока
-	m.removeWSPlayer(playerID)
+	m.wsPlayersMu.Lock()
+	delete(m.wsPlayers, playerID)
+	delete(m.sessions, player.SessionToken)
+	m.wsPlayersMu.Unlock()
 
 	// Удаляем из комнаты
 	room.RemovePlayer(playerID)
 
+	// Боты не играют в комнате без людей
+	if room.GetHumanCount() == 0 {
+		m.gameService.RemoveBots(room)
+	}
+
 	m.gameService.BroadcastPlayerList(room)
-	conn.Close()
 
 	// Получаем количество игроков для логирования
 	playersLeft := room.GetPlayerCount()
@@ -257,6 +365,12 @@ func (m *Manager) handleMessages(conn *websocket.Conn, room *game.Room, player *
 			log.Printf("[WS IN] Игрок %s: тип=%s", playerID, msg.Type)
 		}
 
+		// Зрители только смотрят: ходы, подсказки, новая игра и боты им недоступны
+		if spectatorForbidden[msg.Type] && room.IsSpectator(playerID) {
+			m.sendError(player, playerID, msg.Type, fmt.Errorf("spectators can't play"))
+			continue
+		}
+
 		log.Printf("[WS IN] Игрок %s: обработка сообщения type=%s в switch", playerID, msg.Type)
 		switch msg.Type {
 		case "ping":
@@ -287,6 +401,22 @@ func (m *Manager) handleMessages(conn *websocket.Conn, room *game.Room, player *
 			log.Printf("[WS IN] Игрок %s: вызов handleNewGame", playerID)
 			m.handleNewGame(room, roomID)
 			log.Printf("[WS IN] Игрок %s: handleNewGame завершен", playerID)
+		case "addBot", "removeBot":
+			log.Printf("[WS IN] Игрок %s: вызов handleBot", playerID)
+			m.handleBot(room, player, playerID, msg)
+			log.Printf("[WS IN] Игрок %s: handleBot завершен", playerID)
+		case "ack":
+			if msg.Ack != nil {
+				player.Ack(msg.Ack.Seq)
+				m.gameService.AckState(room, playerID, msg.Ack.Version, msg.Ack.Resync)
+			}
+		case "auth":
+			// Токен принимается только при подключении
+			m.sendError(player, playerID, msg.Type, fmt.Errorf("already connected"))
+		case "setTeam":
+			log.Printf("[WS IN] Игрок %s: вызов handleSetTeam", playerID)
+			m.handleSetTeam(room, player, playerID, msg)
+			log.Printf("[WS IN] Игрок %s: handleSetTeam завершен", playerID)
 		default:
 			log.Printf("[WS IN] Игрок %s: неизвестный тип сообщения в switch: %s", playerID, msg.Type)
 		}
@@ -294,6 +424,15 @@ func (m *Manager) handleMessages(conn *websocket.Conn, room *game.Room, player *
 	}
 }
 
+// spectatorForbidden типы сообщений, которые отклоняются от зрителей
+var spectatorForbidden = map[string]bool{
+	"cellClick": true,
+	"hint":      true,
+	"newGame":   true,
+	"addBot":    true,
+	"removeBot": true,
+}
+
 // handlePing обрабатывает ping сообщение
 func (m *Manager) handlePing(player *Player, playerID string) {
 	player.Mu.Lock()
@@ -400,6 +539,50 @@ func (m *Manager) handleHint(room *game.Room, playerID string, msg *game.Message
 	}
 }
 
+// handleBot обрабатывает добавление и удаление ботов (только создатель комнаты)
+func (m *Manager) handleBot(room *game.Room, player *Player, playerID string, msg *game.Message) {
+	if msg.Bot == nil {
+		return
+	}
+
+	var err error
+	userID := player.GetUserID()
+	if userID == 0 || !room.IsCreator(userID) {
+		err = fmt.Errorf("only room creator can manage bots")
+	} else if msg.Type == "addBot" {
+		err = m.gameService.AddBot(room, msg.Bot.Skill)
+	} else {
+		err = m.gameService.RemoveBot(room, msg.Bot.PlayerID)
+	}
+	if err == nil {
+		return
+	}
+	m.sendError(player, playerID, msg.Type, err)
+}
+
+// handleSetTeam обрабатывает выбор команды (режим versus)
+func (m *Manager) handleSetTeam(room *game.Room, player *Player, playerID string, msg *game.Message) {
+	if msg.Team == nil {
+		return
+	}
+	if err := m.gameService.SetTeam(room, playerID, msg.Team.Team); err != nil {
+		m.sendError(player, playerID, msg.Type, err)
+	}
+}
+
+// sendError сообщает игроку об ошибке обработки его сообщения
+func (m *Manager) sendError(player *Player, playerID, msgType string, err error) {
+	log.Printf("Ошибка обработки %s от игрока %s: %v", msgType, playerID, err)
+	errorMsg, _ := EncodeErrorProtobuf(err.Error())
+	player.Mu.Lock()
+	defer player.Mu.Unlock()
+	if player.Conn != nil {
+		if err := player.Conn.WriteMessage(websocket.BinaryMessage, errorMsg); err != nil {
+			log.Printf("[WS OUT] Ошибка отправки error игроку %s: %v", playerID, err)
+		}
+	}
+}
+
 // handleNewGame обрабатывает запрос новой игры
 func (m *Manager) handleNewGame(room *game.Room, roomID string) {
 	log.Printf("Обработка newGame для комнаты %s", roomID)
@@ -411,6 +594,8 @@ func (m *Manager) handleNewGame(room *game.Room, roomID string) {
 		if err := m.roomManager.SaveRoom(room); err != nil {
 			log.Printf("Предупреждение: не удалось сохранить комнату %s после сброса игры: %v", roomID, err)
 		}
+		// В режиме race новая игра начинается с обратного отсчета
+		m.gameService.StartRace(room)
 		// Отправляем состояние игры после сброса
 		m.gameService.BroadcastGameState(room)
 	}()
@@ -440,10 +625,35 @@ func (m *Manager) getWSPlayer(playerID string) *Player {
 	return m.GetWSPlayer(playerID)
 }
 
-// removeWSPlayer удаляет WebSocket Player
-func (m *Manager) removeWSPlayer(playerID string) {
+// CloseRoom отключает всех игроков комнаты без возможности возобновить сессию (комната удалена)
+func (m *Manager) CloseRoom(roomID string) {
 	m.wsPlayersMu.Lock()
-	defer m.wsPlayersMu.Unlock()
-	delete(m.wsPlayers, playerID)
+	players := make([]*Player, 0)
+	for id, player := range m.wsPlayers {
+		if player.RoomID == roomID {
+			players = append(players, player)
+			delete(m.wsPlayers, id)
+			delete(m.sessions, player.SessionToken)
+		}
+	}
+	m.wsPlayersMu.Unlock()
+
+	errorMsg, _ := EncodeErrorProtobuf("Room deleted")
+	for _, player := range players {
+		player.Mu.Lock()
+		if player.Conn != nil {
+			player.Conn.WriteMessage(websocket.BinaryMessage, errorMsg)
+			player.Conn.Close()
+		}
+		player.Mu.Unlock()
+	}
+	log.Printf("Комната %s закрыта, отключено игроков: %d", roomID, len(players))
+}
+
+// getSession получает игрока по токену сессии
+func (m *Manager) getSession(token string) *Player {
+	m.wsPlayersMu.RLock()
+	defer m.wsPlayersMu.RUnlock()
+	return m.sessions[token]
 }
 
@@ -74,8 +74,9 @@ type SafeCell struct {
 
 // CellHint представляет подсказку для ячейки
 type CellHint struct {
-	Row  int    `json:"r"`
-	Col  int    `json:"c"`
-	Type string `json:"t"` // "MINE", "SAFE", "UNKNOWN"
+	Row         int     `json:"r"`
+	Col         int     `json:"c"`
+	Type        string  `json:"t"`           // "MINE", "SAFE", "UNKNOWN", "PROBABILITY"
+	Probability float64 `json:"p,omitempty"` // Точная вероятность мины (режим training)
 }
 
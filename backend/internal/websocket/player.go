@@ -18,6 +18,15 @@ type Player struct {
 	LastCursorX        float64
 	LastCursorY        float64
 	LastCursorSendTime time.Time
+
+	// Сессия: по токену игрок в течение grace-периода переподключается под тем же ID
+	SessionToken  string
+	RoomID        string
+	AuthSessionID int           // Сессия входа (sid JWT), под которой игрок подключился, 0 для гостя
+	seq           uint64        // Номер последнего пронумерованного сообщения
+	outbox        []outboxEntry // Последние пронумерованные сообщения для повтора
+	removeTimer   *time.Timer   // Удаление из комнаты, если игрок не переподключится
+	expired       bool          // Grace-период истек, сессию нельзя возобновить
 }
 
 // GetNickname возвращает никнейм игрока
@@ -128,6 +128,22 @@ func EncodePongProtobuf() ([]byte, error) {
 	return proto.Marshal(wsMsg)
 }
 
+// EncodeSessionProtobuf кодирует сведения о сессии игрока в protobuf формат
+func EncodeSessionProtobuf(token, playerID string, resumed bool, seq uint64) ([]byte, error) {
+	wsMsg := &pb.WebSocketMessage{
+		Message: &pb.WebSocketMessage_Session{
+			Session: &pb.SessionMessage{
+				Token:    token,
+				PlayerId: truncatePlayerID(playerID),
+				Resumed:  resumed,
+				Seq:      seq,
+			},
+		},
+	}
+
+	return proto.Marshal(wsMsg)
+}
+
 // EncodeErrorProtobuf кодирует сообщение об ошибке в protobuf формат
 func EncodeErrorProtobuf(errorMsg string) ([]byte, error) {
 	errorMsgProto := &pb.ErrorMessage{
@@ -161,14 +177,18 @@ func decodeClientMessageProtobuf(data []byte) (*game.Message, error) {
 	msg := &game.Message{}
 
 	// Детальное логирование для диагностики
-	log.Printf("[DECODE] Декодирование ClientMessage: nickname=%v, cursor=%v, cellClick=%v, hint=%v, newGame=%v, chat=%v, ping=%v",
+	log.Printf("[DECODE] Декодирование ClientMessage: nickname=%v, cursor=%v, cellClick=%v, hint=%v, newGame=%v, chat=%v, ping=%v, addBot=%v, removeBot=%v, setTeam=%v, ack=%v",
 		clientMsg.GetNickname() != "",
 		clientMsg.GetCursor() != nil,
 		clientMsg.GetCellClick() != nil,
 		clientMsg.GetHint() != nil,
 		clientMsg.GetNewGame() != nil,
 		clientMsg.GetChat() != nil,
-		clientMsg.GetPing() != nil)
+		clientMsg.GetPing() != nil,
+		clientMsg.GetAddBot() != nil,
+		clientMsg.GetRemoveBot() != nil,
+		clientMsg.GetSetTeam() != nil,
+		clientMsg.GetAck() != nil)
 
 	switch {
 	case clientMsg.GetNickname() != "":
@@ -218,6 +238,7 @@ func decodeClientMessageProtobuf(data []byte) (*game.Message, error) {
 			Action:   chatProto.Action,
 			Row:      int(chatProto.Row),
 			Col:      int(chatProto.Col),
+			TeamOnly: chatProto.TeamOnly,
 		}
 		log.Printf("[DECODE] Определен тип: chat, text=%s", msg.Chat.Text)
 
@@ -225,6 +246,39 @@ func decodeClientMessageProtobuf(data []byte) (*game.Message, error) {
 		msg.Type = "ping"
 		log.Printf("[DECODE] Определен тип: ping")
 
+	case clientMsg.GetAddBot() != nil:
+		addBotProto := clientMsg.GetAddBot()
+		msg.Type = "addBot"
+		msg.Bot = &game.BotCommand{
+			Skill: game.ParseBotSkill(addBotProto.Perfect, addBotProto.GuessPolicy, int(addBotProto.ClickDelayMs)),
+		}
+		log.Printf("[DECODE] Определен тип: addBot, perfect=%v, guessPolicy=%s", addBotProto.Perfect, addBotProto.GuessPolicy)
+
+	case clientMsg.GetRemoveBot() != nil:
+		msg.Type = "removeBot"
+		msg.Bot = &game.BotCommand{
+			PlayerID: clientMsg.GetRemoveBot().PlayerId,
+		}
+		log.Printf("[DECODE] Определен тип: removeBot, playerId=%s", msg.Bot.PlayerID)
+
+	case clientMsg.GetSetTeam() != nil:
+		msg.Type = "setTeam"
+		msg.Team = &game.TeamCommand{
+			Team: int(clientMsg.GetSetTeam().Team),
+		}
+		log.Printf("[DECODE] Определен тип: setTeam, team=%d", msg.Team.Team)
+
+	case clientMsg.GetAuth() != nil:
+		msg.Type = "auth"
+
+	case clientMsg.GetAck() != nil:
+		msg.Type = "ack"
+		msg.Ack = &game.AckCommand{
+			Seq:     clientMsg.GetAck().Seq,
+			Version: clientMsg.GetAck().Version,
+			Resync:  clientMsg.GetAck().Resync,
+		}
+
 	default:
 		log.Printf("[DECODE] ОШИБКА: неизвестный тип сообщения в ClientMessage")
 		return nil, fmt.Errorf("unknown message type in ClientMessage")
@@ -0,0 +1,156 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/encoding/protowire"
+	"minesweeperonline/internal/auth"
+)
+
+// sessionGracePeriod сколько игрок остается в комнате после разрыва соединения
+const sessionGracePeriod = 30 * time.Second
+
+// sessionOutboxSize сколько последних пронумерованных сообщений хранится для повтора
+const sessionOutboxSize = 512
+
+// sessionWriteTimeout сколько ждать записи в соединение, прежде чем считать его зависшим
+const sessionWriteTimeout = 10 * time.Second
+
+// seqFieldNumber номер поля seq в WebSocketMessage
+const seqFieldNumber = 14
+
+// errSessionExpired сессию нельзя возобновить: игрок уже удален из комнаты
+var errSessionExpired = errors.New("session expired")
+
+// outboxEntry пронумерованное сообщение, ожидающее подтверждения
+type outboxEntry struct {
+	seq  uint64
+	data []byte
+}
+
+// Send отправляет игроку бинарное сообщение
+// replay - сообщение получает номер в сессии и хранится до подтверждения, чтобы повторить его при возобновлении
+// Пока соединения нет, пронумерованное сообщение только сохраняется
+func (p *Player) Send(data []byte, replay bool) error {
+	p.Mu.Lock()
+	defer p.Mu.Unlock()
+
+	if replay {
+		p.seq++
+		data = appendSeq(data, p.seq)
+		p.outbox = append(p.outbox, outboxEntry{seq: p.seq, data: data})
+		if len(p.outbox) > sessionOutboxSize {
+			p.outbox = append(p.outbox[:0], p.outbox[len(p.outbox)-sessionOutboxSize:]...)
+		}
+	}
+	if p.Conn == nil {
+		if replay {
+			return nil
+		}
+		return fmt.Errorf("connection is not available")
+	}
+	// Без дедлайна зависший клиент блокировал бы отправителя, пока TCP не оборвет соединение
+	p.Conn.SetWriteDeadline(time.Now().Add(sessionWriteTimeout))
+	return p.Conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+// Ack отбрасывает сообщения, получение которых подтвердил клиент
+func (p *Player) Ack(seq uint64) {
+	p.Mu.Lock()
+	defer p.Mu.Unlock()
+
+	i := 0
+	for i < len(p.outbox) && p.outbox[i].seq <= seq {
+		i++
+	}
+	p.outbox = append(p.outbox[:0], p.outbox[i:]...)
+}
+
+// appendSeq дописывает к закодированному WebSocketMessage номер сообщения
+// Копирует data: одно и то же сообщение рассылается нескольким игрокам с разными номерами
+func appendSeq(data []byte, seq uint64) []byte {
+	out := make([]byte, len(data), len(data)+protowire.SizeTag(seqFieldNumber)+protowire.SizeVarint(seq))
+	copy(out, data)
+	out = protowire.AppendTag(out, seqFieldNumber, protowire.VarintType)
+	return protowire.AppendVarint(out, seq)
+}
+
+// detach отвязывает разорванное соединение и запускает grace-период
+// Возвращает false, если игрок уже переподключился через другое соединение
+func (p *Player) detach(conn *websocket.Conn, onExpire func()) bool {
+	p.Mu.Lock()
+	defer p.Mu.Unlock()
+
+	if p.Conn != conn {
+		return false
+	}
+	p.Conn = nil
+	p.removeTimer = time.AfterFunc(sessionGracePeriod, func() {
+		p.Mu.Lock()
+		if p.Conn != nil || p.expired {
+			p.Mu.Unlock()
+			return
+		}
+		p.expired = true
+		p.Mu.Unlock()
+		onExpire()
+	})
+	return true
+}
+
+// canResume проверяет, что сессию возобновляет тот же вход, под которым игрок подключился
+// Гость возобновляет сессию без токена; пользователь - только с действующим access токеном той же сессии входа,
+// поэтому после выхода, отзыва сессий или смены пароля игровая сессия не возобновляется
+func (p *Player) canResume(claims *auth.Claims) bool {
+	if claims == nil {
+		return p.UserID == 0
+	}
+	return p.UserID == claims.UserID && p.AuthSessionID == claims.SessionID
+}
+
+// resume привязывает к сессии новое соединение и повторяет сообщения с номером больше lastSeq
+// Возвращает старое соединение (его нужно закрыть) и признак полного повтора
+// Если часть сообщений уже отброшена из буфера, replayed = false и клиенту нужен полный снимок состояния
+func (p *Player) resume(conn *websocket.Conn, lastSeq uint64) (oldConn *websocket.Conn, replayed bool, err error) {
+	p.Mu.Lock()
+	defer p.Mu.Unlock()
+
+	if p.expired {
+		return nil, false, errSessionExpired
+	}
+	if p.removeTimer != nil {
+		p.removeTimer.Stop()
+		p.removeTimer = nil
+	}
+	oldConn = p.Conn
+	p.Conn = conn
+
+	sessionMsg, err := EncodeSessionProtobuf(p.SessionToken, p.ID, true, p.seq)
+	if err != nil {
+		return oldConn, false, err
+	}
+	if err := conn.WriteMessage(websocket.BinaryMessage, sessionMsg); err != nil {
+		return oldConn, false, err
+	}
+
+	// Недостающие сообщения должны идти подряд с lastSeq+1
+	first := p.seq + 1
+	if len(p.outbox) > 0 {
+		first = p.outbox[0].seq
+	}
+	if lastSeq > p.seq || lastSeq+1 < first {
+		return oldConn, false, nil
+	}
+	for _, entry := range p.outbox {
+		if entry.seq <= lastSeq {
+			continue
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, entry.data); err != nil {
+			return oldConn, false, err
+		}
+	}
+	return oldConn, true, nil
+}
@@ -0,0 +1,31 @@
+package websocket
+
+import (
+	"testing"
+
+	"minesweeperonline/internal/auth"
+)
+
+func TestCanResume(t *testing.T) {
+	guest := &Player{}
+	user := &Player{UserID: 7, AuthSessionID: 3}
+
+	tests := []struct {
+		name   string
+		player *Player
+		claims *auth.Claims
+		want   bool
+	}{
+		{"гость без токена", guest, nil, true},
+		{"гость с чужим токеном", guest, &auth.Claims{UserID: 7, SessionID: 3}, false},
+		{"пользователь без токена", user, nil, false},
+		{"пользователь с токеном того же входа", user, &auth.Claims{UserID: 7, SessionID: 3}, true},
+		{"пользователь с токеном другого входа", user, &auth.Claims{UserID: 7, SessionID: 4}, false},
+		{"другой пользователь", user, &auth.Claims{UserID: 8, SessionID: 3}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.player.canResume(tt.claims); got != tt.want {
+			t.Errorf("%s: canResume = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
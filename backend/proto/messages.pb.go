@@ -24,20 +24,22 @@ const (
 type CellType int32
 
 const (
-	CellType_CELL_TYPE_NEIGHBOR_0 CellType = 0   // Открытая клетка с 0 соседних мин
-	CellType_CELL_TYPE_NEIGHBOR_1 CellType = 1   // Открытая клетка с 1 соседней миной
-	CellType_CELL_TYPE_NEIGHBOR_2 CellType = 2   // Открытая клетка с 2 соседними минами
-	CellType_CELL_TYPE_NEIGHBOR_3 CellType = 3   // Открытая клетка с 3 соседними минами
-	CellType_CELL_TYPE_NEIGHBOR_4 CellType = 4   // Открытая клетка с 4 соседними минами
-	CellType_CELL_TYPE_NEIGHBOR_5 CellType = 5   // Открытая клетка с 5 соседними минами
-	CellType_CELL_TYPE_NEIGHBOR_6 CellType = 6   // Открытая клетка с 6 соседними минами
-	CellType_CELL_TYPE_NEIGHBOR_7 CellType = 7   // Открытая клетка с 7 соседними минами
-	CellType_CELL_TYPE_NEIGHBOR_8 CellType = 8   // Открытая клетка с 8 соседними минами
-	CellType_CELL_TYPE_MINE       CellType = 9   // Мина
-	CellType_CELL_TYPE_SAFE       CellType = 10  // Зеленая (SAFE) - для режима обучения (закрытая)
-	CellType_CELL_TYPE_UNKNOWN    CellType = 11  // Желтая (UNKNOWN) - для режима обучения (закрытая)
-	CellType_CELL_TYPE_DANGER     CellType = 12  // Красная (MINE) - для режима обучения (закрытая)
-	CellType_CELL_TYPE_CLOSED     CellType = 255 // Закрыта (без подсказок)
+	CellType_CELL_TYPE_NEIGHBOR_0  CellType = 0   // Открытая клетка с 0 соседних мин
+	CellType_CELL_TYPE_NEIGHBOR_1  CellType = 1   // Открытая клетка с 1 соседней миной
+	CellType_CELL_TYPE_NEIGHBOR_2  CellType = 2   // Открытая клетка с 2 соседними минами
+	CellType_CELL_TYPE_NEIGHBOR_3  CellType = 3   // Открытая клетка с 3 соседними минами
+	CellType_CELL_TYPE_NEIGHBOR_4  CellType = 4   // Открытая клетка с 4 соседними минами
+	CellType_CELL_TYPE_NEIGHBOR_5  CellType = 5   // Открытая клетка с 5 соседними минами
+	CellType_CELL_TYPE_NEIGHBOR_6  CellType = 6   // Открытая клетка с 6 соседними минами
+	CellType_CELL_TYPE_NEIGHBOR_7  CellType = 7   // Открытая клетка с 7 соседними минами
+	CellType_CELL_TYPE_NEIGHBOR_8  CellType = 8   // Открытая клетка с 8 соседними минами
+	CellType_CELL_TYPE_MINE        CellType = 9   // Мина
+	CellType_CELL_TYPE_SAFE        CellType = 10  // Зеленая (SAFE) - для режима обучения (закрытая)
+	CellType_CELL_TYPE_UNKNOWN     CellType = 11  // Желтая (UNKNOWN) - для режима обучения (закрытая)
+	CellType_CELL_TYPE_DANGER      CellType = 12  // Красная (MINE) - для режима обучения (закрытая)
+	CellType_CELL_TYPE_PROBABILITY CellType = 13  // Закрытая клетка вне границы, только вероятность мины - для режима обучения
+	CellType_CELL_TYPE_EXPLODED    CellType = 14  // Подорванная мина - в режиме жизней игра продолжается
+	CellType_CELL_TYPE_CLOSED      CellType = 255 // Закрыта (без подсказок)
 )
 
 // Enum value maps for CellType.
@@ -56,23 +58,27 @@ var (
 		10:  "CELL_TYPE_SAFE",
 		11:  "CELL_TYPE_UNKNOWN",
 		12:  "CELL_TYPE_DANGER",
+		13:  "CELL_TYPE_PROBABILITY",
+		14:  "CELL_TYPE_EXPLODED",
 		255: "CELL_TYPE_CLOSED",
 	}
 	CellType_value = map[string]int32{
-		"CELL_TYPE_NEIGHBOR_0": 0,
-		"CELL_TYPE_NEIGHBOR_1": 1,
-		"CELL_TYPE_NEIGHBOR_2": 2,
-		"CELL_TYPE_NEIGHBOR_3": 3,
-		"CELL_TYPE_NEIGHBOR_4": 4,
-		"CELL_TYPE_NEIGHBOR_5": 5,
-		"CELL_TYPE_NEIGHBOR_6": 6,
-		"CELL_TYPE_NEIGHBOR_7": 7,
-		"CELL_TYPE_NEIGHBOR_8": 8,
-		"CELL_TYPE_MINE":       9,
-		"CELL_TYPE_SAFE":       10,
-		"CELL_TYPE_UNKNOWN":    11,
-		"CELL_TYPE_DANGER":     12,
-		"CELL_TYPE_CLOSED":     255,
+		"CELL_TYPE_NEIGHBOR_0":  0,
+		"CELL_TYPE_NEIGHBOR_1":  1,
+		"CELL_TYPE_NEIGHBOR_2":  2,
+		"CELL_TYPE_NEIGHBOR_3":  3,
+		"CELL_TYPE_NEIGHBOR_4":  4,
+		"CELL_TYPE_NEIGHBOR_5":  5,
+		"CELL_TYPE_NEIGHBOR_6":  6,
+		"CELL_TYPE_NEIGHBOR_7":  7,
+		"CELL_TYPE_NEIGHBOR_8":  8,
+		"CELL_TYPE_MINE":        9,
+		"CELL_TYPE_SAFE":        10,
+		"CELL_TYPE_UNKNOWN":     11,
+		"CELL_TYPE_DANGER":      12,
+		"CELL_TYPE_PROBABILITY": 13,
+		"CELL_TYPE_EXPLODED":    14,
+		"CELL_TYPE_CLOSED":      255,
 	}
 )
 
@@ -115,7 +121,15 @@ type WebSocketMessage struct {
 	//	*WebSocketMessage_Pong
 	//	*WebSocketMessage_Error
 	//	*WebSocketMessage_CellUpdate
+	//	*WebSocketMessage_HintExplanation
+	//	*WebSocketMessage_RaceStandings
+	//	*WebSocketMessage_VersusStatus
+	//	*WebSocketMessage_TimeSync
+	//	*WebSocketMessage_TurnStatus
+	//	*WebSocketMessage_Session
 	Message       isWebSocketMessage_Message `protobuf_oneof:"message"`
+	Seq           uint64                     `protobuf:"varint,14,opt,name=seq,proto3" json:"seq,omitempty"`         // Номер сообщения в сессии игрока (0 - сообщение не нумеруется и не повторяется)
+	Version       uint64                     `protobuf:"varint,15,opt,name=version,proto3" json:"version,omitempty"` // Версия состояния поля комнаты после этого сообщения (0 - сообщение не меняет общее поле)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -220,6 +234,74 @@ func (x *WebSocketMessage) GetCellUpdate() *CellUpdateMessage {
 	return nil
 }
 
+func (x *WebSocketMessage) GetHintExplanation() *HintExplanationMessage {
+	if x != nil {
+		if x, ok := x.Message.(*WebSocketMessage_HintExplanation); ok {
+			return x.HintExplanation
+		}
+	}
+	return nil
+}
+
+func (x *WebSocketMessage) GetRaceStandings() *RaceStandingsMessage {
+	if x != nil {
+		if x, ok := x.Message.(*WebSocketMessage_RaceStandings); ok {
+			return x.RaceStandings
+		}
+	}
+	return nil
+}
+
+func (x *WebSocketMessage) GetVersusStatus() *VersusStatusMessage {
+	if x != nil {
+		if x, ok := x.Message.(*WebSocketMessage_VersusStatus); ok {
+			return x.VersusStatus
+		}
+	}
+	return nil
+}
+
+func (x *WebSocketMessage) GetTimeSync() *TimeSyncMessage {
+	if x != nil {
+		if x, ok := x.Message.(*WebSocketMessage_TimeSync); ok {
+			return x.TimeSync
+		}
+	}
+	return nil
+}
+
+func (x *WebSocketMessage) GetTurnStatus() *TurnStatusMessage {
+	if x != nil {
+		if x, ok := x.Message.(*WebSocketMessage_TurnStatus); ok {
+			return x.TurnStatus
+		}
+	}
+	return nil
+}
+
+func (x *WebSocketMessage) GetSession() *SessionMessage {
+	if x != nil {
+		if x, ok := x.Message.(*WebSocketMessage_Session); ok {
+			return x.Session
+		}
+	}
+	return nil
+}
+
+func (x *WebSocketMessage) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *WebSocketMessage) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type isWebSocketMessage_Message interface {
 	isWebSocketMessage_Message()
 }
@@ -252,6 +334,30 @@ type WebSocketMessage_CellUpdate struct {
 	CellUpdate *CellUpdateMessage `protobuf:"bytes,7,opt,name=cell_update,json=cellUpdate,proto3,oneof"`
 }
 
+type WebSocketMessage_HintExplanation struct {
+	HintExplanation *HintExplanationMessage `protobuf:"bytes,8,opt,name=hint_explanation,json=hintExplanation,proto3,oneof"`
+}
+
+type WebSocketMessage_RaceStandings struct {
+	RaceStandings *RaceStandingsMessage `protobuf:"bytes,9,opt,name=race_standings,json=raceStandings,proto3,oneof"`
+}
+
+type WebSocketMessage_VersusStatus struct {
+	VersusStatus *VersusStatusMessage `protobuf:"bytes,10,opt,name=versus_status,json=versusStatus,proto3,oneof"`
+}
+
+type WebSocketMessage_TimeSync struct {
+	TimeSync *TimeSyncMessage `protobuf:"bytes,11,opt,name=time_sync,json=timeSync,proto3,oneof"`
+}
+
+type WebSocketMessage_TurnStatus struct {
+	TurnStatus *TurnStatusMessage `protobuf:"bytes,12,opt,name=turn_status,json=turnStatus,proto3,oneof"`
+}
+
+type WebSocketMessage_Session struct {
+	Session *SessionMessage `protobuf:"bytes,13,opt,name=session,proto3,oneof"`
+}
+
 func (*WebSocketMessage_GameState) isWebSocketMessage_Message() {}
 
 func (*WebSocketMessage_Chat) isWebSocketMessage_Message() {}
@@ -266,6 +372,18 @@ func (*WebSocketMessage_Error) isWebSocketMessage_Message() {}
 
 func (*WebSocketMessage_CellUpdate) isWebSocketMessage_Message() {}
 
+func (*WebSocketMessage_HintExplanation) isWebSocketMessage_Message() {}
+
+func (*WebSocketMessage_RaceStandings) isWebSocketMessage_Message() {}
+
+func (*WebSocketMessage_VersusStatus) isWebSocketMessage_Message() {}
+
+func (*WebSocketMessage_TimeSync) isWebSocketMessage_Message() {}
+
+func (*WebSocketMessage_TurnStatus) isWebSocketMessage_Message() {}
+
+func (*WebSocketMessage_Session) isWebSocketMessage_Message() {}
+
 // Входящее сообщение от клиента
 type ClientMessage struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -278,6 +396,11 @@ type ClientMessage struct {
 	//	*ClientMessage_NewGame
 	//	*ClientMessage_Chat
 	//	*ClientMessage_Ping
+	//	*ClientMessage_AddBot
+	//	*ClientMessage_RemoveBot
+	//	*ClientMessage_SetTeam
+	//	*ClientMessage_Ack
+	//	*ClientMessage_Auth
 	Message       isClientMessage_Message `protobuf_oneof:"message"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -383,6 +506,51 @@ func (x *ClientMessage) GetPing() *PingMessage {
 	return nil
 }
 
+func (x *ClientMessage) GetAddBot() *AddBotMessage {
+	if x != nil {
+		if x, ok := x.Message.(*ClientMessage_AddBot); ok {
+			return x.AddBot
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetRemoveBot() *RemoveBotMessage {
+	if x != nil {
+		if x, ok := x.Message.(*ClientMessage_RemoveBot); ok {
+			return x.RemoveBot
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetSetTeam() *SetTeamMessage {
+	if x != nil {
+		if x, ok := x.Message.(*ClientMessage_SetTeam); ok {
+			return x.SetTeam
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetAck() *AckMessage {
+	if x != nil {
+		if x, ok := x.Message.(*ClientMessage_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetAuth() *AuthMessage {
+	if x != nil {
+		if x, ok := x.Message.(*ClientMessage_Auth); ok {
+			return x.Auth
+		}
+	}
+	return nil
+}
+
 type isClientMessage_Message interface {
 	isClientMessage_Message()
 }
@@ -415,6 +583,26 @@ type ClientMessage_Ping struct {
 	Ping *PingMessage `protobuf:"bytes,7,opt,name=ping,proto3,oneof"`
 }
 
+type ClientMessage_AddBot struct {
+	AddBot *AddBotMessage `protobuf:"bytes,8,opt,name=add_bot,json=addBot,proto3,oneof"`
+}
+
+type ClientMessage_RemoveBot struct {
+	RemoveBot *RemoveBotMessage `protobuf:"bytes,9,opt,name=remove_bot,json=removeBot,proto3,oneof"`
+}
+
+type ClientMessage_SetTeam struct {
+	SetTeam *SetTeamMessage `protobuf:"bytes,10,opt,name=set_team,json=setTeam,proto3,oneof"`
+}
+
+type ClientMessage_Ack struct {
+	Ack *AckMessage `protobuf:"bytes,11,opt,name=ack,proto3,oneof"`
+}
+
+type ClientMessage_Auth struct {
+	Auth *AuthMessage `protobuf:"bytes,12,opt,name=auth,proto3,oneof"`
+}
+
 func (*ClientMessage_Nickname) isClientMessage_Message() {}
 
 func (*ClientMessage_Cursor) isClientMessage_Message() {}
@@ -429,24 +617,39 @@ func (*ClientMessage_Chat) isClientMessage_Message() {}
 
 func (*ClientMessage_Ping) isClientMessage_Message() {}
 
+func (*ClientMessage_AddBot) isClientMessage_Message() {}
+
+func (*ClientMessage_RemoveBot) isClientMessage_Message() {}
+
+func (*ClientMessage_SetTeam) isClientMessage_Message() {}
+
+func (*ClientMessage_Ack) isClientMessage_Message() {}
+
+func (*ClientMessage_Auth) isClientMessage_Message() {}
+
 // Состояние игры
 type GameStateMessage struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Board         *Board                 `protobuf:"bytes,1,opt,name=board,proto3" json:"board,omitempty"`
-	Rows          int32                  `protobuf:"varint,2,opt,name=rows,proto3" json:"rows,omitempty"`
-	Cols          int32                  `protobuf:"varint,3,opt,name=cols,proto3" json:"cols,omitempty"`
-	Mines         int32                  `protobuf:"varint,4,opt,name=mines,proto3" json:"mines,omitempty"`
-	Seed          string                 `protobuf:"bytes,13,opt,name=seed,proto3" json:"seed,omitempty"` // Seed для генерации поля (UUID)
-	GameOver      bool                   `protobuf:"varint,5,opt,name=game_over,json=gameOver,proto3" json:"game_over,omitempty"`
-	GameWon       bool                   `protobuf:"varint,6,opt,name=game_won,json=gameWon,proto3" json:"game_won,omitempty"`
-	Revealed      int32                  `protobuf:"varint,7,opt,name=revealed,proto3" json:"revealed,omitempty"`
-	HintsUsed     int32                  `protobuf:"varint,8,opt,name=hints_used,json=hintsUsed,proto3" json:"hints_used,omitempty"`
-	SafeCells     []*SafeCell            `protobuf:"bytes,9,rep,name=safe_cells,json=safeCells,proto3" json:"safe_cells,omitempty"`
-	CellHints     []*CellHint            `protobuf:"bytes,10,rep,name=cell_hints,json=cellHints,proto3" json:"cell_hints,omitempty"`
-	LoserPlayerId string                 `protobuf:"bytes,11,opt,name=loser_player_id,json=loserPlayerId,proto3" json:"loser_player_id,omitempty"`
-	LoserNickname string                 `protobuf:"bytes,12,opt,name=loser_nickname,json=loserNickname,proto3" json:"loser_nickname,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Board          *Board                 `protobuf:"bytes,1,opt,name=board,proto3" json:"board,omitempty"`
+	Rows           int32                  `protobuf:"varint,2,opt,name=rows,proto3" json:"rows,omitempty"`
+	Cols           int32                  `protobuf:"varint,3,opt,name=cols,proto3" json:"cols,omitempty"`
+	Mines          int32                  `protobuf:"varint,4,opt,name=mines,proto3" json:"mines,omitempty"`
+	Seed           string                 `protobuf:"bytes,13,opt,name=seed,proto3" json:"seed,omitempty"` // Seed для генерации поля (UUID)
+	GameOver       bool                   `protobuf:"varint,5,opt,name=game_over,json=gameOver,proto3" json:"game_over,omitempty"`
+	GameWon        bool                   `protobuf:"varint,6,opt,name=game_won,json=gameWon,proto3" json:"game_won,omitempty"`
+	Revealed       int32                  `protobuf:"varint,7,opt,name=revealed,proto3" json:"revealed,omitempty"`
+	HintsUsed      int32                  `protobuf:"varint,8,opt,name=hints_used,json=hintsUsed,proto3" json:"hints_used,omitempty"`
+	SafeCells      []*SafeCell            `protobuf:"bytes,9,rep,name=safe_cells,json=safeCells,proto3" json:"safe_cells,omitempty"`
+	CellHints      []*CellHint            `protobuf:"bytes,10,rep,name=cell_hints,json=cellHints,proto3" json:"cell_hints,omitempty"`
+	LoserPlayerId  string                 `protobuf:"bytes,11,opt,name=loser_player_id,json=loserPlayerId,proto3" json:"loser_player_id,omitempty"`
+	LoserNickname  string                 `protobuf:"bytes,12,opt,name=loser_nickname,json=loserNickname,proto3" json:"loser_nickname,omitempty"`
+	Team           int32                  `protobuf:"varint,14,opt,name=team,proto3" json:"team,omitempty"`                                             // Команда, чье это поле (режим versus), 0 - общее поле комнаты
+	Lives          int32                  `protobuf:"varint,15,opt,name=lives,proto3" json:"lives,omitempty"`                                           // Жизней в начале игры (на всех или на каждого), 0 - первая мина заканчивает игру
+	PerPlayerLives bool                   `protobuf:"varint,16,opt,name=per_player_lives,json=perPlayerLives,proto3" json:"per_player_lives,omitempty"` // Жизни у каждого игрока свои
+	LivesLeft      int32                  `protobuf:"varint,17,opt,name=lives_left,json=livesLeft,proto3" json:"lives_left,omitempty"`                  // Осталось общих жизней
+	PlayerLives    []*PlayerLives         `protobuf:"bytes,18,rep,name=player_lives,json=playerLives,proto3" json:"player_lives,omitempty"`             // Потерянные жизни по игрокам
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *GameStateMessage) Reset() {
@@ -570,6 +773,102 @@ func (x *GameStateMessage) GetLoserNickname() string {
 	return ""
 }
 
+func (x *GameStateMessage) GetTeam() int32 {
+	if x != nil {
+		return x.Team
+	}
+	return 0
+}
+
+func (x *GameStateMessage) GetLives() int32 {
+	if x != nil {
+		return x.Lives
+	}
+	return 0
+}
+
+func (x *GameStateMessage) GetPerPlayerLives() bool {
+	if x != nil {
+		return x.PerPlayerLives
+	}
+	return false
+}
+
+func (x *GameStateMessage) GetLivesLeft() int32 {
+	if x != nil {
+		return x.LivesLeft
+	}
+	return 0
+}
+
+func (x *GameStateMessage) GetPlayerLives() []*PlayerLives {
+	if x != nil {
+		return x.PlayerLives
+	}
+	return nil
+}
+
+// Жизни игрока (режим жизней)
+type PlayerLives struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlayerId      string                 `protobuf:"bytes,1,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	LivesLost     int32                  `protobuf:"varint,2,opt,name=lives_lost,json=livesLost,proto3" json:"lives_lost,omitempty"`
+	LivesLeft     int32                  `protobuf:"varint,3,opt,name=lives_left,json=livesLeft,proto3" json:"lives_left,omitempty"` // Только для жизней у каждого своих
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlayerLives) Reset() {
+	*x = PlayerLives{}
+	mi := &file_messages_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayerLives) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayerLives) ProtoMessage() {}
+
+func (x *PlayerLives) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayerLives.ProtoReflect.Descriptor instead.
+func (*PlayerLives) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PlayerLives) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+func (x *PlayerLives) GetLivesLost() int32 {
+	if x != nil {
+		return x.LivesLost
+	}
+	return 0
+}
+
+func (x *PlayerLives) GetLivesLeft() int32 {
+	if x != nil {
+		return x.LivesLeft
+	}
+	return 0
+}
+
 type Board struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Rows          []*Row                 `protobuf:"bytes,1,rep,name=rows,proto3" json:"rows,omitempty"`
@@ -579,7 +878,7 @@ type Board struct {
 
 func (x *Board) Reset() {
 	*x = Board{}
-	mi := &file_messages_proto_msgTypes[3]
+	mi := &file_messages_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -591,7 +890,7 @@ func (x *Board) String() string {
 func (*Board) ProtoMessage() {}
 
 func (x *Board) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[3]
+	mi := &file_messages_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -604,7 +903,7 @@ func (x *Board) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Board.ProtoReflect.Descriptor instead.
 func (*Board) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{3}
+	return file_messages_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *Board) GetRows() []*Row {
@@ -623,7 +922,7 @@ type Row struct {
 
 func (x *Row) Reset() {
 	*x = Row{}
-	mi := &file_messages_proto_msgTypes[4]
+	mi := &file_messages_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -635,7 +934,7 @@ func (x *Row) String() string {
 func (*Row) ProtoMessage() {}
 
 func (x *Row) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[4]
+	mi := &file_messages_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -648,7 +947,7 @@ func (x *Row) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Row.ProtoReflect.Descriptor instead.
 func (*Row) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{4}
+	return file_messages_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Row) GetCells() []*Cell {
@@ -665,13 +964,14 @@ type Cell struct {
 	IsFlagged     bool                   `protobuf:"varint,3,opt,name=is_flagged,json=isFlagged,proto3" json:"is_flagged,omitempty"`
 	NeighborMines int32                  `protobuf:"varint,4,opt,name=neighbor_mines,json=neighborMines,proto3" json:"neighbor_mines,omitempty"`
 	FlagColor     string                 `protobuf:"bytes,5,opt,name=flag_color,json=flagColor,proto3" json:"flag_color,omitempty"`
+	IsExploded    bool                   `protobuf:"varint,6,opt,name=is_exploded,json=isExploded,proto3" json:"is_exploded,omitempty"` // Подорванная мина (режим жизней): закрыта и отмечена
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Cell) Reset() {
 	*x = Cell{}
-	mi := &file_messages_proto_msgTypes[5]
+	mi := &file_messages_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -683,7 +983,7 @@ func (x *Cell) String() string {
 func (*Cell) ProtoMessage() {}
 
 func (x *Cell) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[5]
+	mi := &file_messages_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -696,7 +996,7 @@ func (x *Cell) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Cell.ProtoReflect.Descriptor instead.
 func (*Cell) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{5}
+	return file_messages_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *Cell) GetIsMine() bool {
@@ -734,6 +1034,13 @@ func (x *Cell) GetFlagColor() string {
 	return ""
 }
 
+func (x *Cell) GetIsExploded() bool {
+	if x != nil {
+		return x.IsExploded
+	}
+	return false
+}
+
 type SafeCell struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Row           int32                  `protobuf:"varint,1,opt,name=row,proto3" json:"row,omitempty"`
@@ -744,7 +1051,7 @@ type SafeCell struct {
 
 func (x *SafeCell) Reset() {
 	*x = SafeCell{}
-	mi := &file_messages_proto_msgTypes[6]
+	mi := &file_messages_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -756,7 +1063,7 @@ func (x *SafeCell) String() string {
 func (*SafeCell) ProtoMessage() {}
 
 func (x *SafeCell) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[6]
+	mi := &file_messages_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -769,7 +1076,7 @@ func (x *SafeCell) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SafeCell.ProtoReflect.Descriptor instead.
 func (*SafeCell) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{6}
+	return file_messages_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *SafeCell) GetRow() int32 {
@@ -790,14 +1097,15 @@ type CellHint struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Row           int32                  `protobuf:"varint,1,opt,name=row,proto3" json:"row,omitempty"`
 	Col           int32                  `protobuf:"varint,2,opt,name=col,proto3" json:"col,omitempty"`
-	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"` // "MINE", "SAFE", "UNKNOWN"
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`                 // "MINE", "SAFE", "UNKNOWN", "PROBABILITY"
+	Probability   float32                `protobuf:"fixed32,4,opt,name=probability,proto3" json:"probability,omitempty"` // Вероятность мины 0..1 (режим training)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CellHint) Reset() {
 	*x = CellHint{}
-	mi := &file_messages_proto_msgTypes[7]
+	mi := &file_messages_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -809,7 +1117,7 @@ func (x *CellHint) String() string {
 func (*CellHint) ProtoMessage() {}
 
 func (x *CellHint) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[7]
+	mi := &file_messages_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -822,7 +1130,7 @@ func (x *CellHint) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CellHint.ProtoReflect.Descriptor instead.
 func (*CellHint) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{7}
+	return file_messages_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *CellHint) GetRow() int32 {
@@ -846,6 +1154,13 @@ func (x *CellHint) GetType() string {
 	return ""
 }
 
+func (x *CellHint) GetProbability() float32 {
+	if x != nil {
+		return x.Probability
+	}
+	return 0
+}
+
 // Сообщение чата
 type ChatMessage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -857,13 +1172,14 @@ type ChatMessage struct {
 	Action        string                 `protobuf:"bytes,6,opt,name=action,proto3" json:"action,omitempty"` // "flag", "reveal", "explode"
 	Row           int32                  `protobuf:"varint,7,opt,name=row,proto3" json:"row,omitempty"`
 	Col           int32                  `protobuf:"varint,8,opt,name=col,proto3" json:"col,omitempty"`
+	TeamOnly      bool                   `protobuf:"varint,9,opt,name=team_only,json=teamOnly,proto3" json:"team_only,omitempty"` // Только своей команде (режим versus)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ChatMessage) Reset() {
 	*x = ChatMessage{}
-	mi := &file_messages_proto_msgTypes[8]
+	mi := &file_messages_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -875,7 +1191,7 @@ func (x *ChatMessage) String() string {
 func (*ChatMessage) ProtoMessage() {}
 
 func (x *ChatMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[8]
+	mi := &file_messages_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -888,7 +1204,7 @@ func (x *ChatMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
 func (*ChatMessage) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{8}
+	return file_messages_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ChatMessage) GetPlayerId() string {
@@ -947,6 +1263,13 @@ func (x *ChatMessage) GetCol() int32 {
 	return 0
 }
 
+func (x *ChatMessage) GetTeamOnly() bool {
+	if x != nil {
+		return x.TeamOnly
+	}
+	return false
+}
+
 // Позиция курсора
 type CursorMessage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -961,7 +1284,7 @@ type CursorMessage struct {
 
 func (x *CursorMessage) Reset() {
 	*x = CursorMessage{}
-	mi := &file_messages_proto_msgTypes[9]
+	mi := &file_messages_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -973,7 +1296,7 @@ func (x *CursorMessage) String() string {
 func (*CursorMessage) ProtoMessage() {}
 
 func (x *CursorMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[9]
+	mi := &file_messages_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -986,7 +1309,7 @@ func (x *CursorMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CursorMessage.ProtoReflect.Descriptor instead.
 func (*CursorMessage) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{9}
+	return file_messages_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *CursorMessage) GetPlayerId() string {
@@ -1028,13 +1351,14 @@ func (x *CursorMessage) GetY() float64 {
 type PlayersMessage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Players       []*Player              `protobuf:"bytes,1,rep,name=players,proto3" json:"players,omitempty"`
+	Spectators    []*Player              `protobuf:"bytes,2,rep,name=spectators,proto3" json:"spectators,omitempty"` // Зрители: видят игру, но не ходят
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PlayersMessage) Reset() {
 	*x = PlayersMessage{}
-	mi := &file_messages_proto_msgTypes[10]
+	mi := &file_messages_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1046,7 +1370,7 @@ func (x *PlayersMessage) String() string {
 func (*PlayersMessage) ProtoMessage() {}
 
 func (x *PlayersMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[10]
+	mi := &file_messages_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1059,7 +1383,7 @@ func (x *PlayersMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlayersMessage.ProtoReflect.Descriptor instead.
 func (*PlayersMessage) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{10}
+	return file_messages_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *PlayersMessage) GetPlayers() []*Player {
@@ -1069,18 +1393,27 @@ func (x *PlayersMessage) GetPlayers() []*Player {
 	return nil
 }
 
+func (x *PlayersMessage) GetSpectators() []*Player {
+	if x != nil {
+		return x.Spectators
+	}
+	return nil
+}
+
 type Player struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	Nickname      string                 `protobuf:"bytes,2,opt,name=nickname,proto3" json:"nickname,omitempty"`
 	Color         string                 `protobuf:"bytes,3,opt,name=color,proto3" json:"color,omitempty"`
+	IsBot         bool                   `protobuf:"varint,4,opt,name=is_bot,json=isBot,proto3" json:"is_bot,omitempty"`
+	Team          int32                  `protobuf:"varint,5,opt,name=team,proto3" json:"team,omitempty"` // Команда в режиме versus (0 - зритель)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Player) Reset() {
 	*x = Player{}
-	mi := &file_messages_proto_msgTypes[11]
+	mi := &file_messages_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1092,7 +1425,7 @@ func (x *Player) String() string {
 func (*Player) ProtoMessage() {}
 
 func (x *Player) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[11]
+	mi := &file_messages_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1105,7 +1438,7 @@ func (x *Player) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Player.ProtoReflect.Descriptor instead.
 func (*Player) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{11}
+	return file_messages_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *Player) GetId() string {
@@ -1129,6 +1462,20 @@ func (x *Player) GetColor() string {
 	return ""
 }
 
+func (x *Player) GetIsBot() bool {
+	if x != nil {
+		return x.IsBot
+	}
+	return false
+}
+
+func (x *Player) GetTeam() int32 {
+	if x != nil {
+		return x.Team
+	}
+	return 0
+}
+
 // Сообщение об ошибке
 type ErrorMessage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -1139,7 +1486,7 @@ type ErrorMessage struct {
 
 func (x *ErrorMessage) Reset() {
 	*x = ErrorMessage{}
-	mi := &file_messages_proto_msgTypes[12]
+	mi := &file_messages_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1151,7 +1498,7 @@ func (x *ErrorMessage) String() string {
 func (*ErrorMessage) ProtoMessage() {}
 
 func (x *ErrorMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[12]
+	mi := &file_messages_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1164,7 +1511,7 @@ func (x *ErrorMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ErrorMessage.ProtoReflect.Descriptor instead.
 func (*ErrorMessage) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{12}
+	return file_messages_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ErrorMessage) GetError() string {
@@ -1183,7 +1530,7 @@ type PongMessage struct {
 
 func (x *PongMessage) Reset() {
 	*x = PongMessage{}
-	mi := &file_messages_proto_msgTypes[13]
+	mi := &file_messages_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1195,7 +1542,7 @@ func (x *PongMessage) String() string {
 func (*PongMessage) ProtoMessage() {}
 
 func (x *PongMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[13]
+	mi := &file_messages_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1208,7 +1555,7 @@ func (x *PongMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PongMessage.ProtoReflect.Descriptor instead.
 func (*PongMessage) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{13}
+	return file_messages_proto_rawDescGZIP(), []int{14}
 }
 
 // Ping сообщение
@@ -1220,7 +1567,7 @@ type PingMessage struct {
 
 func (x *PingMessage) Reset() {
 	*x = PingMessage{}
-	mi := &file_messages_proto_msgTypes[14]
+	mi := &file_messages_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1232,7 +1579,7 @@ func (x *PingMessage) String() string {
 func (*PingMessage) ProtoMessage() {}
 
 func (x *PingMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[14]
+	mi := &file_messages_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1245,7 +1592,7 @@ func (x *PingMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingMessage.ProtoReflect.Descriptor instead.
 func (*PingMessage) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{14}
+	return file_messages_proto_rawDescGZIP(), []int{15}
 }
 
 // Клик по клетке
@@ -1260,7 +1607,7 @@ type CellClickMessage struct {
 
 func (x *CellClickMessage) Reset() {
 	*x = CellClickMessage{}
-	mi := &file_messages_proto_msgTypes[15]
+	mi := &file_messages_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1272,7 +1619,7 @@ func (x *CellClickMessage) String() string {
 func (*CellClickMessage) ProtoMessage() {}
 
 func (x *CellClickMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[15]
+	mi := &file_messages_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1285,7 +1632,7 @@ func (x *CellClickMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CellClickMessage.ProtoReflect.Descriptor instead.
 func (*CellClickMessage) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{15}
+	return file_messages_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *CellClickMessage) GetRow() int32 {
@@ -1320,7 +1667,7 @@ type HintMessage struct {
 
 func (x *HintMessage) Reset() {
 	*x = HintMessage{}
-	mi := &file_messages_proto_msgTypes[16]
+	mi := &file_messages_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1332,7 +1679,7 @@ func (x *HintMessage) String() string {
 func (*HintMessage) ProtoMessage() {}
 
 func (x *HintMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[16]
+	mi := &file_messages_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1345,7 +1692,7 @@ func (x *HintMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HintMessage.ProtoReflect.Descriptor instead.
 func (*HintMessage) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{16}
+	return file_messages_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *HintMessage) GetRow() int32 {
@@ -1371,7 +1718,7 @@ type NewGameMessage struct {
 
 func (x *NewGameMessage) Reset() {
 	*x = NewGameMessage{}
-	mi := &file_messages_proto_msgTypes[17]
+	mi := &file_messages_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1383,7 +1730,7 @@ func (x *NewGameMessage) String() string {
 func (*NewGameMessage) ProtoMessage() {}
 
 func (x *NewGameMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[17]
+	mi := &file_messages_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1396,7 +1743,158 @@ func (x *NewGameMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NewGameMessage.ProtoReflect.Descriptor instead.
 func (*NewGameMessage) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{17}
+	return file_messages_proto_rawDescGZIP(), []int{18}
+}
+
+// Добавление бота (только создатель комнаты)
+type AddBotMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Perfect       bool                   `protobuf:"varint,1,opt,name=perfect,proto3" json:"perfect,omitempty"`                           // Все логические выводы, иначе только простые по одной цифре
+	GuessPolicy   string                 `protobuf:"bytes,2,opt,name=guess_policy,json=guessPolicy,proto3" json:"guess_policy,omitempty"` // "safest", "random", "none"
+	ClickDelayMs  int32                  `protobuf:"varint,3,opt,name=click_delay_ms,json=clickDelayMs,proto3" json:"click_delay_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddBotMessage) Reset() {
+	*x = AddBotMessage{}
+	mi := &file_messages_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddBotMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddBotMessage) ProtoMessage() {}
+
+func (x *AddBotMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddBotMessage.ProtoReflect.Descriptor instead.
+func (*AddBotMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AddBotMessage) GetPerfect() bool {
+	if x != nil {
+		return x.Perfect
+	}
+	return false
+}
+
+func (x *AddBotMessage) GetGuessPolicy() string {
+	if x != nil {
+		return x.GuessPolicy
+	}
+	return ""
+}
+
+func (x *AddBotMessage) GetClickDelayMs() int32 {
+	if x != nil {
+		return x.ClickDelayMs
+	}
+	return 0
+}
+
+// Удаление бота (только создатель комнаты)
+type RemoveBotMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlayerId      string                 `protobuf:"bytes,1,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveBotMessage) Reset() {
+	*x = RemoveBotMessage{}
+	mi := &file_messages_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveBotMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveBotMessage) ProtoMessage() {}
+
+func (x *RemoveBotMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveBotMessage.ProtoReflect.Descriptor instead.
+func (*RemoveBotMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RemoveBotMessage) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+// Выбор команды в режиме versus
+type SetTeamMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Team          int32                  `protobuf:"varint,1,opt,name=team,proto3" json:"team,omitempty"` // 1 или 2, 0 - смотреть матч
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetTeamMessage) Reset() {
+	*x = SetTeamMessage{}
+	mi := &file_messages_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetTeamMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetTeamMessage) ProtoMessage() {}
+
+func (x *SetTeamMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetTeamMessage.ProtoReflect.Descriptor instead.
+func (*SetTeamMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SetTeamMessage) GetTeam() int32 {
+	if x != nil {
+		return x.Team
+	}
+	return 0
 }
 
 // Обновление клеток
@@ -1409,13 +1907,16 @@ type CellUpdateMessage struct {
 	LoserPlayerId string                 `protobuf:"bytes,5,opt,name=loser_player_id,json=loserPlayerId,proto3" json:"loser_player_id,omitempty"`
 	LoserNickname string                 `protobuf:"bytes,6,opt,name=loser_nickname,json=loserNickname,proto3" json:"loser_nickname,omitempty"`
 	Updates       []*CellUpdate          `protobuf:"bytes,7,rep,name=updates,proto3" json:"updates,omitempty"`
+	Team          int32                  `protobuf:"varint,8,opt,name=team,proto3" json:"team,omitempty"`                                  // Команда, чье это поле (режим versus)
+	LivesLeft     int32                  `protobuf:"varint,9,opt,name=lives_left,json=livesLeft,proto3" json:"lives_left,omitempty"`       // Осталось общих жизней (режим жизней)
+	PlayerLives   []*PlayerLives         `protobuf:"bytes,10,rep,name=player_lives,json=playerLives,proto3" json:"player_lives,omitempty"` // Потерянные жизни по игрокам (режим жизней)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CellUpdateMessage) Reset() {
 	*x = CellUpdateMessage{}
-	mi := &file_messages_proto_msgTypes[18]
+	mi := &file_messages_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1427,7 +1928,7 @@ func (x *CellUpdateMessage) String() string {
 func (*CellUpdateMessage) ProtoMessage() {}
 
 func (x *CellUpdateMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[18]
+	mi := &file_messages_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1440,7 +1941,7 @@ func (x *CellUpdateMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CellUpdateMessage.ProtoReflect.Descriptor instead.
 func (*CellUpdateMessage) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{18}
+	return file_messages_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *CellUpdateMessage) GetGameOver() bool {
@@ -1492,18 +1993,40 @@ func (x *CellUpdateMessage) GetUpdates() []*CellUpdate {
 	return nil
 }
 
+func (x *CellUpdateMessage) GetTeam() int32 {
+	if x != nil {
+		return x.Team
+	}
+	return 0
+}
+
+func (x *CellUpdateMessage) GetLivesLeft() int32 {
+	if x != nil {
+		return x.LivesLeft
+	}
+	return 0
+}
+
+func (x *CellUpdateMessage) GetPlayerLives() []*PlayerLives {
+	if x != nil {
+		return x.PlayerLives
+	}
+	return nil
+}
+
 type CellUpdate struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Row           int32                  `protobuf:"varint,1,opt,name=row,proto3" json:"row,omitempty"`
 	Col           int32                  `protobuf:"varint,2,opt,name=col,proto3" json:"col,omitempty"`
 	Type          CellType               `protobuf:"varint,3,opt,name=type,proto3,enum=messages.CellType" json:"type,omitempty"`
+	Probability   float32                `protobuf:"fixed32,4,opt,name=probability,proto3" json:"probability,omitempty"` // Вероятность мины для закрытой клетки с подсказкой (режим training)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CellUpdate) Reset() {
 	*x = CellUpdate{}
-	mi := &file_messages_proto_msgTypes[19]
+	mi := &file_messages_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1515,7 +2038,7 @@ func (x *CellUpdate) String() string {
 func (*CellUpdate) ProtoMessage() {}
 
 func (x *CellUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_messages_proto_msgTypes[19]
+	mi := &file_messages_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1528,7 +2051,7 @@ func (x *CellUpdate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CellUpdate.ProtoReflect.Descriptor instead.
 func (*CellUpdate) Descriptor() ([]byte, []int) {
-	return file_messages_proto_rawDescGZIP(), []int{19}
+	return file_messages_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *CellUpdate) GetRow() int32 {
@@ -1552,103 +2075,1074 @@ func (x *CellUpdate) GetType() CellType {
 	return CellType_CELL_TYPE_NEIGHBOR_0
 }
 
-var File_messages_proto protoreflect.FileDescriptor
+func (x *CellUpdate) GetProbability() float32 {
+	if x != nil {
+		return x.Probability
+	}
+	return 0
+}
 
-const file_messages_proto_rawDesc = "" +
-	"\n" +
-	"\x0emessages.proto\x12\bmessages\"\x8d\x03\n" +
-	"\x10WebSocketMessage\x12;\n" +
-	"\n" +
-	"game_state\x18\x01 \x01(\v2\x1a.messages.GameStateMessageH\x00R\tgameState\x12+\n" +
-	"\x04chat\x18\x02 \x01(\v2\x15.messages.ChatMessageH\x00R\x04chat\x121\n" +
-	"\x06cursor\x18\x03 \x01(\v2\x17.messages.CursorMessageH\x00R\x06cursor\x124\n" +
-	"\aplayers\x18\x04 \x01(\v2\x18.messages.PlayersMessageH\x00R\aplayers\x12+\n" +
-	"\x04pong\x18\x05 \x01(\v2\x15.messages.PongMessageH\x00R\x04pong\x12.\n" +
-	"\x05error\x18\x06 \x01(\v2\x16.messages.ErrorMessageH\x00R\x05error\x12>\n" +
-	"\vcell_update\x18\a \x01(\v2\x1b.messages.CellUpdateMessageH\x00R\n" +
-	"cellUpdateB\t\n" +
-	"\amessage\"\xe6\x02\n" +
-	"\rClientMessage\x12\x1c\n" +
-	"\bnickname\x18\x01 \x01(\tH\x00R\bnickname\x121\n" +
-	"\x06cursor\x18\x02 \x01(\v2\x17.messages.CursorMessageH\x00R\x06cursor\x12;\n" +
-	"\n" +
-	"cell_click\x18\x03 \x01(\v2\x1a.messages.CellClickMessageH\x00R\tcellClick\x12+\n" +
-	"\x04hint\x18\x04 \x01(\v2\x15.messages.HintMessageH\x00R\x04hint\x125\n" +
-	"\bnew_game\x18\x05 \x01(\v2\x18.messages.NewGameMessageH\x00R\anewGame\x12+\n" +
-	"\x04chat\x18\x06 \x01(\v2\x15.messages.ChatMessageH\x00R\x04chat\x12+\n" +
-	"\x04ping\x18\a \x01(\v2\x15.messages.PingMessageH\x00R\x04pingB\t\n" +
-	"\amessage\"\xb3\x03\n" +
-	"\x10GameStateMessage\x12%\n" +
-	"\x05board\x18\x01 \x01(\v2\x0f.messages.BoardR\x05board\x12\x12\n" +
-	"\x04rows\x18\x02 \x01(\x05R\x04rows\x12\x12\n" +
-	"\x04cols\x18\x03 \x01(\x05R\x04cols\x12\x14\n" +
-	"\x05mines\x18\x04 \x01(\x05R\x05mines\x12\x12\n" +
-	"\x04seed\x18\r \x01(\tR\x04seed\x12\x1b\n" +
-	"\tgame_over\x18\x05 \x01(\bR\bgameOver\x12\x19\n" +
-	"\bgame_won\x18\x06 \x01(\bR\agameWon\x12\x1a\n" +
-	"\brevealed\x18\a \x01(\x05R\brevealed\x12\x1d\n" +
-	"\n" +
-	"hints_used\x18\b \x01(\x05R\thintsUsed\x121\n" +
-	"\n" +
-	"safe_cells\x18\t \x03(\v2\x12.messages.SafeCellR\tsafeCells\x121\n" +
-	"\n" +
-	"cell_hints\x18\n" +
-	" \x03(\v2\x12.messages.CellHintR\tcellHints\x12&\n" +
-	"\x0floser_player_id\x18\v \x01(\tR\rloserPlayerId\x12%\n" +
-	"\x0eloser_nickname\x18\f \x01(\tR\rloserNickname\"*\n" +
-	"\x05Board\x12!\n" +
-	"\x04rows\x18\x01 \x03(\v2\r.messages.RowR\x04rows\"+\n" +
-	"\x03Row\x12$\n" +
-	"\x05cells\x18\x01 \x03(\v2\x0e.messages.CellR\x05cells\"\xa5\x01\n" +
-	"\x04Cell\x12\x17\n" +
-	"\ais_mine\x18\x01 \x01(\bR\x06isMine\x12\x1f\n" +
-	"\vis_revealed\x18\x02 \x01(\bR\n" +
-	"isRevealed\x12\x1d\n" +
-	"\n" +
-	"is_flagged\x18\x03 \x01(\bR\tisFlagged\x12%\n" +
-	"\x0eneighbor_mines\x18\x04 \x01(\x05R\rneighborMines\x12\x1d\n" +
-	"\n" +
-	"flag_color\x18\x05 \x01(\tR\tflagColor\".\n" +
-	"\bSafeCell\x12\x10\n" +
-	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
-	"\x03col\x18\x02 \x01(\x05R\x03col\"B\n" +
-	"\bCellHint\x12\x10\n" +
-	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
-	"\x03col\x18\x02 \x01(\x05R\x03col\x12\x12\n" +
-	"\x04type\x18\x03 \x01(\tR\x04type\"\xc9\x01\n" +
-	"\vChatMessage\x12\x1b\n" +
-	"\tplayer_id\x18\x01 \x01(\tR\bplayerId\x12\x1a\n" +
-	"\bnickname\x18\x02 \x01(\tR\bnickname\x12\x14\n" +
-	"\x05color\x18\x03 \x01(\tR\x05color\x12\x12\n" +
-	"\x04text\x18\x04 \x01(\tR\x04text\x12\x1b\n" +
-	"\tis_system\x18\x05 \x01(\bR\bisSystem\x12\x16\n" +
-	"\x06action\x18\x06 \x01(\tR\x06action\x12\x10\n" +
-	"\x03row\x18\a \x01(\x05R\x03row\x12\x10\n" +
-	"\x03col\x18\b \x01(\x05R\x03col\"z\n" +
-	"\rCursorMessage\x12\x1b\n" +
-	"\tplayer_id\x18\x01 \x01(\tR\bplayerId\x12\x1a\n" +
-	"\bnickname\x18\x02 \x01(\tR\bnickname\x12\x14\n" +
-	"\x05color\x18\x03 \x01(\tR\x05color\x12\f\n" +
-	"\x01x\x18\x04 \x01(\x01R\x01x\x12\f\n" +
-	"\x01y\x18\x05 \x01(\x01R\x01y\"<\n" +
-	"\x0ePlayersMessage\x12*\n" +
-	"\aplayers\x18\x01 \x03(\v2\x10.messages.PlayerR\aplayers\"J\n" +
-	"\x06Player\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
-	"\bnickname\x18\x02 \x01(\tR\bnickname\x12\x14\n" +
-	"\x05color\x18\x03 \x01(\tR\x05color\"$\n" +
-	"\fErrorMessage\x12\x14\n" +
-	"\x05error\x18\x01 \x01(\tR\x05error\"\r\n" +
-	"\vPongMessage\"\r\n" +
-	"\vPingMessage\"J\n" +
-	"\x10CellClickMessage\x12\x10\n" +
-	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
-	"\x03col\x18\x02 \x01(\x05R\x03col\x12\x12\n" +
-	"\x04flag\x18\x03 \x01(\bR\x04flag\"1\n" +
-	"\vHintMessage\x12\x10\n" +
-	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
+// Объяснение подсказки (только запросившему игроку)
+type HintExplanationMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Row           int32                  `protobuf:"varint,1,opt,name=row,proto3" json:"row,omitempty"`
+	Col           int32                  `protobuf:"varint,2,opt,name=col,proto3" json:"col,omitempty"`
+	IsMine        bool                   `protobuf:"varint,3,opt,name=is_mine,json=isMine,proto3" json:"is_mine,omitempty"`
+	Pattern       string                 `protobuf:"bytes,4,opt,name=pattern,proto3" json:"pattern,omitempty"` // "BASIC", "SUBTRACTION", "1-2-1", "1-2-2-1", "COMPLEX", "MINE_COUNT", "GUESS"
+	Labels        []*ExplanationCell     `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty"`   // Открытые клетки, из чисел которых следует вывод
+	Cells         []*ExplanationCell     `protobuf:"bytes,6,rep,name=cells,proto3" json:"cells,omitempty"`     // Закрытые клетки вокруг этих чисел
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HintExplanationMessage) Reset() {
+	*x = HintExplanationMessage{}
+	mi := &file_messages_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HintExplanationMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HintExplanationMessage) ProtoMessage() {}
+
+func (x *HintExplanationMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HintExplanationMessage.ProtoReflect.Descriptor instead.
+func (*HintExplanationMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *HintExplanationMessage) GetRow() int32 {
+	if x != nil {
+		return x.Row
+	}
+	return 0
+}
+
+func (x *HintExplanationMessage) GetCol() int32 {
+	if x != nil {
+		return x.Col
+	}
+	return 0
+}
+
+func (x *HintExplanationMessage) GetIsMine() bool {
+	if x != nil {
+		return x.IsMine
+	}
+	return false
+}
+
+func (x *HintExplanationMessage) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *HintExplanationMessage) GetLabels() []*ExplanationCell {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *HintExplanationMessage) GetCells() []*ExplanationCell {
+	if x != nil {
+		return x.Cells
+	}
+	return nil
+}
+
+type ExplanationCell struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Row           int32                  `protobuf:"varint,1,opt,name=row,proto3" json:"row,omitempty"`
+	Col           int32                  `protobuf:"varint,2,opt,name=col,proto3" json:"col,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplanationCell) Reset() {
+	*x = ExplanationCell{}
+	mi := &file_messages_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplanationCell) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplanationCell) ProtoMessage() {}
+
+func (x *ExplanationCell) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplanationCell.ProtoReflect.Descriptor instead.
+func (*ExplanationCell) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ExplanationCell) GetRow() int32 {
+	if x != nil {
+		return x.Row
+	}
+	return 0
+}
+
+func (x *ExplanationCell) GetCol() int32 {
+	if x != nil {
+		return x.Col
+	}
+	return 0
+}
+
+// Таблица гонки (режим race): у каждого игрока свое поле с одним seed
+type RaceStandingsMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`                              // "countdown", "running", "finished"
+	StartsInMs    int64                  `protobuf:"varint,2,opt,name=starts_in_ms,json=startsInMs,proto3" json:"starts_in_ms,omitempty"` // Сколько осталось до старта (countdown)
+	ElapsedMs     int64                  `protobuf:"varint,3,opt,name=elapsed_ms,json=elapsedMs,proto3" json:"elapsed_ms,omitempty"`      // Сколько прошло с начала гонки
+	WinnerId      string                 `protobuf:"bytes,4,opt,name=winner_id,json=winnerId,proto3" json:"winner_id,omitempty"`
+	Racers        []*RaceProgress        `protobuf:"bytes,5,rep,name=racers,proto3" json:"racers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RaceStandingsMessage) Reset() {
+	*x = RaceStandingsMessage{}
+	mi := &file_messages_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaceStandingsMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaceStandingsMessage) ProtoMessage() {}
+
+func (x *RaceStandingsMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaceStandingsMessage.ProtoReflect.Descriptor instead.
+func (*RaceStandingsMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *RaceStandingsMessage) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *RaceStandingsMessage) GetStartsInMs() int64 {
+	if x != nil {
+		return x.StartsInMs
+	}
+	return 0
+}
+
+func (x *RaceStandingsMessage) GetElapsedMs() int64 {
+	if x != nil {
+		return x.ElapsedMs
+	}
+	return 0
+}
+
+func (x *RaceStandingsMessage) GetWinnerId() string {
+	if x != nil {
+		return x.WinnerId
+	}
+	return ""
+}
+
+func (x *RaceStandingsMessage) GetRacers() []*RaceProgress {
+	if x != nil {
+		return x.Racers
+	}
+	return nil
+}
+
+type RaceProgress struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PlayerId        string                 `protobuf:"bytes,1,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	Nickname        string                 `protobuf:"bytes,2,opt,name=nickname,proto3" json:"nickname,omitempty"`
+	Color           string                 `protobuf:"bytes,3,opt,name=color,proto3" json:"color,omitempty"`
+	Revealed        int32                  `protobuf:"varint,4,opt,name=revealed,proto3" json:"revealed,omitempty"`
+	TotalSafe       int32                  `protobuf:"varint,5,opt,name=total_safe,json=totalSafe,proto3" json:"total_safe,omitempty"`
+	RevealedPercent float32                `protobuf:"fixed32,6,opt,name=revealed_percent,json=revealedPercent,proto3" json:"revealed_percent,omitempty"`
+	Flags           int32                  `protobuf:"varint,7,opt,name=flags,proto3" json:"flags,omitempty"`
+	Deaths          int32                  `protobuf:"varint,8,opt,name=deaths,proto3" json:"deaths,omitempty"`
+	Finished        bool                   `protobuf:"varint,9,opt,name=finished,proto3" json:"finished,omitempty"`
+	Place           int32                  `protobuf:"varint,10,opt,name=place,proto3" json:"place,omitempty"`                              // Место после окончания гонки (0 - гонка идет)
+	FinishTime      float64                `protobuf:"fixed64,11,opt,name=finish_time,json=finishTime,proto3" json:"finish_time,omitempty"` // Время прохождения в секундах
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RaceProgress) Reset() {
+	*x = RaceProgress{}
+	mi := &file_messages_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaceProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaceProgress) ProtoMessage() {}
+
+func (x *RaceProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaceProgress.ProtoReflect.Descriptor instead.
+func (*RaceProgress) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *RaceProgress) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+func (x *RaceProgress) GetNickname() string {
+	if x != nil {
+		return x.Nickname
+	}
+	return ""
+}
+
+func (x *RaceProgress) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *RaceProgress) GetRevealed() int32 {
+	if x != nil {
+		return x.Revealed
+	}
+	return 0
+}
+
+func (x *RaceProgress) GetTotalSafe() int32 {
+	if x != nil {
+		return x.TotalSafe
+	}
+	return 0
+}
+
+func (x *RaceProgress) GetRevealedPercent() float32 {
+	if x != nil {
+		return x.RevealedPercent
+	}
+	return 0
+}
+
+func (x *RaceProgress) GetFlags() int32 {
+	if x != nil {
+		return x.Flags
+	}
+	return 0
+}
+
+func (x *RaceProgress) GetDeaths() int32 {
+	if x != nil {
+		return x.Deaths
+	}
+	return 0
+}
+
+func (x *RaceProgress) GetFinished() bool {
+	if x != nil {
+		return x.Finished
+	}
+	return false
+}
+
+func (x *RaceProgress) GetPlace() int32 {
+	if x != nil {
+		return x.Place
+	}
+	return 0
+}
+
+func (x *RaceProgress) GetFinishTime() float64 {
+	if x != nil {
+		return x.FinishTime
+	}
+	return 0
+}
+
+// Счет матча команд (режим versus): у каждой команды свое поле с одним seed
+type VersusStatusMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WinnerTeam    int32                  `protobuf:"varint,1,opt,name=winner_team,json=winnerTeam,proto3" json:"winner_team,omitempty"` // 0 - матч идет
+	Teams         []*TeamProgress        `protobuf:"bytes,2,rep,name=teams,proto3" json:"teams,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VersusStatusMessage) Reset() {
+	*x = VersusStatusMessage{}
+	mi := &file_messages_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VersusStatusMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersusStatusMessage) ProtoMessage() {}
+
+func (x *VersusStatusMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersusStatusMessage.ProtoReflect.Descriptor instead.
+func (*VersusStatusMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *VersusStatusMessage) GetWinnerTeam() int32 {
+	if x != nil {
+		return x.WinnerTeam
+	}
+	return 0
+}
+
+func (x *VersusStatusMessage) GetTeams() []*TeamProgress {
+	if x != nil {
+		return x.Teams
+	}
+	return nil
+}
+
+type TeamProgress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Team          int32                  `protobuf:"varint,1,opt,name=team,proto3" json:"team,omitempty"`
+	Revealed      int32                  `protobuf:"varint,2,opt,name=revealed,proto3" json:"revealed,omitempty"`
+	TotalSafe     int32                  `protobuf:"varint,3,opt,name=total_safe,json=totalSafe,proto3" json:"total_safe,omitempty"`
+	Deaths        int32                  `protobuf:"varint,4,opt,name=deaths,proto3" json:"deaths,omitempty"` // Подрывы: после подрыва поле команды начинается заново
+	Cleared       bool                   `protobuf:"varint,5,opt,name=cleared,proto3" json:"cleared,omitempty"`
+	ClearTime     float64                `protobuf:"fixed64,6,opt,name=clear_time,json=clearTime,proto3" json:"clear_time,omitempty"` // Время прохождения в секундах
+	Players       int32                  `protobuf:"varint,7,opt,name=players,proto3" json:"players,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TeamProgress) Reset() {
+	*x = TeamProgress{}
+	mi := &file_messages_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TeamProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TeamProgress) ProtoMessage() {}
+
+func (x *TeamProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TeamProgress.ProtoReflect.Descriptor instead.
+func (*TeamProgress) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *TeamProgress) GetTeam() int32 {
+	if x != nil {
+		return x.Team
+	}
+	return 0
+}
+
+func (x *TeamProgress) GetRevealed() int32 {
+	if x != nil {
+		return x.Revealed
+	}
+	return 0
+}
+
+func (x *TeamProgress) GetTotalSafe() int32 {
+	if x != nil {
+		return x.TotalSafe
+	}
+	return 0
+}
+
+func (x *TeamProgress) GetDeaths() int32 {
+	if x != nil {
+		return x.Deaths
+	}
+	return 0
+}
+
+func (x *TeamProgress) GetCleared() bool {
+	if x != nil {
+		return x.Cleared
+	}
+	return false
+}
+
+func (x *TeamProgress) GetClearTime() float64 {
+	if x != nil {
+		return x.ClearTime
+	}
+	return 0
+}
+
+func (x *TeamProgress) GetPlayers() int32 {
+	if x != nil {
+		return x.Players
+	}
+	return 0
+}
+
+// Часы сервера: время идущей игры считает сервер, клиент только отображает его
+type TimeSyncMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServerTimeMs  int64                  `protobuf:"varint,1,opt,name=server_time_ms,json=serverTimeMs,proto3" json:"server_time_ms,omitempty"` // Время сервера (Unix, мс) в момент отправки
+	ElapsedMs     int64                  `protobuf:"varint,2,opt,name=elapsed_ms,json=elapsedMs,proto3" json:"elapsed_ms,omitempty"`            // Сколько прошло с начала игры
+	TimeLeftMs    int64                  `protobuf:"varint,3,opt,name=time_left_ms,json=timeLeftMs,proto3" json:"time_left_ms,omitempty"`       // Сколько осталось (игра на время), 0 - без ограничения
+	ClockMode     string                 `protobuf:"bytes,4,opt,name=clock_mode,json=clockMode,proto3" json:"clock_mode,omitempty"`             // "" - без ограничения, "deadline" - открыть поле до конца времени, "score" - открыть как можно больше
+	TimeLimit     int32                  `protobuf:"varint,5,opt,name=time_limit,json=timeLimit,proto3" json:"time_limit,omitempty"`            // Ограничение времени в секундах
+	Running       bool                   `protobuf:"varint,6,opt,name=running,proto3" json:"running,omitempty"`                                 // false - часы остановлены, игра закончилась
+	Score         int32                  `protobuf:"varint,7,opt,name=score,proto3" json:"score,omitempty"`                                     // Счет в режиме score: открытые клетки
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeSyncMessage) Reset() {
+	*x = TimeSyncMessage{}
+	mi := &file_messages_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeSyncMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeSyncMessage) ProtoMessage() {}
+
+func (x *TimeSyncMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeSyncMessage.ProtoReflect.Descriptor instead.
+func (*TimeSyncMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *TimeSyncMessage) GetServerTimeMs() int64 {
+	if x != nil {
+		return x.ServerTimeMs
+	}
+	return 0
+}
+
+func (x *TimeSyncMessage) GetElapsedMs() int64 {
+	if x != nil {
+		return x.ElapsedMs
+	}
+	return 0
+}
+
+func (x *TimeSyncMessage) GetTimeLeftMs() int64 {
+	if x != nil {
+		return x.TimeLeftMs
+	}
+	return 0
+}
+
+func (x *TimeSyncMessage) GetClockMode() string {
+	if x != nil {
+		return x.ClockMode
+	}
+	return ""
+}
+
+func (x *TimeSyncMessage) GetTimeLimit() int32 {
+	if x != nil {
+		return x.TimeLimit
+	}
+	return 0
+}
+
+func (x *TimeSyncMessage) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *TimeSyncMessage) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+// Очередь ходов и счет пошагового режима (turns)
+type TurnStatusMessage struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	CurrentPlayerId string                 `protobuf:"bytes,1,opt,name=current_player_id,json=currentPlayerId,proto3" json:"current_player_id,omitempty"` // Чей сейчас ход
+	Turn            int32                  `protobuf:"varint,2,opt,name=turn,proto3" json:"turn,omitempty"`                                               // Номер хода
+	TimeLeftMs      int64                  `protobuf:"varint,3,opt,name=time_left_ms,json=timeLeftMs,proto3" json:"time_left_ms,omitempty"`               // Сколько осталось на ход, 0 - без ограничения
+	TurnTimeout     int32                  `protobuf:"varint,4,opt,name=turn_timeout,json=turnTimeout,proto3" json:"turn_timeout,omitempty"`              // Время на ход в секундах, 0 - без ограничения
+	Finished        bool                   `protobuf:"varint,5,opt,name=finished,proto3" json:"finished,omitempty"`                                       // Поле открыто, счет окончательный
+	Scores          []*PlayerScore         `protobuf:"bytes,6,rep,name=scores,proto3" json:"scores,omitempty"`                                            // В порядке очереди ходов
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TurnStatusMessage) Reset() {
+	*x = TurnStatusMessage{}
+	mi := &file_messages_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TurnStatusMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TurnStatusMessage) ProtoMessage() {}
+
+func (x *TurnStatusMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TurnStatusMessage.ProtoReflect.Descriptor instead.
+func (*TurnStatusMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *TurnStatusMessage) GetCurrentPlayerId() string {
+	if x != nil {
+		return x.CurrentPlayerId
+	}
+	return ""
+}
+
+func (x *TurnStatusMessage) GetTurn() int32 {
+	if x != nil {
+		return x.Turn
+	}
+	return 0
+}
+
+func (x *TurnStatusMessage) GetTimeLeftMs() int64 {
+	if x != nil {
+		return x.TimeLeftMs
+	}
+	return 0
+}
+
+func (x *TurnStatusMessage) GetTurnTimeout() int32 {
+	if x != nil {
+		return x.TurnTimeout
+	}
+	return 0
+}
+
+func (x *TurnStatusMessage) GetFinished() bool {
+	if x != nil {
+		return x.Finished
+	}
+	return false
+}
+
+func (x *TurnStatusMessage) GetScores() []*PlayerScore {
+	if x != nil {
+		return x.Scores
+	}
+	return nil
+}
+
+type PlayerScore struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlayerId      string                 `protobuf:"bytes,1,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	Nickname      string                 `protobuf:"bytes,2,opt,name=nickname,proto3" json:"nickname,omitempty"`
+	Color         string                 `protobuf:"bytes,3,opt,name=color,proto3" json:"color,omitempty"`
+	Score         int32                  `protobuf:"varint,4,opt,name=score,proto3" json:"score,omitempty"`
+	Place         int32                  `protobuf:"varint,5,opt,name=place,proto3" json:"place,omitempty"` // Место после окончания игры (0 - игра идет)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlayerScore) Reset() {
+	*x = PlayerScore{}
+	mi := &file_messages_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayerScore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayerScore) ProtoMessage() {}
+
+func (x *PlayerScore) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayerScore.ProtoReflect.Descriptor instead.
+func (*PlayerScore) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *PlayerScore) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+func (x *PlayerScore) GetNickname() string {
+	if x != nil {
+		return x.Nickname
+	}
+	return ""
+}
+
+func (x *PlayerScore) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *PlayerScore) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *PlayerScore) GetPlace() int32 {
+	if x != nil {
+		return x.Place
+	}
+	return 0
+}
+
+// Сессия игрока: отправляется при подключении
+// Переподключение с подпротоколом "session, <token>" и ?lastSeq=<seq> в течение grace-периода возвращает тот же player_id
+// и повторяет сообщения с номером больше lastSeq
+// Пользователь возобновляет сессию только с действующим access токеном того же входа
+type SessionMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	PlayerId      string                 `protobuf:"bytes,2,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	Resumed       bool                   `protobuf:"varint,3,opt,name=resumed,proto3" json:"resumed,omitempty"` // Сессия возобновлена, а не создана заново
+	Seq           uint64                 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`         // Номер последнего сообщения, отправленного до этого
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionMessage) Reset() {
+	*x = SessionMessage{}
+	mi := &file_messages_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionMessage) ProtoMessage() {}
+
+func (x *SessionMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionMessage.ProtoReflect.Descriptor instead.
+func (*SessionMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *SessionMessage) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *SessionMessage) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+func (x *SessionMessage) GetResumed() bool {
+	if x != nil {
+		return x.Resumed
+	}
+	return false
+}
+
+func (x *SessionMessage) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+// Подтверждение получения сообщений с номером до seq включительно
+// version - последняя версия поля, примененная без пропусков
+// resync - клиент обнаружил пропуск версий и просит все изменения после version (или полный снимок)
+type AckMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Version       uint64                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Resync        bool                   `protobuf:"varint,3,opt,name=resync,proto3" json:"resync,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckMessage) Reset() {
+	*x = AckMessage{}
+	mi := &file_messages_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckMessage) ProtoMessage() {}
+
+func (x *AckMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckMessage.ProtoReflect.Descriptor instead.
+func (*AckMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *AckMessage) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *AckMessage) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *AckMessage) GetResync() bool {
+	if x != nil {
+		return x.Resync
+	}
+	return false
+}
+
+// Авторизация первым сообщением (при подключении с ?auth=message)
+// token - JWT, выданный при входе; без токена игрок подключается гостем
+type AuthMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthMessage) Reset() {
+	*x = AuthMessage{}
+	mi := &file_messages_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthMessage) ProtoMessage() {}
+
+func (x *AuthMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messages_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthMessage.ProtoReflect.Descriptor instead.
+func (*AuthMessage) Descriptor() ([]byte, []int) {
+	return file_messages_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *AuthMessage) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+var File_messages_proto protoreflect.FileDescriptor
+
+const file_messages_proto_rawDesc = "" +
+	"\n" +
+	"\x0emessages.proto\x12\bmessages\"\xc7\x06\n" +
+	"\x10WebSocketMessage\x12;\n" +
+	"\n" +
+	"game_state\x18\x01 \x01(\v2\x1a.messages.GameStateMessageH\x00R\tgameState\x12+\n" +
+	"\x04chat\x18\x02 \x01(\v2\x15.messages.ChatMessageH\x00R\x04chat\x121\n" +
+	"\x06cursor\x18\x03 \x01(\v2\x17.messages.CursorMessageH\x00R\x06cursor\x124\n" +
+	"\aplayers\x18\x04 \x01(\v2\x18.messages.PlayersMessageH\x00R\aplayers\x12+\n" +
+	"\x04pong\x18\x05 \x01(\v2\x15.messages.PongMessageH\x00R\x04pong\x12.\n" +
+	"\x05error\x18\x06 \x01(\v2\x16.messages.ErrorMessageH\x00R\x05error\x12>\n" +
+	"\vcell_update\x18\a \x01(\v2\x1b.messages.CellUpdateMessageH\x00R\n" +
+	"cellUpdate\x12M\n" +
+	"\x10hint_explanation\x18\b \x01(\v2 .messages.HintExplanationMessageH\x00R\x0fhintExplanation\x12G\n" +
+	"\x0erace_standings\x18\t \x01(\v2\x1e.messages.RaceStandingsMessageH\x00R\rraceStandings\x12D\n" +
+	"\rversus_status\x18\n" +
+	" \x01(\v2\x1d.messages.VersusStatusMessageH\x00R\fversusStatus\x128\n" +
+	"\ttime_sync\x18\v \x01(\v2\x19.messages.TimeSyncMessageH\x00R\btimeSync\x12>\n" +
+	"\vturn_status\x18\f \x01(\v2\x1b.messages.TurnStatusMessageH\x00R\n" +
+	"turnStatus\x124\n" +
+	"\asession\x18\r \x01(\v2\x18.messages.SessionMessageH\x00R\asession\x12\x10\n" +
+	"\x03seq\x18\x0e \x01(\x04R\x03seq\x12\x18\n" +
+	"\aversion\x18\x0f \x01(\x04R\aversionB\t\n" +
+	"\amessage\"\xe5\x04\n" +
+	"\rClientMessage\x12\x1c\n" +
+	"\bnickname\x18\x01 \x01(\tH\x00R\bnickname\x121\n" +
+	"\x06cursor\x18\x02 \x01(\v2\x17.messages.CursorMessageH\x00R\x06cursor\x12;\n" +
+	"\n" +
+	"cell_click\x18\x03 \x01(\v2\x1a.messages.CellClickMessageH\x00R\tcellClick\x12+\n" +
+	"\x04hint\x18\x04 \x01(\v2\x15.messages.HintMessageH\x00R\x04hint\x125\n" +
+	"\bnew_game\x18\x05 \x01(\v2\x18.messages.NewGameMessageH\x00R\anewGame\x12+\n" +
+	"\x04chat\x18\x06 \x01(\v2\x15.messages.ChatMessageH\x00R\x04chat\x12+\n" +
+	"\x04ping\x18\a \x01(\v2\x15.messages.PingMessageH\x00R\x04ping\x122\n" +
+	"\aadd_bot\x18\b \x01(\v2\x17.messages.AddBotMessageH\x00R\x06addBot\x12;\n" +
+	"\n" +
+	"remove_bot\x18\t \x01(\v2\x1a.messages.RemoveBotMessageH\x00R\tremoveBot\x125\n" +
+	"\bset_team\x18\n" +
+	" \x01(\v2\x18.messages.SetTeamMessageH\x00R\asetTeam\x12(\n" +
+	"\x03ack\x18\v \x01(\v2\x14.messages.AckMessageH\x00R\x03ack\x12+\n" +
+	"\x04auth\x18\f \x01(\v2\x15.messages.AuthMessageH\x00R\x04authB\t\n" +
+	"\amessage\"\xe0\x04\n" +
+	"\x10GameStateMessage\x12%\n" +
+	"\x05board\x18\x01 \x01(\v2\x0f.messages.BoardR\x05board\x12\x12\n" +
+	"\x04rows\x18\x02 \x01(\x05R\x04rows\x12\x12\n" +
+	"\x04cols\x18\x03 \x01(\x05R\x04cols\x12\x14\n" +
+	"\x05mines\x18\x04 \x01(\x05R\x05mines\x12\x12\n" +
+	"\x04seed\x18\r \x01(\tR\x04seed\x12\x1b\n" +
+	"\tgame_over\x18\x05 \x01(\bR\bgameOver\x12\x19\n" +
+	"\bgame_won\x18\x06 \x01(\bR\agameWon\x12\x1a\n" +
+	"\brevealed\x18\a \x01(\x05R\brevealed\x12\x1d\n" +
+	"\n" +
+	"hints_used\x18\b \x01(\x05R\thintsUsed\x121\n" +
+	"\n" +
+	"safe_cells\x18\t \x03(\v2\x12.messages.SafeCellR\tsafeCells\x121\n" +
+	"\n" +
+	"cell_hints\x18\n" +
+	" \x03(\v2\x12.messages.CellHintR\tcellHints\x12&\n" +
+	"\x0floser_player_id\x18\v \x01(\tR\rloserPlayerId\x12%\n" +
+	"\x0eloser_nickname\x18\f \x01(\tR\rloserNickname\x12\x12\n" +
+	"\x04team\x18\x0e \x01(\x05R\x04team\x12\x14\n" +
+	"\x05lives\x18\x0f \x01(\x05R\x05lives\x12(\n" +
+	"\x10per_player_lives\x18\x10 \x01(\bR\x0eperPlayerLives\x12\x1d\n" +
+	"\n" +
+	"lives_left\x18\x11 \x01(\x05R\tlivesLeft\x128\n" +
+	"\fplayer_lives\x18\x12 \x03(\v2\x15.messages.PlayerLivesR\vplayerLives\"h\n" +
+	"\vPlayerLives\x12\x1b\n" +
+	"\tplayer_id\x18\x01 \x01(\tR\bplayerId\x12\x1d\n" +
+	"\n" +
+	"lives_lost\x18\x02 \x01(\x05R\tlivesLost\x12\x1d\n" +
+	"\n" +
+	"lives_left\x18\x03 \x01(\x05R\tlivesLeft\"*\n" +
+	"\x05Board\x12!\n" +
+	"\x04rows\x18\x01 \x03(\v2\r.messages.RowR\x04rows\"+\n" +
+	"\x03Row\x12$\n" +
+	"\x05cells\x18\x01 \x03(\v2\x0e.messages.CellR\x05cells\"\xc6\x01\n" +
+	"\x04Cell\x12\x17\n" +
+	"\ais_mine\x18\x01 \x01(\bR\x06isMine\x12\x1f\n" +
+	"\vis_revealed\x18\x02 \x01(\bR\n" +
+	"isRevealed\x12\x1d\n" +
+	"\n" +
+	"is_flagged\x18\x03 \x01(\bR\tisFlagged\x12%\n" +
+	"\x0eneighbor_mines\x18\x04 \x01(\x05R\rneighborMines\x12\x1d\n" +
+	"\n" +
+	"flag_color\x18\x05 \x01(\tR\tflagColor\x12\x1f\n" +
+	"\vis_exploded\x18\x06 \x01(\bR\n" +
+	"isExploded\".\n" +
+	"\bSafeCell\x12\x10\n" +
+	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
+	"\x03col\x18\x02 \x01(\x05R\x03col\"d\n" +
+	"\bCellHint\x12\x10\n" +
+	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
+	"\x03col\x18\x02 \x01(\x05R\x03col\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12 \n" +
+	"\vprobability\x18\x04 \x01(\x02R\vprobability\"\xe6\x01\n" +
+	"\vChatMessage\x12\x1b\n" +
+	"\tplayer_id\x18\x01 \x01(\tR\bplayerId\x12\x1a\n" +
+	"\bnickname\x18\x02 \x01(\tR\bnickname\x12\x14\n" +
+	"\x05color\x18\x03 \x01(\tR\x05color\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\x12\x1b\n" +
+	"\tis_system\x18\x05 \x01(\bR\bisSystem\x12\x16\n" +
+	"\x06action\x18\x06 \x01(\tR\x06action\x12\x10\n" +
+	"\x03row\x18\a \x01(\x05R\x03row\x12\x10\n" +
+	"\x03col\x18\b \x01(\x05R\x03col\x12\x1b\n" +
+	"\tteam_only\x18\t \x01(\bR\bteamOnly\"z\n" +
+	"\rCursorMessage\x12\x1b\n" +
+	"\tplayer_id\x18\x01 \x01(\tR\bplayerId\x12\x1a\n" +
+	"\bnickname\x18\x02 \x01(\tR\bnickname\x12\x14\n" +
+	"\x05color\x18\x03 \x01(\tR\x05color\x12\f\n" +
+	"\x01x\x18\x04 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x05 \x01(\x01R\x01y\"n\n" +
+	"\x0ePlayersMessage\x12*\n" +
+	"\aplayers\x18\x01 \x03(\v2\x10.messages.PlayerR\aplayers\x120\n" +
+	"\n" +
+	"spectators\x18\x02 \x03(\v2\x10.messages.PlayerR\n" +
+	"spectators\"u\n" +
+	"\x06Player\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bnickname\x18\x02 \x01(\tR\bnickname\x12\x14\n" +
+	"\x05color\x18\x03 \x01(\tR\x05color\x12\x15\n" +
+	"\x06is_bot\x18\x04 \x01(\bR\x05isBot\x12\x12\n" +
+	"\x04team\x18\x05 \x01(\x05R\x04team\"$\n" +
+	"\fErrorMessage\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\"\r\n" +
+	"\vPongMessage\"\r\n" +
+	"\vPingMessage\"J\n" +
+	"\x10CellClickMessage\x12\x10\n" +
+	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
+	"\x03col\x18\x02 \x01(\x05R\x03col\x12\x12\n" +
+	"\x04flag\x18\x03 \x01(\bR\x04flag\"1\n" +
+	"\vHintMessage\x12\x10\n" +
+	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
 	"\x03col\x18\x02 \x01(\x05R\x03col\"\x10\n" +
-	"\x0eNewGameMessage\"\x85\x02\n" +
+	"\x0eNewGameMessage\"r\n" +
+	"\rAddBotMessage\x12\x18\n" +
+	"\aperfect\x18\x01 \x01(\bR\aperfect\x12!\n" +
+	"\fguess_policy\x18\x02 \x01(\tR\vguessPolicy\x12$\n" +
+	"\x0eclick_delay_ms\x18\x03 \x01(\x05R\fclickDelayMs\"/\n" +
+	"\x10RemoveBotMessage\x12\x1b\n" +
+	"\tplayer_id\x18\x01 \x01(\tR\bplayerId\"$\n" +
+	"\x0eSetTeamMessage\x12\x12\n" +
+	"\x04team\x18\x01 \x01(\x05R\x04team\"\xf2\x02\n" +
 	"\x11CellUpdateMessage\x12\x1b\n" +
 	"\tgame_over\x18\x01 \x01(\bR\bgameOver\x12\x19\n" +
 	"\bgame_won\x18\x02 \x01(\bR\agameWon\x12\x1a\n" +
@@ -1657,12 +3151,103 @@ const file_messages_proto_rawDesc = "" +
 	"hints_used\x18\x04 \x01(\x05R\thintsUsed\x12&\n" +
 	"\x0floser_player_id\x18\x05 \x01(\tR\rloserPlayerId\x12%\n" +
 	"\x0eloser_nickname\x18\x06 \x01(\tR\rloserNickname\x12.\n" +
-	"\aupdates\x18\a \x03(\v2\x14.messages.CellUpdateR\aupdates\"X\n" +
+	"\aupdates\x18\a \x03(\v2\x14.messages.CellUpdateR\aupdates\x12\x12\n" +
+	"\x04team\x18\b \x01(\x05R\x04team\x12\x1d\n" +
+	"\n" +
+	"lives_left\x18\t \x01(\x05R\tlivesLeft\x128\n" +
+	"\fplayer_lives\x18\n" +
+	" \x03(\v2\x15.messages.PlayerLivesR\vplayerLives\"z\n" +
 	"\n" +
 	"CellUpdate\x12\x10\n" +
 	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
 	"\x03col\x18\x02 \x01(\x05R\x03col\x12&\n" +
-	"\x04type\x18\x03 \x01(\x0e2\x12.messages.CellTypeR\x04type*\xe0\x02\n" +
+	"\x04type\x18\x03 \x01(\x0e2\x12.messages.CellTypeR\x04type\x12 \n" +
+	"\vprobability\x18\x04 \x01(\x02R\vprobability\"\xd3\x01\n" +
+	"\x16HintExplanationMessage\x12\x10\n" +
+	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
+	"\x03col\x18\x02 \x01(\x05R\x03col\x12\x17\n" +
+	"\ais_mine\x18\x03 \x01(\bR\x06isMine\x12\x18\n" +
+	"\apattern\x18\x04 \x01(\tR\apattern\x121\n" +
+	"\x06labels\x18\x05 \x03(\v2\x19.messages.ExplanationCellR\x06labels\x12/\n" +
+	"\x05cells\x18\x06 \x03(\v2\x19.messages.ExplanationCellR\x05cells\"5\n" +
+	"\x0fExplanationCell\x12\x10\n" +
+	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
+	"\x03col\x18\x02 \x01(\x05R\x03col\"\xbc\x01\n" +
+	"\x14RaceStandingsMessage\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12 \n" +
+	"\fstarts_in_ms\x18\x02 \x01(\x03R\n" +
+	"startsInMs\x12\x1d\n" +
+	"\n" +
+	"elapsed_ms\x18\x03 \x01(\x03R\telapsedMs\x12\x1b\n" +
+	"\twinner_id\x18\x04 \x01(\tR\bwinnerId\x12.\n" +
+	"\x06racers\x18\x05 \x03(\v2\x16.messages.RaceProgressR\x06racers\"\xc4\x02\n" +
+	"\fRaceProgress\x12\x1b\n" +
+	"\tplayer_id\x18\x01 \x01(\tR\bplayerId\x12\x1a\n" +
+	"\bnickname\x18\x02 \x01(\tR\bnickname\x12\x14\n" +
+	"\x05color\x18\x03 \x01(\tR\x05color\x12\x1a\n" +
+	"\brevealed\x18\x04 \x01(\x05R\brevealed\x12\x1d\n" +
+	"\n" +
+	"total_safe\x18\x05 \x01(\x05R\ttotalSafe\x12)\n" +
+	"\x10revealed_percent\x18\x06 \x01(\x02R\x0frevealedPercent\x12\x14\n" +
+	"\x05flags\x18\a \x01(\x05R\x05flags\x12\x16\n" +
+	"\x06deaths\x18\b \x01(\x05R\x06deaths\x12\x1a\n" +
+	"\bfinished\x18\t \x01(\bR\bfinished\x12\x14\n" +
+	"\x05place\x18\n" +
+	" \x01(\x05R\x05place\x12\x1f\n" +
+	"\vfinish_time\x18\v \x01(\x01R\n" +
+	"finishTime\"d\n" +
+	"\x13VersusStatusMessage\x12\x1f\n" +
+	"\vwinner_team\x18\x01 \x01(\x05R\n" +
+	"winnerTeam\x12,\n" +
+	"\x05teams\x18\x02 \x03(\v2\x16.messages.TeamProgressR\x05teams\"\xc8\x01\n" +
+	"\fTeamProgress\x12\x12\n" +
+	"\x04team\x18\x01 \x01(\x05R\x04team\x12\x1a\n" +
+	"\brevealed\x18\x02 \x01(\x05R\brevealed\x12\x1d\n" +
+	"\n" +
+	"total_safe\x18\x03 \x01(\x05R\ttotalSafe\x12\x16\n" +
+	"\x06deaths\x18\x04 \x01(\x05R\x06deaths\x12\x18\n" +
+	"\acleared\x18\x05 \x01(\bR\acleared\x12\x1d\n" +
+	"\n" +
+	"clear_time\x18\x06 \x01(\x01R\tclearTime\x12\x18\n" +
+	"\aplayers\x18\a \x01(\x05R\aplayers\"\xe6\x01\n" +
+	"\x0fTimeSyncMessage\x12$\n" +
+	"\x0eserver_time_ms\x18\x01 \x01(\x03R\fserverTimeMs\x12\x1d\n" +
+	"\n" +
+	"elapsed_ms\x18\x02 \x01(\x03R\telapsedMs\x12 \n" +
+	"\ftime_left_ms\x18\x03 \x01(\x03R\n" +
+	"timeLeftMs\x12\x1d\n" +
+	"\n" +
+	"clock_mode\x18\x04 \x01(\tR\tclockMode\x12\x1d\n" +
+	"\n" +
+	"time_limit\x18\x05 \x01(\x05R\ttimeLimit\x12\x18\n" +
+	"\arunning\x18\x06 \x01(\bR\arunning\x12\x14\n" +
+	"\x05score\x18\a \x01(\x05R\x05score\"\xe3\x01\n" +
+	"\x11TurnStatusMessage\x12*\n" +
+	"\x11current_player_id\x18\x01 \x01(\tR\x0fcurrentPlayerId\x12\x12\n" +
+	"\x04turn\x18\x02 \x01(\x05R\x04turn\x12 \n" +
+	"\ftime_left_ms\x18\x03 \x01(\x03R\n" +
+	"timeLeftMs\x12!\n" +
+	"\fturn_timeout\x18\x04 \x01(\x05R\vturnTimeout\x12\x1a\n" +
+	"\bfinished\x18\x05 \x01(\bR\bfinished\x12-\n" +
+	"\x06scores\x18\x06 \x03(\v2\x15.messages.PlayerScoreR\x06scores\"\x88\x01\n" +
+	"\vPlayerScore\x12\x1b\n" +
+	"\tplayer_id\x18\x01 \x01(\tR\bplayerId\x12\x1a\n" +
+	"\bnickname\x18\x02 \x01(\tR\bnickname\x12\x14\n" +
+	"\x05color\x18\x03 \x01(\tR\x05color\x12\x14\n" +
+	"\x05score\x18\x04 \x01(\x05R\x05score\x12\x14\n" +
+	"\x05place\x18\x05 \x01(\x05R\x05place\"o\n" +
+	"\x0eSessionMessage\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1b\n" +
+	"\tplayer_id\x18\x02 \x01(\tR\bplayerId\x12\x18\n" +
+	"\aresumed\x18\x03 \x01(\bR\aresumed\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x04R\x03seq\"P\n" +
+	"\n" +
+	"AckMessage\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\x12\x16\n" +
+	"\x06resync\x18\x03 \x01(\bR\x06resync\"#\n" +
+	"\vAuthMessage\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token*\x93\x03\n" +
 	"\bCellType\x12\x18\n" +
 	"\x14CELL_TYPE_NEIGHBOR_0\x10\x00\x12\x18\n" +
 	"\x14CELL_TYPE_NEIGHBOR_1\x10\x01\x12\x18\n" +
@@ -1677,7 +3262,9 @@ const file_messages_proto_rawDesc = "" +
 	"\x0eCELL_TYPE_SAFE\x10\n" +
 	"\x12\x15\n" +
 	"\x11CELL_TYPE_UNKNOWN\x10\v\x12\x14\n" +
-	"\x10CELL_TYPE_DANGER\x10\f\x12\x15\n" +
+	"\x10CELL_TYPE_DANGER\x10\f\x12\x19\n" +
+	"\x15CELL_TYPE_PROBABILITY\x10\r\x12\x16\n" +
+	"\x12CELL_TYPE_EXPLODED\x10\x0e\x12\x15\n" +
 	"\x10CELL_TYPE_CLOSED\x10\xff\x01B\x19Z\x17minesweeperonline/protob\x06proto3"
 
 var (
@@ -1693,57 +3280,92 @@ func file_messages_proto_rawDescGZIP() []byte {
 }
 
 var file_messages_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_messages_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_messages_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
 var file_messages_proto_goTypes = []any{
-	(CellType)(0),             // 0: messages.CellType
-	(*WebSocketMessage)(nil),  // 1: messages.WebSocketMessage
-	(*ClientMessage)(nil),     // 2: messages.ClientMessage
-	(*GameStateMessage)(nil),  // 3: messages.GameStateMessage
-	(*Board)(nil),             // 4: messages.Board
-	(*Row)(nil),               // 5: messages.Row
-	(*Cell)(nil),              // 6: messages.Cell
-	(*SafeCell)(nil),          // 7: messages.SafeCell
-	(*CellHint)(nil),          // 8: messages.CellHint
-	(*ChatMessage)(nil),       // 9: messages.ChatMessage
-	(*CursorMessage)(nil),     // 10: messages.CursorMessage
-	(*PlayersMessage)(nil),    // 11: messages.PlayersMessage
-	(*Player)(nil),            // 12: messages.Player
-	(*ErrorMessage)(nil),      // 13: messages.ErrorMessage
-	(*PongMessage)(nil),       // 14: messages.PongMessage
-	(*PingMessage)(nil),       // 15: messages.PingMessage
-	(*CellClickMessage)(nil),  // 16: messages.CellClickMessage
-	(*HintMessage)(nil),       // 17: messages.HintMessage
-	(*NewGameMessage)(nil),    // 18: messages.NewGameMessage
-	(*CellUpdateMessage)(nil), // 19: messages.CellUpdateMessage
-	(*CellUpdate)(nil),        // 20: messages.CellUpdate
+	(CellType)(0),                  // 0: messages.CellType
+	(*WebSocketMessage)(nil),       // 1: messages.WebSocketMessage
+	(*ClientMessage)(nil),          // 2: messages.ClientMessage
+	(*GameStateMessage)(nil),       // 3: messages.GameStateMessage
+	(*PlayerLives)(nil),            // 4: messages.PlayerLives
+	(*Board)(nil),                  // 5: messages.Board
+	(*Row)(nil),                    // 6: messages.Row
+	(*Cell)(nil),                   // 7: messages.Cell
+	(*SafeCell)(nil),               // 8: messages.SafeCell
+	(*CellHint)(nil),               // 9: messages.CellHint
+	(*ChatMessage)(nil),            // 10: messages.ChatMessage
+	(*CursorMessage)(nil),          // 11: messages.CursorMessage
+	(*PlayersMessage)(nil),         // 12: messages.PlayersMessage
+	(*Player)(nil),                 // 13: messages.Player
+	(*ErrorMessage)(nil),           // 14: messages.ErrorMessage
+	(*PongMessage)(nil),            // 15: messages.PongMessage
+	(*PingMessage)(nil),            // 16: messages.PingMessage
+	(*CellClickMessage)(nil),       // 17: messages.CellClickMessage
+	(*HintMessage)(nil),            // 18: messages.HintMessage
+	(*NewGameMessage)(nil),         // 19: messages.NewGameMessage
+	(*AddBotMessage)(nil),          // 20: messages.AddBotMessage
+	(*RemoveBotMessage)(nil),       // 21: messages.RemoveBotMessage
+	(*SetTeamMessage)(nil),         // 22: messages.SetTeamMessage
+	(*CellUpdateMessage)(nil),      // 23: messages.CellUpdateMessage
+	(*CellUpdate)(nil),             // 24: messages.CellUpdate
+	(*HintExplanationMessage)(nil), // 25: messages.HintExplanationMessage
+	(*ExplanationCell)(nil),        // 26: messages.ExplanationCell
+	(*RaceStandingsMessage)(nil),   // 27: messages.RaceStandingsMessage
+	(*RaceProgress)(nil),           // 28: messages.RaceProgress
+	(*VersusStatusMessage)(nil),    // 29: messages.VersusStatusMessage
+	(*TeamProgress)(nil),           // 30: messages.TeamProgress
+	(*TimeSyncMessage)(nil),        // 31: messages.TimeSyncMessage
+	(*TurnStatusMessage)(nil),      // 32: messages.TurnStatusMessage
+	(*PlayerScore)(nil),            // 33: messages.PlayerScore
+	(*SessionMessage)(nil),         // 34: messages.SessionMessage
+	(*AckMessage)(nil),             // 35: messages.AckMessage
+	(*AuthMessage)(nil),            // 36: messages.AuthMessage
 }
 var file_messages_proto_depIdxs = []int32{
 	3,  // 0: messages.WebSocketMessage.game_state:type_name -> messages.GameStateMessage
-	9,  // 1: messages.WebSocketMessage.chat:type_name -> messages.ChatMessage
-	10, // 2: messages.WebSocketMessage.cursor:type_name -> messages.CursorMessage
-	11, // 3: messages.WebSocketMessage.players:type_name -> messages.PlayersMessage
-	14, // 4: messages.WebSocketMessage.pong:type_name -> messages.PongMessage
-	13, // 5: messages.WebSocketMessage.error:type_name -> messages.ErrorMessage
-	19, // 6: messages.WebSocketMessage.cell_update:type_name -> messages.CellUpdateMessage
-	10, // 7: messages.ClientMessage.cursor:type_name -> messages.CursorMessage
-	16, // 8: messages.ClientMessage.cell_click:type_name -> messages.CellClickMessage
-	17, // 9: messages.ClientMessage.hint:type_name -> messages.HintMessage
-	18, // 10: messages.ClientMessage.new_game:type_name -> messages.NewGameMessage
-	9,  // 11: messages.ClientMessage.chat:type_name -> messages.ChatMessage
-	15, // 12: messages.ClientMessage.ping:type_name -> messages.PingMessage
-	4,  // 13: messages.GameStateMessage.board:type_name -> messages.Board
-	7,  // 14: messages.GameStateMessage.safe_cells:type_name -> messages.SafeCell
-	8,  // 15: messages.GameStateMessage.cell_hints:type_name -> messages.CellHint
-	5,  // 16: messages.Board.rows:type_name -> messages.Row
-	6,  // 17: messages.Row.cells:type_name -> messages.Cell
-	12, // 18: messages.PlayersMessage.players:type_name -> messages.Player
-	20, // 19: messages.CellUpdateMessage.updates:type_name -> messages.CellUpdate
-	0,  // 20: messages.CellUpdate.type:type_name -> messages.CellType
-	21, // [21:21] is the sub-list for method output_type
-	21, // [21:21] is the sub-list for method input_type
-	21, // [21:21] is the sub-list for extension type_name
-	21, // [21:21] is the sub-list for extension extendee
-	0,  // [0:21] is the sub-list for field type_name
+	10, // 1: messages.WebSocketMessage.chat:type_name -> messages.ChatMessage
+	11, // 2: messages.WebSocketMessage.cursor:type_name -> messages.CursorMessage
+	12, // 3: messages.WebSocketMessage.players:type_name -> messages.PlayersMessage
+	15, // 4: messages.WebSocketMessage.pong:type_name -> messages.PongMessage
+	14, // 5: messages.WebSocketMessage.error:type_name -> messages.ErrorMessage
+	23, // 6: messages.WebSocketMessage.cell_update:type_name -> messages.CellUpdateMessage
+	25, // 7: messages.WebSocketMessage.hint_explanation:type_name -> messages.HintExplanationMessage
+	27, // 8: messages.WebSocketMessage.race_standings:type_name -> messages.RaceStandingsMessage
+	29, // 9: messages.WebSocketMessage.versus_status:type_name -> messages.VersusStatusMessage
+	31, // 10: messages.WebSocketMessage.time_sync:type_name -> messages.TimeSyncMessage
+	32, // 11: messages.WebSocketMessage.turn_status:type_name -> messages.TurnStatusMessage
+	34, // 12: messages.WebSocketMessage.session:type_name -> messages.SessionMessage
+	11, // 13: messages.ClientMessage.cursor:type_name -> messages.CursorMessage
+	17, // 14: messages.ClientMessage.cell_click:type_name -> messages.CellClickMessage
+	18, // 15: messages.ClientMessage.hint:type_name -> messages.HintMessage
+	19, // 16: messages.ClientMessage.new_game:type_name -> messages.NewGameMessage
+	10, // 17: messages.ClientMessage.chat:type_name -> messages.ChatMessage
+	16, // 18: messages.ClientMessage.ping:type_name -> messages.PingMessage
+	20, // 19: messages.ClientMessage.add_bot:type_name -> messages.AddBotMessage
+	21, // 20: messages.ClientMessage.remove_bot:type_name -> messages.RemoveBotMessage
+	22, // 21: messages.ClientMessage.set_team:type_name -> messages.SetTeamMessage
+	35, // 22: messages.ClientMessage.ack:type_name -> messages.AckMessage
+	36, // 23: messages.ClientMessage.auth:type_name -> messages.AuthMessage
+	5,  // 24: messages.GameStateMessage.board:type_name -> messages.Board
+	8,  // 25: messages.GameStateMessage.safe_cells:type_name -> messages.SafeCell
+	9,  // 26: messages.GameStateMessage.cell_hints:type_name -> messages.CellHint
+	4,  // 27: messages.GameStateMessage.player_lives:type_name -> messages.PlayerLives
+	6,  // 28: messages.Board.rows:type_name -> messages.Row
+	7,  // 29: messages.Row.cells:type_name -> messages.Cell
+	13, // 30: messages.PlayersMessage.players:type_name -> messages.Player
+	13, // 31: messages.PlayersMessage.spectators:type_name -> messages.Player
+	24, // 32: messages.CellUpdateMessage.updates:type_name -> messages.CellUpdate
+	4,  // 33: messages.CellUpdateMessage.player_lives:type_name -> messages.PlayerLives
+	0,  // 34: messages.CellUpdate.type:type_name -> messages.CellType
+	26, // 35: messages.HintExplanationMessage.labels:type_name -> messages.ExplanationCell
+	26, // 36: messages.HintExplanationMessage.cells:type_name -> messages.ExplanationCell
+	28, // 37: messages.RaceStandingsMessage.racers:type_name -> messages.RaceProgress
+	30, // 38: messages.VersusStatusMessage.teams:type_name -> messages.TeamProgress
+	33, // 39: messages.TurnStatusMessage.scores:type_name -> messages.PlayerScore
+	40, // [40:40] is the sub-list for method output_type
+	40, // [40:40] is the sub-list for method input_type
+	40, // [40:40] is the sub-list for extension type_name
+	40, // [40:40] is the sub-list for extension extendee
+	0,  // [0:40] is the sub-list for field type_name
 }
 
 func init() { file_messages_proto_init() }
@@ -1759,6 +3381,12 @@ func file_messages_proto_init() {
 		(*WebSocketMessage_Pong)(nil),
 		(*WebSocketMessage_Error)(nil),
 		(*WebSocketMessage_CellUpdate)(nil),
+		(*WebSocketMessage_HintExplanation)(nil),
+		(*WebSocketMessage_RaceStandings)(nil),
+		(*WebSocketMessage_VersusStatus)(nil),
+		(*WebSocketMessage_TimeSync)(nil),
+		(*WebSocketMessage_TurnStatus)(nil),
+		(*WebSocketMessage_Session)(nil),
 	}
 	file_messages_proto_msgTypes[1].OneofWrappers = []any{
 		(*ClientMessage_Nickname)(nil),
@@ -1768,6 +3396,11 @@ func file_messages_proto_init() {
 		(*ClientMessage_NewGame)(nil),
 		(*ClientMessage_Chat)(nil),
 		(*ClientMessage_Ping)(nil),
+		(*ClientMessage_AddBot)(nil),
+		(*ClientMessage_RemoveBot)(nil),
+		(*ClientMessage_SetTeam)(nil),
+		(*ClientMessage_Ack)(nil),
+		(*ClientMessage_Auth)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -1775,7 +3408,7 @@ func file_messages_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_messages_proto_rawDesc), len(file_messages_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   20,
+			NumMessages:   36,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
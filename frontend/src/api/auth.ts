@@ -11,13 +11,68 @@ axios.interceptors.request.use((config) => {
   return config
 })
 
+// Один запрос обновления токена на все одновременно получившие 401
+let refreshing: Promise<string> | null = null
+
+async function refreshAccessToken(): Promise<string> {
+  const refreshToken = localStorage.getItem('refreshToken')
+  if (!refreshToken) {
+    throw new Error('no refresh token')
+  }
+  const response = await axios.post<AuthResponse>(`${API_BASE}/auth/refresh`, { refreshToken })
+  localStorage.setItem('token', response.data.token)
+  localStorage.setItem('refreshToken', response.data.refreshToken)
+  return response.data.token
+}
+
+// getFreshToken возвращает access токен, при необходимости продлив его (null - войти не удалось)
+export async function getFreshToken(): Promise<string | null> {
+  const token = localStorage.getItem('token')
+  if (!token) {
+    return null
+  }
+  try {
+    const payload = JSON.parse(atob(token.split('.')[1].replace(/-/g, '+').replace(/_/g, '/')))
+    if (payload.exp * 1000 - Date.now() > 30000) {
+      return token
+    }
+  } catch {
+    // Нечитаемый токен - пробуем получить новый
+  }
+  try {
+    refreshing = refreshing || refreshAccessToken()
+    return await refreshing
+  } catch {
+    return null
+  } finally {
+    refreshing = null
+  }
+}
+
 // Обработка ошибок авторизации
 axios.interceptors.response.use(
   (response) => response,
-  (error) => {
+  async (error) => {
+    const config = error.config
+    const isRefreshRequest = config?.url?.endsWith('/auth/refresh')
+    // Access токен короткоживущий: при 401 пробуем продлить его refresh токеном и повторить запрос
+    if (error.response?.status === 401 && config && !config._retried && !isRefreshRequest && localStorage.getItem('refreshToken')) {
+      config._retried = true
+      try {
+        refreshing = refreshing || refreshAccessToken()
+        const token = await refreshing
+        config.headers.Authorization = `Bearer ${token}`
+        return axios(config)
+      } catch {
+        // Сессия отозвана или истекла - ниже обрабатывается как обычная ошибка авторизации
+      } finally {
+        refreshing = null
+      }
+    }
     if (error.response?.status === 401) {
       // Токен невалидный, очищаем и перенаправляем на страницу входа
       localStorage.removeItem('token')
+      localStorage.removeItem('refreshToken')
       if (window.location.pathname !== '/login' && window.location.pathname !== '/register') {
         window.location.href = '/login'
       }
@@ -34,6 +89,9 @@ export interface User {
   rating: number
   createdAt: string
   isAdmin?: boolean
+  roles?: string[]
+  permissions?: string[]
+  verified?: boolean
 }
 
 export interface RegisterRequest {
@@ -49,9 +107,21 @@ export interface LoginRequest {
 
 export interface AuthResponse {
   token: string
+  refreshToken: string
+  expiresIn: number
   user: User & { isAdmin?: boolean }
 }
 
+export interface AuthSession {
+  id: number
+  userAgent: string
+  ip: string
+  createdAt: string
+  lastUsedAt: string
+  expiresAt: string
+  current: boolean
+}
+
 export async function register(data: RegisterRequest): Promise<AuthResponse> {
   const response = await axios.post<AuthResponse>(`${API_BASE}/auth/register`, data)
   return response.data
@@ -67,6 +137,21 @@ export async function getMe(): Promise<User> {
   return response.data
 }
 
+export async function logoutSession(token: string): Promise<void> {
+  await axios.post(`${API_BASE}/auth/logout`, null, {
+    headers: { Authorization: `Bearer ${token}` }
+  })
+}
+
+export async function getSessions(): Promise<AuthSession[]> {
+  const response = await axios.get<AuthSession[]>(`${API_BASE}/auth/sessions`)
+  return response.data
+}
+
+export async function revokeSession(id: number): Promise<void> {
+  await axios.delete(`${API_BASE}/auth/sessions/${id}`)
+}
+
 export interface RequestPasswordResetRequest {
   email: string
 }
@@ -107,3 +192,23 @@ export async function resetPasswordByAdmin(data: ResetPasswordByAdminRequest): P
   return response.data
 }
 
+export interface UserRoles {
+  userId: number
+  username: string
+  email: string
+  roles: string[]
+}
+
+export async function getUserRoles(): Promise<UserRoles[]> {
+  const response = await axios.get<UserRoles[]>(`${API_BASE}/admin/roles`)
+  return response.data
+}
+
+export async function grantRole(username: string, role: string): Promise<void> {
+  await axios.put(`${API_BASE}/admin/users/${encodeURIComponent(username)}/roles/${role}`)
+}
+
+export async function revokeRole(username: string, role: string): Promise<void> {
+  await axios.delete(`${API_BASE}/admin/users/${encodeURIComponent(username)}/roles/${role}`)
+}
+
@@ -42,10 +42,15 @@ export async function updateColor(color: string): Promise<void> {
 }
 
 export async function changePassword(currentPassword: string, newPassword: string): Promise<void> {
-  await axios.post(`${API_BASE}/profile/change-password`, {
+  const response = await axios.post(`${API_BASE}/profile/change-password`, {
     currentPassword,
     newPassword
   })
+  // Смена пароля отзывает все сессии; текущая получает новые токены
+  if (response.data?.token && response.data?.refreshToken) {
+    localStorage.setItem('token', response.data.token)
+    localStorage.setItem('refreshToken', response.data.refreshToken)
+  }
 }
 
 export interface LeaderboardEntry {
@@ -98,6 +103,7 @@ export interface RecentGame {
   gameTime: number
   rating: number
   won: boolean
+  completed?: boolean  // Игра окончена без победы и поражения (время вышло в игре на счет)
   createdAt: string
   participants: GameParticipant[]
 }
@@ -128,6 +134,7 @@ export interface GameDetails {
   creatorId: number
   creatorName: string
   won: boolean
+  completed?: boolean  // Игра окончена без победы и поражения (время вышло в игре на счет)
   chording: boolean
   quickStart: boolean
   startTime: string
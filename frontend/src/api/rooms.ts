@@ -100,3 +100,7 @@ export async function updateRoom(roomId: string, data: UpdateRoomRequest): Promi
   return response.data
 }
 
+export async function deleteRoom(roomId: string): Promise<void> {
+  await axios.delete(`${API_BASE}/rooms/${roomId}`)
+}
+
@@ -1,7 +1,7 @@
 import { defineStore } from 'pinia'
 import { ref, computed } from 'vue'
 import type { User } from '@/api/auth'
-import { login, register, getMe } from '@/api/auth'
+import { login, register, getMe, logoutSession } from '@/api/auth'
 import { getErrorMessage } from '@/utils/errorHandler'
 
 export const useAuthStore = defineStore('auth', () => {
@@ -34,6 +34,7 @@ export const useAuthStore = defineStore('auth', () => {
       token.value = response.token
       user.value = response.user
       localStorage.setItem('token', response.token)
+      localStorage.setItem('refreshToken', response.refreshToken)
       return response
     } catch (err: any) {
       error.value = getErrorMessage(err, 'Ошибка входа')
@@ -51,6 +52,7 @@ export const useAuthStore = defineStore('auth', () => {
       token.value = response.token
       user.value = response.user
       localStorage.setItem('token', response.token)
+      localStorage.setItem('refreshToken', response.refreshToken)
       return response
     } catch (err: any) {
       error.value = getErrorMessage(err, 'Ошибка регистрации')
@@ -61,9 +63,15 @@ export const useAuthStore = defineStore('auth', () => {
   }
 
   function logout() {
+    // Отзываем сессию на сервере, не дожидаясь ответа (токен мог быть продлен в обход store)
+    const currentToken = localStorage.getItem('token')
+    if (currentToken) {
+      logoutSession(currentToken).catch(() => {})
+    }
     user.value = null
     token.value = null
     localStorage.removeItem('token')
+    localStorage.removeItem('refreshToken')
   }
 
   return {